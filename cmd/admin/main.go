@@ -16,13 +16,13 @@ import (
 	"gorm.io/gorm"
 
 	"go-gin-gorm-starter/internal/core/auth"
+	"go-gin-gorm-starter/internal/core/cache"
 	"go-gin-gorm-starter/internal/core/config"
 	"go-gin-gorm-starter/internal/core/database"
 	"go-gin-gorm-starter/internal/core/logger"
 	"go-gin-gorm-starter/internal/core/server"
-	"go-gin-gorm-starter/internal/repo"
-	"go-gin-gorm-starter/internal/service"
-	"go-gin-gorm-starter/internal/transport/http/handler"
+	httpez "go-gin-gorm-starter/internal/transport/http/ez"
+	resp "go-gin-gorm-starter/internal/transport/http/response"
 	"go-gin-gorm-starter/internal/transport/http/router"
 )
 
@@ -36,18 +36,54 @@ func main() {
 	db := mustOpenDB(cfg, log)
 	log.Info("database connected", zap.String("driver", cfg.DB.Driver))
 
-	// 依赖
+	// 依赖（Redis 可选：幂等键、令牌吊销缓存）
+	rc := newCache(cfg)
+	revocations, err := auth.NewRevocationStore(db, rc)
+	if err != nil {
+		log.Fatal("revocation store", zap.Error(err))
+	}
+	keys, err := cfg.JWT.KeySet()
+	if err != nil {
+		log.Fatal("jwt keys", zap.Error(err))
+	}
 	jwter := &auth.JWTer{
-		Secret: []byte(cfg.JWT.Secret),
-		Issuer: cfg.JWT.Issuer,
-		TTL:    time.Duration(cfg.JWT.AccessTokenTTLMin) * time.Minute,
+		Secret:     []byte(cfg.JWT.Secret),
+		Issuer:     cfg.JWT.Issuer,
+		TTL:        time.Duration(cfg.JWT.AccessTokenTTLMin) * time.Minute,
+		RefreshTTL: time.Duration(cfg.JWT.RefreshTokenTTLDays) * 24 * time.Hour,
+
+		Revocations: revocations,
+		Keys:        keys,
 	}
-	userRepo := repo.NewUserRepo(db)
-	userSvc := service.NewUserService(userRepo)
-	adminH := handler.NewAdminHandler(userSvc)
+	// 游标分页签名密钥（独立配置，多实例需一致）
+	httpez.SetCursorSecret([]byte(cfg.Pagination.CursorSecret))
+	// 幂等键存储（配置了 Redis 用 Redis，否则落库）；响应保留与占位时长取 idempotency.*，未配置时 24h / 1 分钟
+	httpez.SetIdempotencyStore(mustIdempotencyStore(rc, db, log), time.Duration(cfg.Idempotency.RetentionHours)*time.Hour)
+	httpez.SetIdempotencyLockTTL(time.Duration(cfg.Idempotency.LockTTLSec) * time.Second)
 
 	// 路由（后台端）
-	r := router.NewAdminEngine(log, adminH, jwter)
+	if err := resp.CheckCodes(); err != nil {
+		log.Fatal("error codes", zap.Error(err))
+	}
+	mode, err := resp.ParseMode(cfg.App.Admin.ResponseMode)
+	if err != nil {
+		log.Fatal("config", zap.Error(err))
+	}
+	r := router.NewAdminEngine(log, db, jwter, mode)
+	if err := httpez.CheckCursorSecret(); err != nil {
+		log.Fatal("config: set pagination.cursorSecret", zap.Error(err))
+	}
+
+	// 回收站过期清理（本进程注册的、配置了 TrashRetentionDays 的资源；cmd/api 同样启动）
+	sweepCtx, stopSweep := context.WithCancel(context.Background())
+	defer stopSweep()
+	go httpez.StartTrashSweeper(sweepCtx, time.Hour, func(table string, purged int64, err error) {
+		if err != nil {
+			log.Warn("trash sweep failed", zap.String("table", table), zap.Error(err))
+			return
+		}
+		log.Info("trash swept", zap.String("table", table), zap.Int64("purged", purged))
+	})
 
 	// HTTP Server
 	addr := server.Addr(cfg.App.Admin.Host, cfg.App.Admin.Port)
@@ -102,3 +138,22 @@ func mustOpenDB(cfg *config.Config, l *zap.Logger) *gorm.DB {
 	}
 	return db
 }
+
+// 未配置 redis.addr 时返回 nil
+func newCache(cfg *config.Config) *cache.Cache {
+	if cfg.Redis.Addr == "" {
+		return nil
+	}
+	return cache.New(cfg.Redis.Addr, cfg.Redis.Password, cfg.Redis.DB)
+}
+
+func mustIdempotencyStore(rc *cache.Cache, db *gorm.DB, l *zap.Logger) httpez.IdempotencyStore {
+	if rc != nil {
+		return httpez.NewRedisIdempotencyStore(rc)
+	}
+	s, err := httpez.NewDBIdempotencyStore(db)
+	if err != nil {
+		l.Fatal("idempotency store", zap.Error(err))
+	}
+	return s
+}
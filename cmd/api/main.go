@@ -17,11 +17,15 @@ import (
 	"gorm.io/gorm"
 
 	"go-gin-gorm-starter/internal/core/auth"
+	"go-gin-gorm-starter/internal/core/cache"
 	"go-gin-gorm-starter/internal/core/config"
 	"go-gin-gorm-starter/internal/core/database"
 	"go-gin-gorm-starter/internal/core/logger"
+	"go-gin-gorm-starter/internal/core/mailer"
 	"go-gin-gorm-starter/internal/core/server"
 	"go-gin-gorm-starter/internal/feature/user"
+	httpez "go-gin-gorm-starter/internal/transport/http/ez"
+	resp "go-gin-gorm-starter/internal/transport/http/response"
 	"go-gin-gorm-starter/internal/transport/http/router"
 )
 
@@ -44,14 +48,83 @@ func main() {
 	}
 
 	// JWT
+	// Redis（可选）：幂等键、令牌吊销缓存
+	rc := newCache(cfg)
+	revocations, err := auth.NewRevocationStore(db, rc)
+	if err != nil {
+		log.Fatal("revocation store", zap.Error(err))
+	}
+	keys, err := cfg.JWT.KeySet()
+	if err != nil {
+		log.Fatal("jwt keys", zap.Error(err))
+	}
 	jwter := &auth.JWTer{
-		Secret: []byte(cfg.JWT.Secret),
-		Issuer: cfg.JWT.Issuer,
-		TTL:    time.Duration(cfg.JWT.AccessTokenTTLMin) * time.Minute,
+		Secret:     []byte(cfg.JWT.Secret),
+		Issuer:     cfg.JWT.Issuer,
+		TTL:        time.Duration(cfg.JWT.AccessTokenTTLMin) * time.Minute,
+		RefreshTTL: time.Duration(cfg.JWT.RefreshTokenTTLDays) * 24 * time.Hour,
+
+		Revocations: revocations,
+		Keys:        keys,
 	}
 
+	// 游标分页签名密钥（独立配置，多实例需一致）
+	httpez.SetCursorSecret([]byte(cfg.Pagination.CursorSecret))
+	// 幂等键存储（配置了 Redis 用 Redis，否则落库）；响应保留与占位时长取 idempotency.*，未配置时 24h / 1 分钟
+	httpez.SetIdempotencyStore(mustIdempotencyStore(rc, db, log), time.Duration(cfg.Idempotency.RetentionHours)*time.Hour)
+	httpez.SetIdempotencyLockTTL(time.Duration(cfg.Idempotency.LockTTLSec) * time.Second)
+
 	// 路由（用户端）
-	r := router.NewAPIEngine(log, db, jwter)
+	if err := resp.CheckCodes(); err != nil {
+		log.Fatal("error codes", zap.Error(err))
+	}
+	mode, err := resp.ParseMode(cfg.App.HTTP.ResponseMode)
+	if err != nil {
+		log.Fatal("config", zap.Error(err))
+	}
+	// 账号邮件（配置了 mail.dir 写 .eml 文件，否则写日志）
+	ml, err := mailer.New(cfg.Mail.Dir, log)
+	if err != nil {
+		log.Fatal("mailer", zap.Error(err))
+	}
+	if cfg.Account.TokenSecret == "" {
+		log.Fatal("config: account.tokenSecret is required")
+	}
+	r := router.NewAPIEngine(log, db, jwter, router.Mail{
+		Mailer: ml, From: cfg.Mail.From, LinkBaseURL: cfg.Mail.LinkBaseURL,
+		TokenSecret: []byte(cfg.Account.TokenSecret),
+	}, mode)
+	if err := httpez.CheckCursorSecret(); err != nil {
+		log.Fatal("config: set pagination.cursorSecret", zap.Error(err))
+	}
+
+	// 回收站过期清理（本进程注册的、配置了 TrashRetentionDays 的资源；cmd/admin 同样启动）
+	sweepCtx, stopSweep := context.WithCancel(context.Background())
+	defer stopSweep()
+	go httpez.StartTrashSweeper(sweepCtx, time.Hour, func(table string, purged int64, err error) {
+		if err != nil {
+			log.Warn("trash sweep failed", zap.String("table", table), zap.Error(err))
+			return
+		}
+		log.Info("trash swept", zap.String("table", table), zap.Int64("purged", purged))
+	})
+	// 过期令牌的吊销记录清理
+	go func() {
+		t := time.NewTicker(time.Hour)
+		defer t.Stop()
+		for {
+			select {
+			case <-sweepCtx.Done():
+				return
+			case <-t.C:
+				if n, err := revocations.PurgeExpired(sweepCtx); err != nil {
+					log.Warn("revoked tokens purge failed", zap.Error(err))
+				} else if n > 0 {
+					log.Info("revoked tokens purged", zap.Int64("purged", n))
+				}
+			}
+		}
+	}()
 
 	// HTTP Server
 	addr := server.Addr(cfg.App.HTTP.Host, cfg.App.HTTP.Port)
@@ -109,3 +182,22 @@ func mustOpenDB(cfg *config.Config, l *zap.Logger) *gorm.DB {
 	}
 	return db
 }
+
+// 未配置 redis.addr 时返回 nil
+func newCache(cfg *config.Config) *cache.Cache {
+	if cfg.Redis.Addr == "" {
+		return nil
+	}
+	return cache.New(cfg.Redis.Addr, cfg.Redis.Password, cfg.Redis.DB)
+}
+
+func mustIdempotencyStore(rc *cache.Cache, db *gorm.DB, l *zap.Logger) httpez.IdempotencyStore {
+	if rc != nil {
+		return httpez.NewRedisIdempotencyStore(rc)
+	}
+	s, err := httpez.NewDBIdempotencyStore(db)
+	if err != nil {
+		l.Fatal("idempotency store", zap.Error(err))
+	}
+	return s
+}
@@ -0,0 +1,439 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"strings"
+
+	resp "go-gin-gorm-starter/internal/transport/http/response"
+)
+
+/* ================== Go 客户端 ==================
+   一个文件：运行时（Client / Error / 信封解码）+ 类型 + 每个接口一个方法
+   code != 0 → *Error，可用 errors.Is(err, ErrNotFound) 判断
+*/
+
+func genGo(a *api, pkg string) ([]byte, error) {
+	var b bytes.Buffer
+	w := func(format string, args ...any) { fmt.Fprintf(&b, format, args...) }
+
+	usesTime := false
+	for _, t := range a.types {
+		for _, f := range t.fields {
+			usesTime = usesTime || refUses(f.typ, kTime)
+		}
+	}
+	for _, op := range a.ops {
+		for _, p := range op.query {
+			usesTime = usesTime || refUses(p.typ, kTime)
+		}
+	}
+
+	w("// Code generated by ezgen from the %q OpenAPI document. DO NOT EDIT.\n\n", a.title)
+	w("package %s\n\n", pkg)
+	w("import (\n")
+	for _, imp := range []string{"bytes", "context", "encoding/json", "errors", "fmt", "io", "mime/multipart", "net/http", "net/url", "strings"} {
+		w("\t%q\n", imp)
+	}
+	if usesTime {
+		w("\t\"time\"\n")
+	}
+	w(")\n\n")
+	b.WriteString(goRuntime)
+	b.WriteString(goErrors())
+
+	w("\n/* ---------- 类型 ---------- */\n\n")
+	for _, t := range a.types {
+		if t.desc != "" {
+			w("// %s %s\n", t.name, oneLine(t.desc))
+		}
+		w("type %s struct {\n", t.name)
+		used := map[string]bool{}
+		for _, f := range t.fields {
+			name := uniqueIdent(exportName(f.json), used)
+			tag := f.json
+			if !f.required {
+				tag += ",omitempty"
+			}
+			w("\t%s %s `json:%q`", name, goType(f.typ), tag)
+			if f.desc != "" {
+				w(" // %s", oneLine(f.desc))
+			}
+			w("\n")
+		}
+		w("}\n\n")
+	}
+
+	w("/* ---------- 接口 ---------- */\n\n")
+	for _, op := range a.ops {
+		genGoParams(&b, op)
+		genGoMethod(&b, op)
+	}
+
+	out, err := format.Source(b.Bytes())
+	if err != nil {
+		return b.Bytes(), fmt.Errorf("gofmt generated client: %w", err)
+	}
+	return out, nil
+}
+
+func genGoParams(b *bytes.Buffer, op *operation) {
+	if len(op.query) == 0 {
+		return
+	}
+	name := op.name + "Params"
+	fmt.Fprintf(b, "// %s %s 的 query 参数（零值不发送）\ntype %s struct {\n", name, op.name, name)
+	used := map[string]bool{}
+	fields := make([]string, len(op.query))
+	for i, p := range op.query {
+		fields[i] = uniqueIdent(exportName(p.name), used)
+		fmt.Fprintf(b, "\t%s %s", fields[i], goType(p.typ))
+		if p.desc != "" {
+			fmt.Fprintf(b, " // %s", oneLine(p.desc))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("}\n\n")
+
+	fmt.Fprintf(b, "func (p *%s) values() url.Values {\n\tq := url.Values{}\n\tif p == nil {\n\t\treturn q\n\t}\n", name)
+	for i, p := range op.query {
+		f := "p." + fields[i]
+		switch {
+		case p.typ.kind == kString && !p.typ.nullable:
+			fmt.Fprintf(b, "\tif %s != \"\" {\n\t\tq.Set(%q, %s)\n\t}\n", f, p.name, f)
+		case p.typ.kind == kBool && !p.typ.nullable:
+			fmt.Fprintf(b, "\tif %s {\n\t\tq.Set(%q, \"true\")\n\t}\n", f, p.name)
+		case (p.typ.kind == kInt || p.typ.kind == kUint || p.typ.kind == kFloat) && !p.typ.nullable:
+			fmt.Fprintf(b, "\tif %s != 0 {\n\t\tq.Set(%q, fmt.Sprint(%s))\n\t}\n", f, p.name, f)
+		case p.typ.kind == kTime && !p.typ.nullable:
+			fmt.Fprintf(b, "\tif !%s.IsZero() {\n\t\tq.Set(%q, %s.Format(time.RFC3339))\n\t}\n", f, p.name, f)
+		case p.typ.nullable:
+			fmt.Fprintf(b, "\tif %s != nil {\n\t\tq.Set(%q, fmt.Sprint(*%s))\n\t}\n", f, p.name, f)
+		default:
+			fmt.Fprintf(b, "\tif %s != nil {\n\t\tq.Set(%q, fmt.Sprint(%s))\n\t}\n", f, p.name, f)
+		}
+	}
+	b.WriteString("\treturn q\n}\n\n")
+}
+
+func genGoMethod(b *bytes.Buffer, op *operation) {
+	used := map[string]bool{"ctx": true, "params": true, "in": true, "file": true, "filename": true, "out": true, "err": true, "c": true}
+	args := []string{"ctx context.Context"}
+	pathArgs := map[string]string{}
+	for _, p := range op.pathParams {
+		v := uniqueIdent(lowerName(p), used)
+		pathArgs[p] = v
+		args = append(args, v+" string")
+	}
+	query := "nil"
+	if len(op.query) > 0 {
+		args = append(args, "params *"+op.name+"Params")
+		query = "params.values()"
+	}
+	in := "nil"
+	if op.body != nil {
+		args = append(args, "in "+goArgType(op.body))
+		in = "in"
+	}
+	if op.upload != "" {
+		args = append(args, "filename string", "file io.Reader")
+	}
+
+	if op.summary != "" {
+		fmt.Fprintf(b, "// %s %s\n//\n//\t%s %s\n", op.name, oneLine(op.summary), op.method, op.path)
+	} else {
+		fmt.Fprintf(b, "// %s 调用 %s %s\n", op.name, op.method, op.path)
+	}
+	path := goPathExpr(op.path, pathArgs)
+
+	switch {
+	case op.download:
+		fmt.Fprintf(b, "func (c *Client) %s(%s) (io.ReadCloser, error) {\n", op.name, strings.Join(args, ", "))
+		fmt.Fprintf(b, "\treturn c.download(ctx, %q, %s, %s)\n}\n\n", op.method, path, query)
+		return
+	case op.upload != "":
+		fmt.Fprintf(b, "func (c *Client) %s(%s) (%s, error) {\n", op.name, strings.Join(args, ", "), goReturnType(op.out))
+		genGoReturn(b, op.out, fmt.Sprintf("c.upload(ctx, %s, %s, %q, filename, file, &out)", path, query, op.upload))
+		return
+	}
+	fmt.Fprintf(b, "func (c *Client) %s(%s) (%s, error) {\n", op.name, strings.Join(args, ", "), goReturnType(op.out))
+	genGoReturn(b, op.out, fmt.Sprintf("c.call(ctx, %q, %s, %s, %s, %q, &out)", op.method, path, query, in, op.bodyType))
+}
+
+func genGoReturn(b *bytes.Buffer, out *typeRef, call string) {
+	fmt.Fprintf(b, "\tvar out %s\n", goType(out))
+	if out.kind == kNamed {
+		fmt.Fprintf(b, "\tif err := %s; err != nil {\n\t\treturn nil, err\n\t}\n\treturn &out, nil\n}\n\n", call)
+		return
+	}
+	fmt.Fprintf(b, "\terr := %s\n\treturn out, err\n}\n\n", call)
+}
+
+// /api/v1/notes/{id}/restore → "/api/v1/notes/" + url.PathEscape(id) + "/restore"
+func goPathExpr(path string, args map[string]string) string {
+	var parts []string
+	for path != "" {
+		i := strings.IndexByte(path, '{')
+		if i < 0 {
+			parts = append(parts, fmt.Sprintf("%q", path))
+			break
+		}
+		j := strings.IndexByte(path[i:], '}') + i
+		if i > 0 {
+			parts = append(parts, fmt.Sprintf("%q", path[:i]))
+		}
+		parts = append(parts, "url.PathEscape("+args[path[i+1:j]]+")")
+		path = path[j+1:]
+	}
+	return strings.Join(parts, " + ")
+}
+
+func goType(t *typeRef) string {
+	var s string
+	switch t.kind {
+	case kString:
+		s = "string"
+	case kInt:
+		s = "int64"
+	case kUint:
+		s = "uint64"
+	case kFloat:
+		s = "float64"
+	case kBool:
+		s = "bool"
+	case kTime:
+		s = "time.Time"
+	case kBytes:
+		return "[]byte"
+	case kArray:
+		return "[]" + goType(t.elem)
+	case kMap:
+		return "map[string]" + goType(t.elem)
+	case kNamed:
+		return t.name
+	default:
+		return "json.RawMessage"
+	}
+	if t.nullable {
+		return "*" + s
+	}
+	return s
+}
+
+func goArgType(t *typeRef) string {
+	if t.kind == kNamed {
+		return "*" + t.name
+	}
+	return goType(t)
+}
+
+func goReturnType(t *typeRef) string { return goArgType(t) }
+
+func refUses(t *typeRef, k typeKind) bool {
+	return t != nil && (t.kind == k || refUses(t.elem, k))
+}
+
+func uniqueIdent(name string, used map[string]bool) string {
+	if token.IsKeyword(name) {
+		name += "_"
+	}
+	base := name
+	for i := 2; used[name]; i++ {
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+	used[name] = true
+	return name
+}
+
+func oneLine(s string) string { return strings.Join(strings.Fields(s), " ") }
+
+// 与服务端错误码注册表同步的错误哨兵：ErrNotFound = &Error{Code: 404}、ErrEmailTaken = &Error{Code: 10001}
+func goErrors() string {
+	var b strings.Builder
+	b.WriteString("\n// 业务码（errors.Is(err, ErrNotFound)）\nvar (\n")
+	for _, d := range resp.Codes() {
+		if d.Code == resp.CodeOK {
+			continue
+		}
+		fmt.Fprintf(&b, "\tErr%s = &Error{Code: %d, Msg: %q}\n", exportName(d.Key), d.Code, d.Messages[resp.DefaultLang])
+	}
+	b.WriteString(")\n")
+	return b.String()
+}
+
+const goRuntime = `// Client 接口客户端；零值不可用，用 New 构造
+type Client struct {
+	BaseURL string       // 如 http://127.0.0.1:8080（不含路由前缀）
+	Token   string       // Bearer Token，可选
+	HTTP    *http.Client // 默认 http.DefaultClient
+	Header  http.Header  // 每个请求附带的额外请求头
+}
+
+func New(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// Error 业务错误：服务端返回 code != 0
+type Error struct {
+	Code int
+	Msg  string
+	Data json.RawMessage
+}
+
+func (e *Error) Error() string { return fmt.Sprintf("api error %d: %s", e.Code, e.Msg) }
+
+// Is 按 code 比较，配合 ErrXxx 哨兵使用
+func (e *Error) Is(target error) bool {
+	var t *Error
+	return errors.As(target, &t) && t.Code == e.Code
+}
+
+// FieldError 字段级校验错误（400 时在 data.errors 里）
+type FieldError struct {
+	Field   string ` + "`json:\"field\"`" + `
+	Rule    string ` + "`json:\"rule\"`" + `
+	Param   string ` + "`json:\"param,omitempty\"`" + `
+	Message string ` + "`json:\"message\"`" + `
+}
+
+// FieldErrors 解出校验失败的字段错误；非校验错误返回 nil
+func (e *Error) FieldErrors() []FieldError {
+	var d struct {
+		Errors []FieldError ` + "`json:\"errors\"`" + `
+	}
+	_ = json.Unmarshal(e.Data, &d)
+	return d.Errors
+}
+
+// 统一信封；服务端为 problem 模式时错误体是 RFC 7807（code 同样在顶层，消息在 detail）
+type envelope struct {
+	Code   int             ` + "`json:\"code\"`" + `
+	Msg    string          ` + "`json:\"msg\"`" + `
+	Detail string          ` + "`json:\"detail\"`" + `
+	Data   json.RawMessage ` + "`json:\"data\"`" + `
+}
+
+type headerKey struct{}
+
+// WithHeader 给单次请求附带请求头，如 WithHeader(ctx, "Idempotency-Key", key)
+func WithHeader(ctx context.Context, key, value string) context.Context {
+	h, _ := ctx.Value(headerKey{}).(http.Header)
+	h = h.Clone()
+	if h == nil {
+		h = http.Header{}
+	}
+	h.Set(key, value)
+	return context.WithValue(ctx, headerKey{}, h)
+}
+
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, body io.Reader, contentType string) (*http.Response, error) {
+	u := c.BaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range c.Header {
+		req.Header[k] = v
+	}
+	if h, ok := ctx.Value(headerKey{}).(http.Header); ok {
+		for k, v := range h {
+			req.Header[k] = v
+		}
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	hc := c.HTTP
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	return hc.Do(req)
+}
+
+// decode 解统一信封 {code,msg,data}：code != 0 → *Error，否则把 data 解到 out
+func decode(res *http.Response, out any) error {
+	defer res.Body.Close()
+	var env envelope
+	if err := json.NewDecoder(res.Body).Decode(&env); err != nil {
+		if res.StatusCode != http.StatusOK {
+			return &Error{Code: res.StatusCode, Msg: http.StatusText(res.StatusCode)}
+		}
+		return fmt.Errorf("decode response: %w", err)
+	}
+	if env.Code != 0 {
+		if env.Msg == "" {
+			env.Msg = env.Detail
+		}
+		return &Error{Code: env.Code, Msg: env.Msg, Data: env.Data}
+	}
+	if out == nil || len(env.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(env.Data, out)
+}
+
+func (c *Client) call(ctx context.Context, method, path string, query url.Values, in any, contentType string, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	res, err := c.send(ctx, method, path, query, body, contentType)
+	if err != nil {
+		return err
+	}
+	return decode(res, out)
+}
+
+// upload multipart 上传（边读边发）
+func (c *Client) upload(ctx context.Context, path string, query url.Values, field, filename string, file io.Reader, out any) error {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		part, err := mw.CreateFormFile(field, filename)
+		if err == nil {
+			_, err = io.Copy(part, file)
+		}
+		if err == nil {
+			err = mw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	res, err := c.send(ctx, http.MethodPost, path, query, pr, mw.FormDataContentType())
+	if err != nil {
+		return err
+	}
+	return decode(res, out)
+}
+
+// download 文件下载；服务端出错时返回的是 JSON 信封
+func (c *Client) download(ctx context.Context, method, path string, query url.Values) (io.ReadCloser, error) {
+	res, err := c.send(ctx, method, path, query, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	if ct := res.Header.Get("Content-Type"); strings.HasPrefix(ct, "application/json") || strings.HasPrefix(ct, "application/problem+json") {
+		if err := decode(res, nil); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("unexpected JSON response")
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, &Error{Code: res.StatusCode, Msg: http.StatusText(res.StatusCode)}
+	}
+	return res.Body, nil
+}
+`
@@ -0,0 +1,411 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+/* ================== OpenAPI → 中间表示 ==================
+   只认 ez 生成的那部分 OpenAPI：$ref、object/array/map、基础类型、nullable、enum、oneOf（退化为任意值）
+   内联对象按“所在位置”命名为具名类型（ListNotesResponse、ListNotesResponseItems…）
+*/
+
+type oaSchema struct {
+	Ref                  string               `json:"$ref"`
+	Type                 json.RawMessage      `json:"type"` // "string" 或 ["string","null"]
+	Format               string               `json:"format"`
+	Description          string               `json:"description"`
+	Properties           map[string]*oaSchema `json:"properties"`
+	Required             []string             `json:"required"`
+	Items                *oaSchema            `json:"items"`
+	AdditionalProperties *oaSchema            `json:"additionalProperties"`
+	Enum                 []any                `json:"enum"`
+	OneOf                []*oaSchema          `json:"oneOf"`
+	Minimum              *float64             `json:"minimum"`
+	WriteOnly            bool                 `json:"writeOnly"`
+}
+
+type oaParam struct {
+	Name        string    `json:"name"`
+	In          string    `json:"in"`
+	Required    bool      `json:"required"`
+	Description string    `json:"description"`
+	Schema      *oaSchema `json:"schema"`
+}
+
+type oaMedia struct {
+	Schema *oaSchema `json:"schema"`
+}
+
+type oaOperation struct {
+	OperationID string             `json:"operationId"`
+	Summary     string             `json:"summary"`
+	Tags        []string           `json:"tags"`
+	Parameters  []oaParam          `json:"parameters"`
+	Security    []map[string][]any `json:"security"`
+	RequestBody *struct {
+		Content map[string]oaMedia `json:"content"`
+	} `json:"requestBody"`
+	Responses map[string]struct {
+		Content map[string]oaMedia `json:"content"`
+	} `json:"responses"`
+}
+
+type oaDoc struct {
+	Info struct {
+		Title   string `json:"title"`
+		Version string `json:"version"`
+	} `json:"info"`
+	Paths      map[string]map[string]*oaOperation `json:"paths"`
+	Components struct {
+		Schemas map[string]*oaSchema `json:"schemas"`
+	} `json:"components"`
+}
+
+/* ---------- 中间表示 ---------- */
+
+type typeKind int
+
+const (
+	kAny typeKind = iota
+	kString
+	kInt
+	kUint
+	kFloat
+	kBool
+	kTime
+	kBytes
+	kArray
+	kMap
+	kNamed
+)
+
+type typeRef struct {
+	kind     typeKind
+	name     string   // kNamed
+	elem     *typeRef // kArray / kMap
+	nullable bool
+	enum     []string
+}
+
+type field struct {
+	json     string
+	typ      *typeRef
+	required bool
+	desc     string
+}
+
+type namedType struct {
+	name   string
+	desc   string
+	fields []field
+}
+
+type param struct {
+	name     string
+	typ      *typeRef
+	required bool
+	desc     string
+}
+
+type operation struct {
+	name       string // PascalCase
+	method     string
+	path       string // /api/v1/notes/{id}
+	summary    string
+	tag        string
+	secured    bool
+	pathParams []string
+	query      []param
+	body       *typeRef
+	bodyType   string // Content-Type
+	upload     string // multipart 文件字段名
+	download   bool   // 文件下载（非 JSON 信封）
+	out        *typeRef
+}
+
+type api struct {
+	title string
+	types []*namedType
+	ops   []*operation
+	seen  map[string]bool
+	comps map[string]string // 组件名 → 类型名
+}
+
+func loadAPI(raw []byte) (*api, error) {
+	var doc oaDoc
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("parse openapi: %w", err)
+	}
+	a := &api{title: doc.Info.Title, seen: map[string]bool{}, comps: map[string]string{}}
+	// 运行时已占用的名字（Go / TS 客户端里手写的类型）
+	for _, n := range []string{"Client", "Error", "FieldError", "ApiError", "ApiClient", "BaseClient", "ClientOptions", "Envelope", "Query", "ErrorCodes"} {
+		a.seen[n] = true
+	}
+
+	names := make([]string, 0, len(doc.Components.Schemas))
+	for n := range doc.Components.Schemas {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	// 先定名再展开字段：组件之间可以互相引用
+	for _, n := range names {
+		if n == "FieldError" {
+			a.comps[n] = n // 与运行时内置的 FieldError 同构，直接复用
+			continue
+		}
+		a.comps[n] = a.unique(exportName(n))
+	}
+	for _, n := range names {
+		if n != "FieldError" {
+			a.fill(a.comps[n], doc.Components.Schemas[n])
+		}
+	}
+
+	paths := make([]string, 0, len(doc.Paths))
+	for p := range doc.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		methods := make([]string, 0, len(doc.Paths[p]))
+		for m := range doc.Paths[p] {
+			methods = append(methods, m)
+		}
+		sort.Strings(methods)
+		for _, m := range methods {
+			if err := a.operation(p, m, doc.Paths[p][m]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	sort.Slice(a.types, func(i, j int) bool { return a.types[i].name < a.types[j].name })
+	return a, nil
+}
+
+func (a *api) operation(path, method string, o *oaOperation) error {
+	if o.OperationID == "" {
+		return fmt.Errorf("%s %s: missing operationId", strings.ToUpper(method), path)
+	}
+	op := &operation{
+		name: exportName(o.OperationID), method: strings.ToUpper(method), path: path,
+		summary: o.Summary, secured: len(o.Security) > 0,
+	}
+	if len(o.Tags) > 0 {
+		op.tag = o.Tags[0]
+	}
+	for _, p := range o.Parameters {
+		switch p.In {
+		case "path":
+			op.pathParams = append(op.pathParams, p.Name)
+		case "query":
+			op.query = append(op.query, param{
+				name: p.Name, typ: a.ref(op.name+"Params"+exportName(p.Name), p.Schema),
+				required: p.Required, desc: p.Description,
+			})
+		}
+	}
+	if o.RequestBody != nil {
+		c := o.RequestBody.Content
+		switch {
+		case c["multipart/form-data"].Schema != nil:
+			op.upload = "file"
+			props := c["multipart/form-data"].Schema.Properties
+			names := make([]string, 0, len(props))
+			for name := range props {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				if props[name].Format == "binary" {
+					op.upload = name
+					break
+				}
+			}
+		case c["application/merge-patch+json"].Schema != nil:
+			op.bodyType = "application/merge-patch+json"
+			op.body = a.ref(op.name+"Request", c[op.bodyType].Schema)
+		case c["application/json"].Schema != nil:
+			op.bodyType = "application/json"
+			op.body = a.ref(op.name+"Request", c[op.bodyType].Schema)
+		}
+	}
+	res := o.Responses["200"].Content
+	if env := res["application/json"].Schema; env != nil {
+		if data := env.Properties["data"]; data != nil {
+			op.out = a.ref(op.name+"Response", data)
+		}
+	} else if len(res) > 0 {
+		op.download = true
+	}
+	if op.out == nil {
+		op.out = &typeRef{kind: kAny}
+	}
+	a.ops = append(a.ops, op)
+	return nil
+}
+
+// ref 把 schema 转成类型引用；内联对象以 hint 命名登记
+func (a *api) ref(hint string, s *oaSchema) *typeRef {
+	if s == nil {
+		return &typeRef{kind: kAny}
+	}
+	if s.Ref != "" {
+		return &typeRef{kind: kNamed, name: a.comps[strings.TrimPrefix(s.Ref, "#/components/schemas/")]}
+	}
+	typ, nullable := schemaType(s.Type)
+	t := &typeRef{nullable: nullable}
+	for _, e := range s.Enum {
+		t.enum = append(t.enum, fmt.Sprint(e))
+	}
+	switch typ {
+	case "string":
+		switch s.Format {
+		case "date-time":
+			t.kind = kTime
+		case "byte":
+			t.kind = kBytes
+		default:
+			t.kind = kString
+		}
+	case "integer":
+		t.kind = kInt
+		if s.Format == "" && s.Minimum != nil && *s.Minimum == 0 {
+			t.kind = kUint
+		}
+	case "number":
+		t.kind = kFloat
+	case "boolean":
+		t.kind = kBool
+	case "array":
+		t.kind, t.elem = kArray, a.ref(hint+"Item", s.Items)
+	case "object":
+		switch {
+		case len(s.Properties) > 0:
+			t.kind, t.name = kNamed, a.object(hint, s)
+		case s.AdditionalProperties != nil:
+			t.kind, t.elem = kMap, a.ref(hint+"Value", s.AdditionalProperties)
+		default:
+			t.kind, t.elem = kMap, &typeRef{kind: kAny}
+		}
+	}
+	return t
+}
+
+// object 登记内联对象类型，返回实际名字
+func (a *api) object(hint string, s *oaSchema) string {
+	name := a.unique(hint)
+	a.fill(name, s)
+	return name
+}
+
+// unique 重名时加序号
+func (a *api) unique(name string) string {
+	base := name
+	for i := 2; a.seen[name]; i++ {
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+	a.seen[name] = true
+	return name
+}
+
+func (a *api) fill(name string, s *oaSchema) {
+	nt := &namedType{name: name, desc: s.Description}
+	a.types = append(a.types, nt)
+
+	req := map[string]bool{}
+	for _, r := range s.Required {
+		req[r] = true
+	}
+	keys := make([]string, 0, len(s.Properties))
+	for k := range s.Properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		p := s.Properties[k]
+		nt.fields = append(nt.fields, field{
+			json: k, typ: a.ref(name+exportName(k), p), required: req[k], desc: p.Description,
+		})
+	}
+}
+
+func schemaType(raw json.RawMessage) (string, bool) {
+	if len(raw) == 0 {
+		return "", false
+	}
+	var one string
+	if json.Unmarshal(raw, &one) == nil {
+		return one, false
+	}
+	var many []string
+	_ = json.Unmarshal(raw, &many)
+	typ, nullable := "", false
+	for _, t := range many {
+		if t == "null" {
+			nullable = true
+		} else {
+			typ = t
+		}
+	}
+	return typ, nullable
+}
+
+/* ---------- 命名 ---------- */
+
+var nonIdent = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// 常见缩写整体大写（Go 风格）
+var initialisms = map[string]string{"id": "ID", "url": "URL", "uri": "URI", "uuid": "UUID", "api": "API", "http": "HTTP", "ip": "IP", "json": "JSON"}
+
+func splitWords(s string) []string {
+	var words []string
+	for _, part := range nonIdent.Split(s, -1) {
+		start := 0
+		rs := []rune(part)
+		for i := 1; i < len(rs); i++ {
+			// camelCase 边界：小写→大写，或 ABCd 里的 C→d
+			if unicode.IsUpper(rs[i]) && (unicode.IsLower(rs[i-1]) || (i+1 < len(rs) && unicode.IsLower(rs[i+1]) && unicode.IsUpper(rs[i-1]))) {
+				words = append(words, string(rs[start:i]))
+				start = i
+			}
+		}
+		if start < len(rs) {
+			words = append(words, string(rs[start:]))
+		}
+	}
+	return words
+}
+
+func exportName(s string) string {
+	var b strings.Builder
+	for _, w := range splitWords(s) {
+		if up, ok := initialisms[strings.ToLower(w)]; ok {
+			b.WriteString(up)
+			continue
+		}
+		rs := []rune(w)
+		b.WriteString(strings.ToUpper(string(rs[0])) + string(rs[1:]))
+	}
+	out := b.String()
+	if out == "" || unicode.IsDigit([]rune(out)[0]) {
+		out = "X" + out
+	}
+	return out
+}
+
+func lowerName(s string) string {
+	words := splitWords(s)
+	if len(words) == 0 {
+		return "x"
+	}
+	out := strings.ToLower(words[0])
+	if len(words) > 1 {
+		out += exportName(strings.Join(words[1:], "_"))
+	}
+	return out
+}
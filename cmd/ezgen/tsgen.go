@@ -0,0 +1,313 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	resp "go-gin-gorm-starter/internal/transport/http/response"
+)
+
+/* ================== TypeScript 客户端 ==================
+   单文件、零依赖（fetch）；code != 0 → 抛 ApiError
+*/
+
+func genTS(a *api) []byte {
+	var b strings.Builder
+	w := func(format string, args ...any) { fmt.Fprintf(&b, format, args...) }
+
+	w("// Code generated by ezgen from the %q OpenAPI document. DO NOT EDIT.\n/* eslint-disable */\n\n", a.title)
+	b.WriteString(tsRuntime)
+	b.WriteString(tsErrorCodes())
+
+	w("\n/* ---------- 类型 ---------- */\n\n")
+	for _, t := range a.types {
+		if t.desc != "" {
+			w("/** %s */\n", oneLine(t.desc))
+		}
+		w("export interface %s {\n", t.name)
+		for _, f := range t.fields {
+			if f.desc != "" {
+				w("  /** %s */\n", oneLine(f.desc))
+			}
+			opt := "?"
+			if f.required {
+				opt = ""
+			}
+			w("  %s%s: %s;\n", tsKey(f.json), opt, tsType(f.typ))
+		}
+		w("}\n\n")
+	}
+	for _, op := range a.ops {
+		if len(op.query) == 0 {
+			continue
+		}
+		w("export interface %sParams {\n", op.name)
+		for _, p := range op.query {
+			if p.desc != "" {
+				w("  /** %s */\n", oneLine(p.desc))
+			}
+			opt := "?"
+			if p.required {
+				opt = ""
+			}
+			w("  %s%s: %s;\n", tsKey(p.name), opt, tsType(p.typ))
+		}
+		w("}\n\n")
+	}
+
+	w("/* ---------- 接口 ---------- */\n\n")
+	w("export class ApiClient extends BaseClient {\n")
+	for _, op := range a.ops {
+		genTSMethod(&b, op)
+	}
+	w("}\n")
+	return []byte(b.String())
+}
+
+func genTSMethod(b *strings.Builder, op *operation) {
+	used := map[string]bool{"params": true, "body": true, "file": true, "init": true}
+	var args []string
+	pathArgs := map[string]string{}
+	for _, p := range op.pathParams {
+		v := lowerName(p)
+		for used[v] {
+			v += "_"
+		}
+		used[v] = true
+		pathArgs[p] = v
+		args = append(args, v+": string | number")
+	}
+	query := "undefined"
+	if len(op.query) > 0 {
+		opt := "?"
+		for _, p := range op.query {
+			if p.required {
+				opt = ""
+			}
+		}
+		if opt != "" && (op.body != nil || op.upload != "") {
+			// 后面还有必填参数时不能用可选参数（TS 报错），改成默认值
+			args = append(args, fmt.Sprintf("params: %sParams = {}", op.name))
+		} else {
+			args = append(args, fmt.Sprintf("params%s: %sParams", opt, op.name))
+		}
+		query = "params"
+	}
+	body := "undefined"
+	if op.body != nil {
+		args = append(args, "body: "+tsType(op.body))
+		body = "body"
+	}
+	if op.upload != "" {
+		args = append(args, "file: Blob", "filename?: string")
+	}
+	args = append(args, "init?: RequestInit")
+
+	name := lowerName(op.name)
+	path := tsPathExpr(op.path, pathArgs)
+	if op.summary != "" {
+		fmt.Fprintf(b, "  /** %s — `%s %s` */\n", oneLine(op.summary), op.method, op.path)
+	} else {
+		fmt.Fprintf(b, "  /** `%s %s` */\n", op.method, op.path)
+	}
+	switch {
+	case op.download:
+		fmt.Fprintf(b, "  %s(%s): Promise<Blob> {\n", name, strings.Join(args, ", "))
+		fmt.Fprintf(b, "    return this.download(%q, %s, %s, init);\n  }\n\n", op.method, path, query)
+	case op.upload != "":
+		fmt.Fprintf(b, "  %s(%s): Promise<%s> {\n", name, strings.Join(args, ", "), tsType(op.out))
+		fmt.Fprintf(b, "    const form = new FormData();\n    form.append(%q, file, filename);\n", op.upload)
+		fmt.Fprintf(b, "    return this.request(%q, %s, %s, form, undefined, init);\n  }\n\n", op.method, path, query)
+	default:
+		ct := "undefined"
+		if op.bodyType != "" {
+			ct = fmt.Sprintf("%q", op.bodyType)
+		}
+		fmt.Fprintf(b, "  %s(%s): Promise<%s> {\n", name, strings.Join(args, ", "), tsType(op.out))
+		fmt.Fprintf(b, "    return this.request(%q, %s, %s, %s, %s, init);\n  }\n\n", op.method, path, query, body, ct)
+	}
+}
+
+// /api/v1/notes/{id} → `/api/v1/notes/${encodeURIComponent(String(id))}`
+func tsPathExpr(path string, args map[string]string) string {
+	if !strings.Contains(path, "{") {
+		return fmt.Sprintf("%q", path)
+	}
+	var b strings.Builder
+	b.WriteByte('`')
+	for path != "" {
+		i := strings.IndexByte(path, '{')
+		if i < 0 {
+			b.WriteString(path)
+			break
+		}
+		j := strings.IndexByte(path[i:], '}') + i
+		b.WriteString(path[:i])
+		b.WriteString("${encodeURIComponent(String(" + args[path[i+1:j]] + "))}")
+		path = path[j+1:]
+	}
+	b.WriteByte('`')
+	return b.String()
+}
+
+func tsType(t *typeRef) string {
+	var s string
+	switch {
+	case len(t.enum) > 0:
+		lits := make([]string, len(t.enum))
+		for i, e := range t.enum {
+			if t.kind == kString {
+				q, _ := json.Marshal(e)
+				lits[i] = string(q)
+			} else {
+				lits[i] = e
+			}
+		}
+		s = strings.Join(lits, " | ")
+	case t.kind == kString || t.kind == kTime || t.kind == kBytes:
+		s = "string"
+	case t.kind == kInt || t.kind == kUint || t.kind == kFloat:
+		s = "number"
+	case t.kind == kBool:
+		s = "boolean"
+	case t.kind == kArray:
+		s = tsType(t.elem)
+		if strings.ContainsAny(s, " |") {
+			s = "(" + s + ")"
+		}
+		s += "[]"
+	case t.kind == kMap:
+		s = "Record<string, " + tsType(t.elem) + ">"
+	case t.kind == kNamed:
+		s = t.name
+	default:
+		s = "unknown"
+	}
+	if t.nullable {
+		s += " | null"
+	}
+	return s
+}
+
+var tsIdent = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+func tsKey(k string) string {
+	if !tsIdent.MatchString(k) {
+		q, _ := json.Marshal(k)
+		return string(q)
+	}
+	return k
+}
+
+func tsErrorCodes() string {
+	var b strings.Builder
+	b.WriteString("\n/** 业务码（与服务端错误码注册表同步） */\nexport const ErrorCodes = {\n")
+	for _, d := range resp.Codes() {
+		if d.Code != resp.CodeOK {
+			fmt.Fprintf(&b, "  %s: %d,\n", exportName(d.Key), d.Code)
+		}
+	}
+	b.WriteString("} as const;\n")
+	return b.String()
+}
+
+const tsRuntime = `export interface ClientOptions {
+  /** 如 http://127.0.0.1:8080（不含路由前缀） */
+  baseURL: string;
+  /** Bearer Token；传函数则每次请求时取值 */
+  token?: string | (() => string | undefined | null);
+  headers?: Record<string, string>;
+  fetch?: typeof fetch;
+}
+
+/** 字段级校验错误（400 时在 data.errors 里） */
+export interface FieldError {
+  field: string;
+  rule: string;
+  param?: string;
+  message: string;
+}
+
+/** 业务错误：服务端返回 code != 0 */
+export class ApiError extends Error {
+  constructor(public readonly code: number, message: string, public readonly data?: unknown) {
+    super(message);
+    this.name = "ApiError";
+  }
+
+  /** 校验失败的字段错误；非校验错误为空数组 */
+  get fieldErrors(): FieldError[] {
+    const errors = (this.data as { errors?: FieldError[] } | undefined)?.errors;
+    return Array.isArray(errors) ? errors : [];
+  }
+}
+
+/** 统一信封；服务端为 problem 模式时错误体是 RFC 7807（code 同样在顶层，消息在 detail） */
+interface Envelope<T> {
+  code: number;
+  msg?: string;
+  detail?: string;
+  data: T;
+}
+
+type Query = object | undefined;
+
+class BaseClient {
+  constructor(protected readonly opts: ClientOptions) {}
+
+  protected url(path: string, query: Query): string {
+    const qs = new URLSearchParams();
+    for (const [k, v] of Object.entries(query ?? {})) {
+      if (v !== undefined && v !== null && v !== "") qs.append(k, String(v));
+    }
+    const s = qs.toString();
+    return this.opts.baseURL.replace(/\/+$/, "") + path + (s ? "?" + s : "");
+  }
+
+  protected headers(contentType?: string, extra?: HeadersInit): Headers {
+    const h = new Headers(this.opts.headers);
+    new Headers(extra).forEach((v, k) => h.set(k, v));
+    h.set("Accept", "application/json");
+    if (contentType) h.set("Content-Type", contentType);
+    const token = typeof this.opts.token === "function" ? this.opts.token() : this.opts.token;
+    if (token) h.set("Authorization", "Bearer " + token);
+    return h;
+  }
+
+  protected async send(method: string, path: string, query: Query, body: BodyInit | undefined, contentType: string | undefined, init?: RequestInit): Promise<Response> {
+    const f = this.opts.fetch ?? fetch;
+    return f(this.url(path, query), { ...init, method, body, headers: this.headers(contentType, init?.headers) });
+  }
+
+  protected async request<T>(method: string, path: string, query: Query, body: unknown, contentType: string | undefined, init?: RequestInit): Promise<T> {
+    const payload = body === undefined || body instanceof FormData ? (body as BodyInit | undefined) : JSON.stringify(body);
+    const res = await this.send(method, path, query, payload, contentType, init);
+    return this.decode<T>(res);
+  }
+
+  protected async download(method: string, path: string, query: Query, init?: RequestInit): Promise<Blob> {
+    const res = await this.send(method, path, query, undefined, undefined, init);
+    const ct = res.headers.get("Content-Type") ?? "";
+    if (ct.startsWith("application/json") || ct.startsWith("application/problem+json")) {
+      await this.decode(res);
+      throw new ApiError(-1, "unexpected JSON response");
+    }
+    if (!res.ok) throw new ApiError(res.status, res.statusText);
+    return res.blob();
+  }
+
+  /** 解统一信封 {code,msg,data} */
+  protected async decode<T>(res: Response): Promise<T> {
+    let env: Envelope<T>;
+    try {
+      env = await res.json();
+    } catch {
+      throw new ApiError(res.status, res.statusText || "invalid response");
+    }
+    if (env.code !== 0) throw new ApiError(env.code, env.msg ?? env.detail ?? "", env.data);
+    return env.data;
+  }
+}
+`
@@ -1,47 +1,76 @@
 package auth
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+
+	"go-gin-gorm-starter/pkg/utils"
 )
 
 type Claims struct {
 	UID  string `json:"uid"`
 	Role string `json:"role"` // "user" or "admin"
+	Ver  int    `json:"ver"`  // 签发时的用户令牌版本（见 RevocationStore）
+
+	TenantID string `json:"tid,omitempty"`
 	jwt.RegisteredClaims
 }
 
 type JWTer struct {
-	Secret []byte
-	Issuer string
-	TTL    time.Duration
+	Secret     []byte
+	Issuer     string
+	TTL        time.Duration
+	RefreshTTL time.Duration // 刷新令牌有效期（见 RefreshStore），默认 30 天
+
+	Revocations *RevocationStore // 可选：签发时写入用户版本，AuthJWT 校验吊销
+
+	// 可选：非对称签名（RS256/ES256/EdDSA，见 KeySet）。设置后用 Keys.Active 签发；
+	// Secret 仍非空时继续接受不带 kid 的 HS256 旧令牌（迁移期），迁完清空 Secret 即可
+	Keys *KeySet
 }
 
 func (j *JWTer) Issue(uid, role string) (string, error) {
+	return j.IssueContext(context.Background(), uid, role)
+}
+
+func (j *JWTer) IssueContext(ctx context.Context, uid, role string) (string, error) {
+	ver := 0
+	if j.Revocations != nil {
+		v, err := j.Revocations.Version(ctx, uid)
+		if err != nil {
+			return "", err
+		}
+		ver = v
+	}
 	now := time.Now()
 	claims := Claims{
 		UID:  uid,
 		Role: role,
+		Ver:  ver,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        utils.NewID(),
 			Issuer:    j.Issuer,
 			IssuedAt:  jwt.NewNumericDate(now),
 			ExpiresAt: jwt.NewNumericDate(now.Add(j.TTL)),
 		},
 	}
+	if j.Keys != nil {
+		k := j.Keys.signer()
+		token := jwt.NewWithClaims(k.Alg, claims)
+		token.Header["kid"] = k.ID
+		return token.SignedString(k.Private)
+	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString(j.Secret)
 }
 
 func (j *JWTer) Parse(tokenStr string) (*Claims, error) {
-	t, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if token.Method != jwt.SigningMethodHS256 {
-			return nil, fmt.Errorf("unexpected alg")
-		}
-		return j.Secret, nil
-	}, jwt.WithIssuer(j.Issuer), jwt.WithLeeway(60*time.Second))
+	t, err := jwt.ParseWithClaims(tokenStr, &Claims{}, j.keyFunc,
+		jwt.WithValidMethods(j.validMethods()), jwt.WithIssuer(j.Issuer), jwt.WithLeeway(60*time.Second))
 
 	if err != nil {
 		return nil, err
@@ -51,3 +80,47 @@ func (j *JWTer) Parse(tokenStr string) (*Claims, error) {
 	}
 	return nil, errors.New("invalid token")
 }
+
+// 按 header 选验证密钥：带 kid 查密钥集（算法须与密钥一致），不带 kid 的按 HS256 用 Secret
+func (j *JWTer) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		if token.Method != jwt.SigningMethodHS256 || len(j.Secret) == 0 {
+			return nil, fmt.Errorf("unexpected alg")
+		}
+		return j.Secret, nil
+	}
+	if j.Keys == nil {
+		return nil, fmt.Errorf("unexpected kid")
+	}
+	k := j.Keys.Keys[kid]
+	if k == nil {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+	if token.Method.Alg() != k.Alg.Alg() {
+		return nil, fmt.Errorf("unexpected alg")
+	}
+	return k.Public, nil
+}
+
+func (j *JWTer) validMethods() []string {
+	var out []string
+	if len(j.Secret) > 0 || j.Keys == nil {
+		out = append(out, jwt.SigningMethodHS256.Alg())
+	}
+	if j.Keys != nil {
+		out = append(out, j.Keys.algs()...)
+	}
+	return out
+}
+
+// JWKS 公开的验证公钥（未配置 Keys 时为空集）
+func (j *JWTer) JWKS() JWKS { return j.Keys.JWKS() }
+
+// Revoked 令牌是否已被吊销（未配置 Revocations 时恒为 nil）
+func (j *JWTer) Revoked(ctx context.Context, c *Claims) error {
+	if j.Revocations == nil {
+		return nil
+	}
+	return j.Revocations.Check(ctx, c)
+}
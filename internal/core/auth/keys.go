@@ -0,0 +1,222 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+/* ================== 非对称签名密钥集 ==================
+   JWTer.Keys 不为空时用非对称算法签发（header 带 kid），验证按 kid 选公钥：
+   - 算法由密钥类型决定：RSA → RS256，ECDSA P-256/P-384 → ES256/ES384，Ed25519 → EdDSA
+   - 轮换：新密钥设为 Active 签发，旧密钥只留公钥（仅验证），等旧令牌全部过期后再移除
+   - 公钥通过 JWKS（/.well-known/jwks.json）发布，下游服务无需持有私钥
+*/
+
+// Key 一把签名 / 验证密钥；Private 为空时只用于验证
+type Key struct {
+	ID      string
+	Alg     jwt.SigningMethod
+	Private crypto.Signer
+	Public  crypto.PublicKey
+}
+
+// KeySet 密钥集：Active 为签发用的 kid
+type KeySet struct {
+	Active string
+	Keys   map[string]*Key
+}
+
+// KeyFile 从 PEM 文件加载的一把密钥；PrivateKey 与 PublicKey 至少填一个
+type KeyFile struct {
+	ID         string
+	PrivateKey string // PKCS#8 / PKCS#1 / SEC1 私钥路径
+	PublicKey  string // PKIX 公钥路径（只验证的旧密钥）
+}
+
+// LoadKeySet 读取 PEM 文件组成密钥集；active 必须是带私钥的那把
+func LoadKeySet(active string, files []KeyFile) (*KeySet, error) {
+	ks := &KeySet{Active: active, Keys: map[string]*Key{}}
+	for _, f := range files {
+		if f.ID == "" {
+			return nil, errors.New("jwt key: kid is required")
+		}
+		if _, dup := ks.Keys[f.ID]; dup {
+			return nil, fmt.Errorf("jwt key %q: duplicate kid", f.ID)
+		}
+		var k *Key
+		var err error
+		switch {
+		case f.PrivateKey != "":
+			k, err = loadPrivateKey(f.PrivateKey)
+		case f.PublicKey != "":
+			k, err = loadPublicKey(f.PublicKey)
+		default:
+			err = errors.New("privateKey or publicKey is required")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %q: %w", f.ID, err)
+		}
+		k.ID = f.ID
+		ks.Keys[f.ID] = k
+	}
+	if k := ks.Keys[active]; k == nil || k.Private == nil {
+		return nil, fmt.Errorf("jwt key: active kid %q has no private key", active)
+	}
+	return ks, nil
+}
+
+func (ks *KeySet) signer() *Key { return ks.Keys[ks.Active] }
+
+// 全部验证算法（jwt.WithValidMethods 用）
+func (ks *KeySet) algs() []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, k := range ks.Keys {
+		if a := k.Alg.Alg(); !seen[a] {
+			seen[a] = true
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM block", path)
+	}
+	return block, nil
+}
+
+func loadPrivateKey(path string) (*Key, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	var priv any
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		priv, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		priv, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		priv, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := priv.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key %T", priv)
+	}
+	alg, err := algFor(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	return &Key{Alg: alg, Private: signer, Public: signer.Public()}, nil
+}
+
+func loadPublicKey(path string) (*Key, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	var pub any
+	if block.Type == "RSA PUBLIC KEY" {
+		pub, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	} else {
+		pub, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	alg, err := algFor(pub)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{Alg: alg, Public: pub}, nil
+}
+
+func algFor(pub crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return jwt.SigningMethodES256, nil
+		case elliptic.P384():
+			return jwt.SigningMethodES384, nil
+		}
+		return nil, fmt.Errorf("unsupported curve %s", k.Curve.Params().Name)
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	}
+	return nil, fmt.Errorf("unsupported public key %T", pub)
+}
+
+/* ---------- JWKS ---------- */
+
+// JWK RFC 7517 公钥
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS 全部验证公钥（按 kid 排序）；ks 为 nil 时 keys 为空
+func (ks *KeySet) JWKS() JWKS {
+	out := JWKS{Keys: []JWK{}}
+	if ks == nil {
+		return out
+	}
+	ids := make([]string, 0, len(ks.Keys))
+	for id := range ks.Keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	b64 := base64.RawURLEncoding.EncodeToString
+	for _, id := range ids {
+		k := ks.Keys[id]
+		j := JWK{Kid: id, Alg: k.Alg.Alg(), Use: "sig"}
+		switch pub := k.Public.(type) {
+		case *rsa.PublicKey:
+			j.Kty, j.N, j.E = "RSA", b64(pub.N.Bytes()), b64(big.NewInt(int64(pub.E)).Bytes())
+		case *ecdsa.PublicKey:
+			size := (pub.Curve.Params().BitSize + 7) / 8
+			j.Kty, j.Crv = "EC", pub.Curve.Params().Name
+			j.X, j.Y = b64(pub.X.FillBytes(make([]byte, size))), b64(pub.Y.FillBytes(make([]byte, size)))
+		case ed25519.PublicKey:
+			j.Kty, j.Crv, j.X = "OKP", "Ed25519", b64(pub)
+		}
+		out.Keys = append(out.Keys, j)
+	}
+	return out
+}
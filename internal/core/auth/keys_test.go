@@ -0,0 +1,303 @@
+package auth_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"go-gin-gorm-starter/internal/core/auth"
+)
+
+// 测试用密钥：每种算法生成一次（RSA 较慢）
+var (
+	rsaKey, _   = rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _    = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ = ed25519.GenerateKey(rand.Reader)
+)
+
+// writeKey 把私钥（PKCS#8）或公钥（PKIX）写成 PEM 文件，返回路径
+func writeKey(t *testing.T, name string, key any) string {
+	t.Helper()
+	var block *pem.Block
+	if signer, ok := key.(crypto.Signer); ok {
+		der, err := x509.MarshalPKCS8PrivateKey(signer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	} else {
+		der, err := x509.MarshalPKIXPublicKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block = &pem.Block{Type: "PUBLIC KEY", Bytes: der}
+	}
+	path := filepath.Join(t.TempDir(), name+".pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func mustKeySet(t *testing.T, active string, files ...auth.KeyFile) *auth.KeySet {
+	t.Helper()
+	ks, err := auth.LoadKeySet(active, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ks
+}
+
+func newJWTer(secret string, ks *auth.KeySet) *auth.JWTer {
+	return &auth.JWTer{Secret: []byte(secret), Issuer: "test", TTL: time.Minute, Keys: ks}
+}
+
+// header 取令牌头里的字段
+func header(t *testing.T, token string) map[string]any {
+	t.Helper()
+	tok, _, err := jwt.NewParser().ParseUnverified(token, &auth.Claims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tok.Header
+}
+
+func TestAsymmetricSigning(t *testing.T) {
+	for _, tc := range []struct {
+		kid string
+		key crypto.Signer
+		alg string
+	}{
+		{"rsa", rsaKey, "RS256"},
+		{"ec", ecKey, "ES256"},
+		{"ed", edKey, "EdDSA"},
+	} {
+		j := newJWTer("", mustKeySet(t, tc.kid, auth.KeyFile{ID: tc.kid, PrivateKey: writeKey(t, tc.kid, tc.key)}))
+		tok, err := j.Issue("u1", "user")
+		if err != nil {
+			t.Fatalf("%s: issue: %v", tc.kid, err)
+		}
+		if h := header(t, tok); h["kid"] != tc.kid || h["alg"] != tc.alg {
+			t.Fatalf("%s: header %v, want kid %s alg %s", tc.kid, h, tc.kid, tc.alg)
+		}
+		c, err := j.Parse(tok)
+		if err != nil || c.UID != "u1" || c.Role != "user" {
+			t.Fatalf("%s: parse: %+v %v", tc.kid, c, err)
+		}
+		// 篡改签名
+		if _, err := j.Parse(tok[:len(tok)-4] + "AAAA"); err == nil {
+			t.Fatalf("%s: tampered token accepted", tc.kid)
+		}
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	// 新旧同算法：只有 kid 不同
+	oldPriv, newPriv := writeKey(t, "old", ecKey), writeKey(t, "new", mustECKey(t))
+	before := newJWTer("", mustKeySet(t, "old", auth.KeyFile{ID: "old", PrivateKey: oldPriv}))
+	oldTok, err := before.Issue("u1", "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 轮换后：新密钥签发，旧密钥只留公钥验证
+	after := newJWTer("", mustKeySet(t, "new",
+		auth.KeyFile{ID: "new", PrivateKey: newPriv},
+		auth.KeyFile{ID: "old", PublicKey: writeKey(t, "old-pub", ecKey.Public())},
+	))
+	newTok, err := after.Issue("u1", "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kid := header(t, newTok)["kid"]; kid != "new" {
+		t.Fatalf("signed with kid %v, want new", kid)
+	}
+	for name, tok := range map[string]string{"old": oldTok, "new": newTok} {
+		if _, err := after.Parse(tok); err != nil {
+			t.Fatalf("%s token after rotation: %v", name, err)
+		}
+	}
+	// 未登记的 kid（如已移除的旧密钥）
+	if _, err := before.Parse(newTok); err == nil || !strings.Contains(err.Error(), "unknown kid") {
+		t.Fatalf("token with unknown kid: %v", err)
+	}
+}
+
+func TestAlgorithmConfusion(t *testing.T) {
+	ks := mustKeySet(t, "rsa",
+		auth.KeyFile{ID: "rsa", PrivateKey: writeKey(t, "rsa", rsaKey)},
+		auth.KeyFile{ID: "ec", PublicKey: writeKey(t, "ec", ecKey.Public())},
+	)
+	j := newJWTer("", ks)
+	claims := auth.Claims{UID: "u1", RegisteredClaims: jwt.RegisteredClaims{
+		Issuer: "test", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	}}
+	sign := func(m jwt.SigningMethod, kid string, key any) string {
+		tok := jwt.NewWithClaims(m, claims)
+		if kid != "" {
+			tok.Header["kid"] = kid
+		}
+		s, err := tok.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	pubDER, _ := x509.MarshalPKIXPublicKey(rsaKey.Public())
+	for name, tok := range map[string]string{
+		// 用公钥当 HMAC 密钥伪造：算法须与 kid 对应的密钥一致
+		"hs256 with rsa kid": sign(jwt.SigningMethodHS256, "rsa", pubDER),
+		// kid 指向 EC 公钥，却用 RSA 签
+		"rs256 with ec kid": sign(jwt.SigningMethodRS256, "ec", rsaKey),
+		// 算法对得上，但签名密钥不是登记的那把
+		"es256 by other key": sign(jwt.SigningMethodES256, "ec", mustECKey(t)),
+		// 没有 Secret 时不接受不带 kid 的 HS256
+		"hs256 without kid": sign(jwt.SigningMethodHS256, "", []byte("old-secret")),
+	} {
+		if _, err := j.Parse(tok); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
+
+func mustECKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func TestHS256Migration(t *testing.T) {
+	legacy := newJWTer("old-secret", nil)
+	legacyTok, err := legacy.Issue("u1", "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := header(t, legacyTok)["kid"]; ok {
+		t.Fatal("HS256 token carries a kid")
+	}
+
+	ks := mustKeySet(t, "ed", auth.KeyFile{ID: "ed", PrivateKey: writeKey(t, "ed", edKey)})
+	// 迁移期：Secret 仍在，旧 HS256 令牌继续有效，新令牌用非对称密钥
+	migrating := newJWTer("old-secret", ks)
+	if _, err := migrating.Parse(legacyTok); err != nil {
+		t.Fatalf("legacy token during migration: %v", err)
+	}
+	tok, err := migrating.Issue("u1", "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := header(t, tok); h["alg"] != "EdDSA" {
+		t.Fatalf("migrating JWTer signed with %v", h["alg"])
+	}
+	// 密钥不对的 HS256 令牌
+	if _, err := newJWTer("other-secret", ks).Parse(legacyTok); err == nil {
+		t.Fatal("HS256 token with wrong secret accepted")
+	}
+	// 迁完清空 Secret：旧令牌失效，新令牌照常
+	done := newJWTer("", ks)
+	if _, err := done.Parse(legacyTok); err == nil {
+		t.Fatal("legacy token accepted after Secret was cleared")
+	}
+	if _, err := done.Parse(tok); err != nil {
+		t.Fatalf("asymmetric token after migration: %v", err)
+	}
+}
+
+func TestLoadKeySetErrors(t *testing.T) {
+	priv, pub := writeKey(t, "ec", ecKey), writeKey(t, "ec-pub", ecKey.Public())
+	for name, tc := range map[string]struct {
+		active string
+		files  []auth.KeyFile
+	}{
+		"missing kid":        {"", []auth.KeyFile{{PrivateKey: priv}}},
+		"duplicate kid":      {"a", []auth.KeyFile{{ID: "a", PrivateKey: priv}, {ID: "a", PublicKey: pub}}},
+		"active unknown":     {"b", []auth.KeyFile{{ID: "a", PrivateKey: priv}}},
+		"active public only": {"a", []auth.KeyFile{{ID: "a", PublicKey: pub}}},
+		"no key file":        {"a", []auth.KeyFile{{ID: "a"}}},
+		"missing file":       {"a", []auth.KeyFile{{ID: "a", PrivateKey: filepath.Join(t.TempDir(), "nope.pem")}}},
+		"public as private":  {"a", []auth.KeyFile{{ID: "a", PrivateKey: pub}}},
+		"unsupported curve":  {"a", []auth.KeyFile{{ID: "a", PrivateKey: writeKey(t, "p224", mustKey224(t))}}},
+	} {
+		if _, err := auth.LoadKeySet(tc.active, tc.files); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func mustKey224(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	k, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func TestJWKS(t *testing.T) {
+	if got := newJWTer("secret", nil).JWKS(); got.Keys == nil || len(got.Keys) != 0 {
+		t.Fatalf("JWKS without keys: %+v", got)
+	}
+	j := newJWTer("", mustKeySet(t, "rsa",
+		auth.KeyFile{ID: "rsa", PrivateKey: writeKey(t, "rsa", rsaKey)},
+		auth.KeyFile{ID: "ec", PublicKey: writeKey(t, "ec", ecKey.Public())},
+		auth.KeyFile{ID: "ed", PublicKey: writeKey(t, "ed", edKey.Public())},
+	))
+	set := j.JWKS()
+	var kids []string
+	for _, k := range set.Keys {
+		kids = append(kids, k.Kid)
+		if k.Use != "sig" {
+			t.Errorf("%s: use %q", k.Kid, k.Use)
+		}
+	}
+	if strings.Join(kids, ",") != "ec,ed,rsa" {
+		t.Fatalf("kids %v, want sorted ec,ed,rsa", kids)
+	}
+	b64 := func(s string) []byte {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	// 每把公钥都能从 JWK 还原
+	ec, ed, rs := set.Keys[0], set.Keys[1], set.Keys[2]
+	if ec.Kty != "EC" || ec.Alg != "ES256" || ec.Crv != "P-256" || len(b64(ec.X)) != 32 || len(b64(ec.Y)) != 32 {
+		t.Fatalf("ec jwk %+v", ec)
+	}
+	gotEC := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(b64(ec.X)), Y: new(big.Int).SetBytes(b64(ec.Y))}
+	if !gotEC.Equal(ecKey.Public()) {
+		t.Fatal("ec jwk does not match the public key")
+	}
+	if ed.Kty != "OKP" || ed.Alg != "EdDSA" || ed.Crv != "Ed25519" || !ed25519.PublicKey(b64(ed.X)).Equal(edKey.Public()) {
+		t.Fatalf("ed jwk %+v", ed)
+	}
+	gotRSA := &rsa.PublicKey{N: new(big.Int).SetBytes(b64(rs.N)), E: int(new(big.Int).SetBytes(b64(rs.E)).Int64())}
+	if rs.Kty != "RSA" || rs.Alg != "RS256" || !gotRSA.Equal(rsaKey.Public()) {
+		t.Fatalf("rsa jwk %+v", rs)
+	}
+	// 私钥信息不外泄
+	for _, k := range set.Keys {
+		if strings.Contains(k.N+k.E+k.X+k.Y, base64.RawURLEncoding.EncodeToString(rsaKey.D.Bytes())) {
+			t.Fatalf("%s: jwk leaks private material", k.Kid)
+		}
+	}
+}
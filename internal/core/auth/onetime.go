@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"go-gin-gorm-starter/pkg/utils"
+)
+
+/* ================== 一次性令牌（邮箱验证 / 重置密码） ==================
+   令牌格式 <id>.<sig>：sig = HMAC-SHA256(Secret, purpose "." id)，验签失败直接拒绝，不查库
+   库里记录 用户 / 用途 / 过期时间 / 使用时间：Consume 用条件更新保证只能用一次
+   同一用户同一用途再次签发时，之前未用的令牌作废（只有最新一封邮件有效）
+*/
+
+const (
+	PurposeVerifyEmail   = "verify_email"
+	PurposeResetPassword = "reset_password"
+)
+
+var ErrOneTimeTokenInvalid = errors.New("token invalid, expired or already used")
+
+// OneTimeToken 表 one_time_tokens
+type OneTimeToken struct {
+	ID        string     `gorm:"primaryKey;type:varchar(36)"`
+	UserID    string     `gorm:"index;type:varchar(36);not null"`
+	Purpose   string     `gorm:"size:32;not null"`
+	ExpiresAt time.Time  `gorm:"not null"`
+	UsedAt    *time.Time // 已使用 / 已作废
+	CreatedAt time.Time  `gorm:"autoCreateTime"`
+}
+
+func (OneTimeToken) TableName() string { return "one_time_tokens" }
+
+// OneTimeTokens 一次性令牌存储
+type OneTimeTokens struct {
+	DB     *gorm.DB
+	Secret []byte
+}
+
+func (s *OneTimeTokens) Migrate() error { return s.DB.AutoMigrate(&OneTimeToken{}) }
+
+// WithDB 换用 db（如调用方事务）的副本
+func (s *OneTimeTokens) WithDB(db *gorm.DB) *OneTimeTokens {
+	cp := *s
+	cp.DB = db
+	return &cp
+}
+
+// Issue 签发 uid 在 purpose 用途下的令牌，有效期 ttl
+func (s *OneTimeTokens) Issue(ctx context.Context, uid, purpose string, ttl time.Duration) (string, error) {
+	db := s.DB.WithContext(ctx)
+	now := time.Now()
+	if err := db.Model(&OneTimeToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", uid, purpose).
+		Update("used_at", now).Error; err != nil {
+		return "", err
+	}
+	t := OneTimeToken{ID: utils.NewID(), UserID: uid, Purpose: purpose, ExpiresAt: now.Add(ttl)}
+	if err := db.Create(&t).Error; err != nil {
+		return "", err
+	}
+	return t.ID + "." + s.sign(purpose, t.ID), nil
+}
+
+// Consume 校验并用掉令牌，返回其用户
+func (s *OneTimeTokens) Consume(ctx context.Context, token, purpose string) (string, error) {
+	id, sig, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(s.sign(purpose, id))) {
+		return "", ErrOneTimeTokenInvalid
+	}
+	db := s.DB.WithContext(ctx)
+	now := time.Now()
+	res := db.Model(&OneTimeToken{}).
+		Where("id = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", id, purpose, now).
+		Update("used_at", now)
+	if res.Error != nil {
+		return "", res.Error
+	}
+	if res.RowsAffected == 0 {
+		return "", ErrOneTimeTokenInvalid
+	}
+	var t OneTimeToken
+	if err := db.Select("user_id").Where("id = ?", id).Take(&t).Error; err != nil {
+		return "", err
+	}
+	return t.UserID, nil
+}
+
+func (s *OneTimeTokens) sign(purpose, id string) string {
+	mac := hmac.New(sha256.New, s.Secret)
+	mac.Write([]byte(purpose + "." + id))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
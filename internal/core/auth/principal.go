@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"context"
+	"slices"
+)
+
+/* ================== 当前登录主体 ==================
+   认证中间件把 Principal 同时放进 gin 上下文（c.Set(ContextKey, p)）和 c.Request.Context()：
+   - handler 里：auth.UserID(c) / auth.HasRole(c, "admin")（*gin.Context 就是 context.Context）
+   - service / 仓储里：传下去的 ctx（c、c.Request.Context() 或 db.WithContext(c) 的 Statement.Context）同样可取
+   未登录时取到 nil / 空串
+*/
+
+// ContextKey gin 上下文里 Principal 的键
+const ContextKey = "auth.principal"
+
+// 认证方式
+const (
+	MethodJWT = "jwt"
+)
+
+// Principal 当前请求的登录主体
+type Principal struct {
+	UserID   string
+	Roles    []string
+	TokenID  string // 访问令牌 jti
+	Method   string // 认证方式，见 MethodJWT
+	TenantID string // 多租户时的租户，可为空
+}
+
+// PrincipalFromClaims 由访问令牌 claims 构造
+func PrincipalFromClaims(c *Claims) *Principal {
+	p := &Principal{UserID: c.UID, TokenID: c.ID, Method: MethodJWT, TenantID: c.TenantID}
+	if c.Role != "" {
+		p.Roles = []string{c.Role}
+	}
+	return p
+}
+
+// Role 主角色（第一个），没有时为空
+func (p *Principal) Role() string {
+	if p == nil || len(p.Roles) == 0 {
+		return ""
+	}
+	return p.Roles[0]
+}
+
+// HasRole 拥有 roles 中任一角色；p 为 nil 时恒为 false
+func (p *Principal) HasRole(roles ...string) bool {
+	if p == nil {
+		return false
+	}
+	for _, r := range roles {
+		if slices.Contains(p.Roles, r) {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+// WithPrincipal 把 p 挂到 ctx 上
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom 取当前主体；*gin.Context 上按 ContextKey 查，其它 ctx 按 WithPrincipal 的键查
+func PrincipalFrom(ctx context.Context) *Principal {
+	if ctx == nil {
+		return nil
+	}
+	if p, ok := ctx.Value(principalKey{}).(*Principal); ok {
+		return p
+	}
+	if p, ok := ctx.Value(ContextKey).(*Principal); ok {
+		return p
+	}
+	return nil
+}
+
+// UserID 当前用户 ID，未登录为空串
+func UserID(ctx context.Context) string {
+	if p := PrincipalFrom(ctx); p != nil {
+		return p.UserID
+	}
+	return ""
+}
+
+// Roles 当前用户的角色
+func Roles(ctx context.Context) []string {
+	if p := PrincipalFrom(ctx); p != nil {
+		return p.Roles
+	}
+	return nil
+}
+
+// HasRole 当前用户拥有 roles 中任一角色
+func HasRole(ctx context.Context, roles ...string) bool {
+	return PrincipalFrom(ctx).HasRole(roles...)
+}
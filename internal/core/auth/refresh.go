@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"go-gin-gorm-starter/pkg/utils"
+)
+
+/* ================== 刷新令牌：轮换 + 重放检测 ==================
+   - 刷新令牌为不透明随机串，库里只存 sha256
+   - 登录开一个新"家族"（FamilyID）；每次刷新吊销当前令牌、在同一家族下签发新令牌（轮换）
+   - 已被轮换 / 吊销的令牌再次出现 = 令牌泄露，整条家族链一并吊销，返回 ErrRefreshReused
+   - 登出吊销当前家族
+*/
+
+var (
+	ErrRefreshInvalid = errors.New("refresh token invalid or expired")
+	ErrRefreshReused  = errors.New("refresh token reused")
+)
+
+// RefreshToken 表 refresh_tokens
+type RefreshToken struct {
+	ID         string     `gorm:"primaryKey;type:varchar(36)"`
+	FamilyID   string     `gorm:"index;type:varchar(36);not null"`
+	UserID     string     `gorm:"index;type:varchar(36);not null"`
+	TokenHash  string     `gorm:"uniqueIndex;size:64;not null"`
+	ExpiresAt  time.Time  `gorm:"not null"`
+	RevokedAt  *time.Time // 被轮换或吊销的时间
+	ReplacedBy string     `gorm:"type:varchar(36)"` // 轮换后的新令牌 ID
+	CreatedAt  time.Time  `gorm:"autoCreateTime"`
+}
+
+func (RefreshToken) TableName() string { return "refresh_tokens" }
+
+// RefreshStore 刷新令牌存储（数据库）
+type RefreshStore struct {
+	DB  *gorm.DB
+	TTL time.Duration // 单个刷新令牌有效期，默认 30 天
+}
+
+func (s *RefreshStore) Migrate() error { return s.DB.AutoMigrate(&RefreshToken{}) }
+
+// Issue 登录时调用：开新家族并签发第一枚刷新令牌
+func (s *RefreshStore) Issue(ctx context.Context, uid string) (string, error) {
+	return s.issue(s.DB.WithContext(ctx), uid, utils.NewID(), utils.NewID())
+}
+
+// Rotate 用旧令牌换新令牌，返回新令牌与其所属用户
+func (s *RefreshStore) Rotate(ctx context.Context, token string) (newToken, uid string, err error) {
+	reused := false
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var cur RefreshToken
+		if err := tx.Where("token_hash = ?", hashRefresh(token)).Take(&cur).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRefreshInvalid
+			}
+			return err
+		}
+		if cur.RevokedAt != nil {
+			reused = true
+			return ErrRefreshReused
+		}
+		if time.Now().After(cur.ExpiresAt) {
+			return ErrRefreshInvalid
+		}
+
+		next := utils.NewID()
+		// 条件更新：并发的两次刷新只有一次能轮换成功，另一次按重放处理
+		res := tx.Model(&RefreshToken{}).Where("id = ? AND revoked_at IS NULL", cur.ID).
+			Updates(map[string]any{"revoked_at": time.Now(), "replaced_by": next})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			reused = true
+			return ErrRefreshReused
+		}
+		t, err := s.issue(tx, cur.UserID, cur.FamilyID, next)
+		if err != nil {
+			return err
+		}
+		newToken, uid = t, cur.UserID
+		return nil
+	})
+	if reused {
+		// 事务已回滚，单独吊销整个家族
+		if e := s.revokeByHash(ctx, token); e != nil {
+			return "", "", e
+		}
+	}
+	if err != nil {
+		return "", "", err
+	}
+	return newToken, uid, nil
+}
+
+// Revoke 登出：吊销令牌所在的整个家族（令牌不存在时静默成功）
+func (s *RefreshStore) Revoke(ctx context.Context, token string) error {
+	return s.revokeByHash(ctx, token)
+}
+
+// WithDB 换用 db（如调用方事务）的副本
+func (s *RefreshStore) WithDB(db *gorm.DB) *RefreshStore {
+	cp := *s
+	cp.DB = db
+	return &cp
+}
+
+// RevokeUser 吊销用户的全部刷新令牌（改密、封禁等）
+func (s *RefreshStore) RevokeUser(ctx context.Context, uid string) error {
+	return s.DB.WithContext(ctx).Model(&RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", uid).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (s *RefreshStore) revokeByHash(ctx context.Context, token string) error {
+	db := s.DB.WithContext(ctx)
+	var cur RefreshToken
+	if err := db.Select("family_id").Where("token_hash = ?", hashRefresh(token)).Take(&cur).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	return db.Model(&RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", cur.FamilyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (s *RefreshStore) issue(db *gorm.DB, uid, family, id string) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	ttl := s.TTL
+	if ttl <= 0 {
+		ttl = 30 * 24 * time.Hour
+	}
+	rt := RefreshToken{
+		ID: id, FamilyID: family, UserID: uid,
+		TokenHash: hashRefresh(token), ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := db.Create(&rt).Error; err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func hashRefresh(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
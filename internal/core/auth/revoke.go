@@ -0,0 +1,200 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"go-gin-gorm-starter/internal/core/cache"
+)
+
+/* ================== 访问令牌吊销 ==================
+   两种手段，AuthJWT 每次请求都会检查（JWTer.Revocations 不为空时）：
+   - 单个令牌：按 jti 进吊销表（登出），记录保留到令牌本身过期
+   - 整个用户：token_versions 里的版本号 +1（封禁、改密、改角色），签发时写进 claims.ver，旧版本一律失效
+   配了 Redis 时两项查询都走 cache.Cache（含未吊销的否定结果），吊销 / 升版本时同步改缓存
+   版本缓存：升版本提交后由写方把新版本 SET 进缓存（不删键），读方回源只 SETNX，
+   回源时读到旧版本的慢请求不会把旧值写回去
+*/
+
+var ErrTokenRevoked = errors.New("token revoked")
+
+// RevokedToken 表 revoked_tokens
+type RevokedToken struct {
+	JTI       string    `gorm:"primaryKey;type:varchar(36)"`
+	UserID    string    `gorm:"index;type:varchar(36);not null"`
+	ExpiresAt time.Time `gorm:"index;not null"` // 令牌过期后记录即可清理
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+func (RevokedToken) TableName() string { return "revoked_tokens" }
+
+// TokenVersion 表 token_versions（无记录即版本 0）
+type TokenVersion struct {
+	UserID    string    `gorm:"primaryKey;type:varchar(36)"`
+	Version   int       `gorm:"not null;default:0"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+func (TokenVersion) TableName() string { return "token_versions" }
+
+// RevocationStore 吊销存储：数据库为准，Cache 可选
+type RevocationStore struct {
+	DB       *gorm.DB
+	Cache    *cache.Cache  // 为空则每次查库
+	CacheTTL time.Duration // 缓存时长，默认 5 分钟（Redis 丢失时最多这么久回源）
+}
+
+// NewRevocationStore 建表并返回存储；rc 可为 nil
+func NewRevocationStore(db *gorm.DB, rc *cache.Cache) (*RevocationStore, error) {
+	if err := db.AutoMigrate(&RevokedToken{}, &TokenVersion{}); err != nil {
+		return nil, err
+	}
+	return &RevocationStore{DB: db, Cache: rc}, nil
+}
+
+// Check 令牌被单独吊销或版本落后时返回 ErrTokenRevoked
+func (s *RevocationStore) Check(ctx context.Context, c *Claims) error {
+	if c.ID != "" {
+		revoked, err := s.isRevoked(ctx, c.ID)
+		if err != nil {
+			return err
+		}
+		if revoked {
+			return ErrTokenRevoked
+		}
+	}
+	ver, err := s.Version(ctx, c.UID)
+	if err != nil {
+		return err
+	}
+	if c.Ver < ver {
+		return ErrTokenRevoked
+	}
+	return nil
+}
+
+// Revoke 吊销单个访问令牌（登出）
+func (s *RevocationStore) Revoke(ctx context.Context, c *Claims) error {
+	if c.ID == "" {
+		return nil
+	}
+	exp := time.Now().Add(24 * time.Hour)
+	if c.ExpiresAt != nil {
+		exp = c.ExpiresAt.Time
+	}
+	err := s.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&RevokedToken{JTI: c.ID, UserID: c.UID, ExpiresAt: exp}).Error
+	if err != nil {
+		return err
+	}
+	if s.Cache != nil {
+		if ttl := time.Until(exp); ttl > 0 {
+			return s.Cache.RDB.Set(ctx, jtiKey(c.ID), "1", ttl).Err()
+		}
+	}
+	return nil
+}
+
+// Version 用户当前令牌版本
+func (s *RevocationStore) Version(ctx context.Context, uid string) (int, error) {
+	if s.Cache == nil {
+		return s.loadVersion(ctx, uid)
+	}
+	b, err := s.Cache.GetOrLoadNX(ctx, verKey(uid), s.cacheTTL(), func(ctx context.Context) ([]byte, error) {
+		v, err := s.loadVersion(ctx, uid)
+		return []byte(strconv.Itoa(v)), err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(string(b))
+}
+
+func (s *RevocationStore) loadVersion(ctx context.Context, uid string) (int, error) {
+	var v TokenVersion
+	err := s.DB.WithContext(ctx).Where("user_id = ?", uid).Take(&v).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	return v.Version, err
+}
+
+// Bump 用户版本 +1：此前签发的访问令牌全部失效（封禁、改密、改角色时调用）
+func (s *RevocationStore) Bump(ctx context.Context, uid string) error {
+	if err := s.BumpTx(s.DB.WithContext(ctx), uid); err != nil {
+		return err
+	}
+	return s.SyncVersion(ctx, uid)
+}
+
+// BumpTx 在调用方事务 tx 里升版本；只改库，提交后须调 SyncVersion 刷新缓存
+func (s *RevocationStore) BumpTx(tx *gorm.DB, uid string) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]any{"version": gorm.Expr("token_versions.version + 1"), "updated_at": time.Now()}),
+	}).Create(&TokenVersion{UserID: uid, Version: 1}).Error
+}
+
+// SyncVersion 提交后把库里的当前版本写进缓存（覆盖，只升不降）
+// 不用删键：删键与并发回源交错时，回源可能把提交前读到的旧版本写回缓存，旧令牌复活到 TTL 过期
+func (s *RevocationStore) SyncVersion(ctx context.Context, uid string) error {
+	if s.Cache == nil {
+		return nil
+	}
+	v, err := s.loadVersion(ctx, uid)
+	if err != nil {
+		return err
+	}
+	return setVersionScript.Run(ctx, s.Cache.RDB, []string{verKey(uid)}, v, s.cacheTTL().Milliseconds()).Err()
+}
+
+// 版本缓存只升不降：并发升版本时，先读到库却后写缓存的一方不会把较小的版本写回去
+var setVersionScript = redis.NewScript(`
+local cur = tonumber(redis.call('GET', KEYS[1]))
+if cur and cur >= tonumber(ARGV[1]) then return 0 end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+return 1`)
+
+// PurgeExpired 清理已过期令牌的吊销记录
+func (s *RevocationStore) PurgeExpired(ctx context.Context) (int64, error) {
+	res := s.DB.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&RevokedToken{})
+	return res.RowsAffected, res.Error
+}
+
+func (s *RevocationStore) isRevoked(ctx context.Context, jti string) (bool, error) {
+	load := func(ctx context.Context) (bool, error) {
+		var n int64
+		err := s.DB.WithContext(ctx).Model(&RevokedToken{}).Where("jti = ?", jti).Count(&n).Error
+		return n > 0, err
+	}
+	if s.Cache == nil {
+		return load(ctx)
+	}
+	b, err := s.Cache.GetOrLoad(ctx, jtiKey(jti), s.cacheTTL(), func(ctx context.Context) ([]byte, error) {
+		r, err := load(ctx)
+		if r {
+			return []byte("1"), err
+		}
+		return []byte("0"), err
+	})
+	if err != nil {
+		return false, err
+	}
+	return string(b) == "1", nil
+}
+
+func (s *RevocationStore) cacheTTL() time.Duration {
+	if s.CacheTTL > 0 {
+		return s.CacheTTL
+	}
+	return 5 * time.Minute
+}
+
+func jtiKey(jti string) string { return "auth:jti:" + jti }
+func verKey(uid string) string { return "auth:ver:" + uid }
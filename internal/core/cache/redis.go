@@ -19,6 +19,16 @@ func New(addr, pass string, db int) *Cache {
 }
 
 func (c *Cache) GetOrLoad(ctx context.Context, key string, ttl time.Duration, load func(context.Context) ([]byte, error)) ([]byte, error) {
+	return c.getOrLoad(ctx, key, ttl, load, false)
+}
+
+// GetOrLoadNX 同 GetOrLoad，但回源结果只在键不存在时写入（SETNX）
+// 适合“改库提交后由写方 Set 新值”的键：回源期间读到旧值的请求不会盖掉写方刚写入的新值
+func (c *Cache) GetOrLoadNX(ctx context.Context, key string, ttl time.Duration, load func(context.Context) ([]byte, error)) ([]byte, error) {
+	return c.getOrLoad(ctx, key, ttl, load, true)
+}
+
+func (c *Cache) getOrLoad(ctx context.Context, key string, ttl time.Duration, load func(context.Context) ([]byte, error), nx bool) ([]byte, error) {
 	// 先读缓存
 	if b, err := c.RDB.Get(ctx, key).Bytes(); err == nil {
 		return b, nil
@@ -29,7 +39,11 @@ func (c *Cache) GetOrLoad(ctx context.Context, key string, ttl time.Duration, lo
 		if e != nil {
 			return nil, e
 		}
-		_ = c.RDB.Set(ctx, key, b, ttl).Err()
+		if nx {
+			_ = c.RDB.SetNX(ctx, key, b, ttl).Err()
+		} else {
+			_ = c.RDB.Set(ctx, key, b, ttl).Err()
+		}
 		return b, nil
 	})
 	if err != nil {
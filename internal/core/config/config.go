@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/spf13/viper"
+
+	"go-gin-gorm-starter/internal/core/auth"
 )
 
 type HTTP struct {
@@ -15,10 +17,12 @@ type HTTP struct {
 	ReadTimeoutSec  int
 	WriteTimeoutSec int
 	IdleTimeoutSec  int
+	ResponseMode    string // legacy（默认，一律 200）| status（真实状态码）| problem（错误用 RFC 7807）
 }
 type AdminHTTP struct {
-	Host string
-	Port int
+	Host         string
+	Port         int
+	ResponseMode string // 同 HTTP.ResponseMode
 }
 
 type App struct {
@@ -34,9 +38,45 @@ type Log struct {
 }
 
 type JWT struct {
-	Secret            string
-	Issuer            string
-	AccessTokenTTLMin int
+	Secret              string
+	Issuer              string
+	AccessTokenTTLMin   int
+	RefreshTokenTTLDays int // 刷新令牌有效期，默认 30
+
+	// 非对称签名（可选）：配置了 Keys 即用 ActiveKid 那把签发，其余只用于验证（轮换期）
+	ActiveKid string
+	Keys      []JWTKey
+}
+
+type JWTKey struct {
+	Kid        string
+	PrivateKey string // PEM 私钥路径（RSA / ECDSA P-256/P-384 / Ed25519）
+	PublicKey  string // PEM 公钥路径（只验证的旧密钥）
+}
+
+// KeySet 按 Keys 加载非对称签名密钥集；未配置时返回 nil（只用 HS256 Secret）
+func (j JWT) KeySet() (*auth.KeySet, error) {
+	if len(j.Keys) == 0 {
+		return nil, nil
+	}
+	files := make([]auth.KeyFile, 0, len(j.Keys))
+	for _, k := range j.Keys {
+		files = append(files, auth.KeyFile{ID: k.Kid, PrivateKey: k.PrivateKey, PublicKey: k.PublicKey})
+	}
+	return auth.LoadKeySet(j.ActiveKid, files)
+}
+
+type Account struct {
+	TokenSecret string // 邮箱验证 / 重置密码令牌的签名密钥；必填，不要与 jwt.secret 共用
+}
+
+type Pagination struct {
+	CursorSecret string // 游标签名密钥（多实例需一致）；用到游标分页时必填，不要与 jwt.secret 共用
+}
+
+type Idempotency struct {
+	RetentionHours int // 已完成请求的响应保留时长（小时），默认 24
+	LockTTLSec     int // 处理中占位时长（秒），默认 60；要大于最慢写接口的耗时，进程崩溃后最多锁这么久
 }
 
 type Redis struct {
@@ -57,12 +97,23 @@ type DB struct {
 	LogLevel           string
 }
 
+type Mail struct {
+	From        string // 发件人
+	Dir         string // 非空时邮件写成该目录下的 .eml 文件，否则写日志
+	LinkBaseURL string // 邮件里链接的前端地址，如 https://app.example.com
+}
+
 type Config struct {
 	App   App
 	Log   Log
 	JWT   JWT
 	DB    DB
 	Redis Redis `mapstructure:"redis"`
+	Mail  Mail
+
+	Account     Account
+	Pagination  Pagination
+	Idempotency Idempotency
 }
 
 func Load(path string) *Config {
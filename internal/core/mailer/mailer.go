@@ -0,0 +1,80 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+/* ================== 邮件发送 ==================
+   业务只依赖 Mailer 接口；内置两种离线实现，接 SMTP / 第三方服务时另写实现即可：
+   - LogMailer  把邮件打到日志（默认）
+   - FileMailer 每封邮件写成 Dir 下的一个 .eml 文件，便于本地点开验证链接
+*/
+
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Text    string // 纯文本正文
+}
+
+type Mailer interface {
+	Send(ctx context.Context, m Message) error
+}
+
+// New 按配置选实现：dir 非空用 FileMailer，否则 LogMailer
+func New(dir string, l *zap.Logger) (Mailer, error) {
+	if dir == "" {
+		return LogMailer{L: l}, nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileMailer{Dir: dir}, nil
+}
+
+// LogMailer 邮件内容写日志
+type LogMailer struct{ L *zap.Logger }
+
+func (m LogMailer) Send(_ context.Context, msg Message) error {
+	l := m.L
+	if l == nil {
+		l = zap.L()
+	}
+	l.Info("mail",
+		zap.String("from", msg.From), zap.String("to", msg.To),
+		zap.String("subject", msg.Subject), zap.String("text", msg.Text))
+	return nil
+}
+
+// FileMailer 每封邮件一个 .eml 文件
+type FileMailer struct {
+	Dir string
+	seq atomic.Int64
+}
+
+func (m *FileMailer) Send(_ context.Context, msg Message) error {
+	now := time.Now()
+	name := fmt.Sprintf("%s-%04d-%s.eml", now.Format("20060102-150405.000"), m.seq.Add(1)%10000, safeName(msg.To))
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\n", msg.From, msg.To, msg.Subject, now.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Text, "\n", "\r\n"))
+	return os.WriteFile(filepath.Join(m.Dir, name), []byte(b.String()), 0o644)
+}
+
+func safeName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' || r == '_' || r == '@' {
+			return r
+		}
+		return '_'
+	}, s)
+}
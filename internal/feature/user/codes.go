@@ -0,0 +1,31 @@
+package user
+
+import resp "go-gin-gorm-starter/internal/transport/http/response"
+
+// 用户模块业务码（1xxxx）
+var (
+	CodeEmailTaken = resp.Register(resp.CodeDef{
+		Code: 10001, Key: "email_taken", Status: 409,
+		Messages: map[string]string{"en": "email {email} is already registered", "zh": "邮箱 {email} 已被注册"},
+	})
+	CodeInvalidCredentials = resp.Register(resp.CodeDef{
+		Code: 10002, Key: "invalid_credentials", Status: 401,
+		Messages: map[string]string{"en": "invalid email or password", "zh": "邮箱或密码错误"},
+	})
+	CodeRefreshInvalid = resp.Register(resp.CodeDef{
+		Code: 10003, Key: "refresh_token_invalid", Status: 401,
+		Messages: map[string]string{"en": "refresh token is invalid or expired", "zh": "刷新令牌无效或已过期"},
+	})
+	CodeRefreshReused = resp.Register(resp.CodeDef{
+		Code: 10004, Key: "refresh_token_reused", Status: 401,
+		Messages: map[string]string{"en": "refresh token has already been used, please sign in again", "zh": "刷新令牌已被使用，请重新登录"},
+	})
+	CodeTokenInvalid = resp.Register(resp.CodeDef{
+		Code: 10005, Key: "link_token_invalid", Status: 400,
+		Messages: map[string]string{"en": "the link is invalid, expired or has already been used", "zh": "链接无效、已过期或已被使用"},
+	})
+	CodeEmailAlreadyVerified = resp.Register(resp.CodeDef{
+		Code: 10006, Key: "email_already_verified", Status: 409,
+		Messages: map[string]string{"en": "email is already verified", "zh": "邮箱已验证"},
+	})
+)
@@ -12,6 +12,8 @@ type UserModel struct {
 	PasswordHash string `gorm:"size:100;not null"`
 	Role         string `gorm:"size:16;not null;default:user"`
 
+	EmailVerifiedAt *time.Time // 为空表示邮箱未验证
+
 	CreatedAt time.Time      `gorm:"autoCreateTime"`
 	UpdatedAt time.Time      `gorm:"autoUpdateTime"`
 	DeletedAt gorm.DeletedAt `gorm:"index"`
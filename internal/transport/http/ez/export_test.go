@@ -0,0 +1,8 @@
+package ez
+
+// SetMarshalOpenAPI 测试用：替换文档序列化，返回恢复函数
+func SetMarshalOpenAPI(f func(v any) ([]byte, error)) (restore func()) {
+	old := marshalOpenAPI
+	marshalOpenAPI = f
+	return func() { marshalOpenAPI = old }
+}
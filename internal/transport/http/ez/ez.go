@@ -9,6 +9,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
+	"go-gin-gorm-starter/internal/core/auth"
 	resp "go-gin-gorm-starter/internal/transport/http/response"
 )
 
@@ -22,10 +23,10 @@ func (e EZ) GET(path string, h func(c *gin.Context) (any, error)) {
 	e.g.GET(path, func(c *gin.Context) {
 		data, err := h(c)
 		if err != nil {
-			c.JSON(http.StatusOK, resp.Error(500, err.Error()))
+			resp.JSON(c, resp.Error(500, err.Error()))
 			return
 		}
-		c.JSON(http.StatusOK, resp.OK(data))
+		resp.JSON(c, resp.OK(data))
 	})
 }
 
@@ -33,15 +34,15 @@ func POST[T any](e EZ, path string, h func(c *gin.Context, in T) (any, error)) {
 	e.g.POST(path, func(c *gin.Context) {
 		var in T
 		if err := c.ShouldBindJSON(&in); err != nil {
-			c.JSON(http.StatusOK, resp.Error(400, err.Error()))
+			resp.JSON(c, errResp(invalid(c, err)))
 			return
 		}
 		data, err := h(c, in)
 		if err != nil {
-			c.JSON(http.StatusOK, resp.Error(500, err.Error()))
+			resp.JSON(c, resp.Error(500, err.Error()))
 			return
 		}
-		c.JSON(http.StatusOK, resp.OK(data))
+		resp.JSON(c, resp.OK(data))
 	})
 }
 
@@ -50,51 +51,64 @@ func POSTFILES(e EZ, path string, fieldName string, h func(c *gin.Context, files
 	e.g.POST(path, func(c *gin.Context) {
 		form, err := c.MultipartForm()
 		if err != nil {
-			c.JSON(http.StatusOK, resp.Error(400, "invalid multipart form: "+err.Error()))
+			resp.JSON(c, resp.Error(400, "invalid multipart form: "+err.Error()))
 			return
 		}
 		files := form.File[fieldName]
 		if len(files) == 0 {
-			c.JSON(http.StatusOK, resp.Error(400, "no files uploaded"))
+			resp.JSON(c, resp.Error(400, "no files uploaded"))
 			return
 		}
 
 		data, err := h(c, files)
 		if err != nil {
-			c.JSON(http.StatusOK, resp.Error(500, err.Error()))
+			resp.JSON(c, errResp(err))
 			return
 		}
-		c.JSON(http.StatusOK, resp.OK(data))
+		resp.JSON(c, resp.OK(data))
 	})
 }
 
 /* ================== 新增：Action（非 CRUD 一行注册） ================== */
 
-// 绑定方式
+// 绑定方式（多来源组合见 Bind）
 type Binder string
 
 const (
-	BindJSON  Binder = "json"  // 从 JSON 绑定
-	BindQuery Binder = "query" // 从 URL ?a=b 绑定
-	BindNone  Binder = "none"  // 不绑定，自己从 c.Param / c.PostForm 取
+	BindJSON      Binder = "json"      // 从 JSON 绑定
+	BindQuery     Binder = "query"     // 从 URL ?a=b 绑定
+	BindURI       Binder = "uri"       // 从路径参数绑定（uri tag）：/users/:id
+	BindHeader    Binder = "header"    // 从请求头绑定（header tag）
+	BindForm      Binder = "form"      // 从 application/x-www-form-urlencoded 表单绑定
+	BindMultipart Binder = "multipart" // 从 multipart/form-data 绑定（文件进 *multipart.FileHeader 字段）
+	BindNone      Binder = "none"      // 不绑定，自己从 c.Param / c.PostForm 取
 )
 
 // 统一错误对象（配合 resp.Error(int, msg)）
 type AErr struct {
-	Code int
-	Msg  string
-	Err  error
+	Code   int
+	Msg    string         // 为空时用注册表里该码的消息模板（按请求语言渲染）
+	Params map[string]any // 消息模板参数
+	Err    error
+	Data   any // 随错误返回的 data（如校验错误列表），可空
 }
 
 func (e *AErr) Error() string {
 	if e.Msg != "" {
 		return e.Msg
 	}
+	if _, ok := resp.LookupCode(e.Code); ok {
+		return resp.Message(e.Code, resp.DefaultLang, e.Params)
+	}
 	if e.Err != nil {
 		return e.Err.Error()
 	}
 	return "action error"
 }
+
+// Fail 按注册表里的业务码返回错误：Fail(user.CodeEmailTaken, map[string]any{"email": in.Email})
+func Fail(code int, params map[string]any) error { return &AErr{Code: code, Params: params} }
+
 func BadRequest(msg string) error   { return &AErr{Code: 400, Msg: msg} }
 func Unauthorized(msg string) error { return &AErr{Code: 401, Msg: msg} }
 func Forbidden(msg string) error    { return &AErr{Code: 403, Msg: msg} }
@@ -104,15 +118,59 @@ func Internal(msg string, err error) error {
 	return &AErr{Code: 500, Msg: msg, Err: err}
 }
 
+// 错误 → 响应：*AErr 取其 Code，其它一律 500
+func errResp(err error) resp.Resp {
+	var ae *AErr
+	if errors.As(err, &ae) {
+		var r resp.Resp
+		if _, ok := resp.LookupCode(ae.Code); ok && ae.Msg == "" {
+			r = resp.Coded(ae.Code, ae.Params)
+		} else {
+			r = resp.Error(ae.Code, ae.Error())
+		}
+		if ae.Data != nil {
+			r.Data = ae.Data
+		}
+		return r
+	}
+	return resp.Error(500, err.Error())
+}
+
 // 动作定义：I 入参，O 出参
 type Action[I any, O any] struct {
 	Method  string   // "GET" | "POST" | "PUT" | "DELETE"
 	Path    string   // 例："/auth/login"、"/orders/:id/pay"
-	Binder  Binder   // 绑定方式
-	Auth    bool     // 是否要求登录（检查 userId）
+	Binder  Binder   // 绑定方式（可用 Bind(...) 组合多个来源）
+	Auth    bool     // 是否要求登录（检查 auth.Principal）
 	Roles   []string // 限定角色（可选）
-	UseTx   bool     // 是否包事务（gorm.Transaction）
-	Handler func(c *gin.Context, db *gorm.DB, in *I) (O, error)
+	UseTx   bool     // 是否包事务（gorm.Transaction）；提交后要做的事用 AfterCommit 登记
+	Summary string   // 接口说明（OpenAPI summary，可选）
+	// 按 Idempotency-Key 请求头去重 / 重放响应（仅非 GET 生效，见 ez_idempotency.go）
+	Idempotent bool
+	Handler    func(c *gin.Context, db *gorm.DB, in *I) (O, error)
+}
+
+const afterCommitKey = "ez.afterCommit"
+
+// AfterCommit 登记动作成功（UseTx 时即事务已提交）后才执行的步骤，如清缓存、签发依赖新数据的令牌
+// 按登记顺序执行；handler 出错或事务回滚时全部丢弃；步骤出错按 handler 出错处理（已提交的数据不回滚）
+func AfterCommit(c *gin.Context, fn func() error) {
+	c.Set(afterCommitKey, append(afterCommitFns(c), fn))
+}
+
+func afterCommitFns(c *gin.Context) []func() error {
+	v, _ := c.Get(afterCommitKey)
+	fns, _ := v.([]func() error)
+	return fns
+}
+
+func runAfterCommit(c *gin.Context) error {
+	for _, fn := range afterCommitFns(c) {
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // 在当前 EZ 下注册动作接口（传入 *gorm.DB）
@@ -120,39 +178,21 @@ func RegisterAction[I any, O any](e EZ, db *gorm.DB, a Action[I, O]) {
 	h := func(c *gin.Context) {
 		// 1) 鉴权/角色
 		if a.Auth {
-			uid := c.GetString("userId")
-			if uid == "" {
-				c.JSON(http.StatusOK, resp.Error(401, "unauthorized"))
+			p := auth.PrincipalFrom(c)
+			if p == nil || p.UserID == "" {
+				resp.JSON(c, resp.Error(401, "unauthorized"))
 				return
 			}
-			if len(a.Roles) > 0 {
-				role := c.GetString("role")
-				ok := false
-				for _, r := range a.Roles {
-					if role == r {
-						ok = true
-						break
-					}
-				}
-				if !ok {
-					c.JSON(http.StatusOK, resp.Error(403, "forbidden"))
-					return
-				}
+			if len(a.Roles) > 0 && !p.HasRole(a.Roles...) {
+				resp.JSON(c, resp.Error(403, "forbidden"))
+				return
 			}
 		}
 
 		// 2) 绑定入参
 		var in I
-		var bindErr error
-		switch a.Binder {
-		case BindJSON:
-			bindErr = c.ShouldBindJSON(&in)
-		case BindQuery:
-			bindErr = c.ShouldBindQuery(&in)
-		default: // BindNone: 不绑定
-		}
-		if bindErr != nil {
-			c.JSON(http.StatusOK, resp.Error(400, bindErr.Error()))
+		if bindErr := bindInput(c, a.Binder, &in); bindErr != nil {
+			resp.JSON(c, errResp(invalid(c, bindErr)))
 			return
 		}
 
@@ -169,20 +209,31 @@ func RegisterAction[I any, O any](e EZ, db *gorm.DB, a Action[I, O]) {
 		} else {
 			out, err = run(db.WithContext(c))
 		}
+		if err == nil {
+			err = runAfterCommit(c)
+		}
 
-		// 4) 统一错误映射
-		if err != nil {
-			var ae *AErr
-			if errors.As(err, &ae) {
-				c.JSON(http.StatusOK, resp.Error(ae.Code, ae.Error()))
-				return
+		// 4) handler 已自行写出响应（如 Export 流式下载）时不再输出 JSON
+		if c.Writer.Written() {
+			if err != nil {
+				_ = c.Error(err)
 			}
-			c.JSON(http.StatusOK, resp.Error(500, err.Error()))
 			return
 		}
-		c.JSON(http.StatusOK, resp.OK(out))
+
+		// 5) 统一错误映射
+		if err != nil {
+			resp.JSON(c, errResp(err))
+			return
+		}
+		resp.JSON(c, resp.OK(out))
+	}
+
+	if a.Idempotent && !strings.EqualFold(a.Method, http.MethodGet) {
+		h = idempotent(h)
 	}
 
+	recordAction(e.g, a)
 	switch strings.ToUpper(a.Method) {
 	case http.MethodGet:
 		e.g.GET(a.Path, h)
@@ -1,31 +1,54 @@
 package ez
 
 import (
+	"errors"
 	"net/http"
+	"net/url"
 	"reflect"
 	"strconv"
-	"strings"
-	"unicode"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
 
+	"go-gin-gorm-starter/internal/core/auth"
 	resp "go-gin-gorm-starter/internal/transport/http/response"
 	"go-gin-gorm-starter/pkg/utils"
 )
 
-// Hook
+// Hook：写钩子与写入在同一事务里执行，返回 error 即回滚
+// 返回 *AErr 时按其 Code 响应，其它 error 按 400
 type CrudHooks[T any] struct {
-	BeforeCreate func(c *gin.Context, m *T) error
-	BeforeUpdate func(c *gin.Context, m *T) error
-	ScopeList    func(c *gin.Context, q *gorm.DB) *gorm.DB // 自定义筛选/排序
-	AfterGet     func(c *gin.Context, m *T)
+	BeforeCreate func(c *gin.Context, tx *gorm.DB, m *T) error
+	AfterCreate  func(c *gin.Context, tx *gorm.DB, m *T) error
+	BeforeUpdate func(c *gin.Context, tx *gorm.DB, old, in *T) error // in 为待写入的值（PUT 时零值字段不更新）
+	AfterUpdate  func(c *gin.Context, tx *gorm.DB, old, m *T) error  // m 为更新后的完整记录
+	BeforeDelete func(c *gin.Context, tx *gorm.DB, m *T) error
+	AfterDelete  func(c *gin.Context, tx *gorm.DB, m *T) error
+
+	ScopeList func(c *gin.Context, q *gorm.DB) *gorm.DB // 自定义筛选/排序
+	AfterGet  func(c *gin.Context, m *T)                // 输出前加工（不在事务里）
+}
+
+func (h *CrudHooks[T]) hasWriteHooks() bool {
+	return h.BeforeCreate != nil || h.AfterCreate != nil || h.BeforeUpdate != nil ||
+		h.AfterUpdate != nil || h.BeforeDelete != nil || h.AfterDelete != nil
+}
+
+// 钩子错误：*AErr 原样透出，其它按 400
+func hookErr(err error) error {
+	var ae *AErr
+	if errors.As(err, &ae) {
+		return err
+	}
+	return BadRequest(err.Error())
 }
 
 type CrudConfig[T any] struct {
 	DB    *gorm.DB
-	Group *gin.RouterGroup // 已鉴权分组（能拿 userId）
+	Group *gin.RouterGroup // 已鉴权分组（能拿到 auth.Principal）
 	Path  string
 	New   func() *T
 
@@ -35,16 +58,74 @@ type CrudConfig[T any] struct {
 	AllowList   bool
 	AllowGet    bool
 	AllowUpdate bool
+	AllowPatch  bool // PATCH /:id（Merge Patch / JSON Patch）
 	AllowDelete bool
 
+	// Create 按 Idempotency-Key 请求头去重 / 重放（见 ez_idempotency.go）
+	Idempotent bool
+
 	IDField    string // 默认 "ID"
 	OwnerField string // 默认优先 "OwnerID"，其次 "UserID"/"UID"
+	IDParam    string // 路由里的 ID 参数名，默认 "id"（嵌套在父资源 /:id 下时需换名）
+
+	// 复合主键：按顺序列出字段，路由为 /path/:p1/:p2（参数名默认取 json 名，可用 IDParams 指定）
+	IDFields []string
+	IDParams []string
+
+	// 嵌套子资源：归属继承自父资源（此时忽略 Scope/OwnerField），见 ParentOf
+	Parent *Parent
+
+	// 允许 ?include= 预加载的关联（字段名，可写 "Items.Product"）
+	Preloads []string
+
+	// 可见范围：默认只看自己的；管理端可按角色或自定义判断放开归属过滤
+	Scope Scope
+
+	// PATCH 时拒绝修改的字段（ID/Owner/主键/自动时间戳/DeletedAt 默认只读）
+	ReadOnlyFields []string
+
+	// 字段级读写权限（按字段名，覆盖模型上的 ez:"..." tag），见 FieldRule
+	Fields map[string]FieldRule
+
+	// 乐观并发：版本字段（整数版本号或 "UpdatedAt"），为空则不启用 ETag
+	VersionField   string
+	RequireIfMatch bool // 更新/删除必须携带 If-Match，否则 428
+
+	// 批量路由：POST /path:batchCreate | :batchUpdate | :batchDelete
+	AllowBatch    bool
+	BatchMaxItems int // 单次最多条数，默认 1000
+
+	// 导出：GET /path/export?format=csv|ndjson|xlsx（同列表的过滤/排序，流式输出）
+	AllowExport   bool
+	ExportTimeout time.Duration // 单次导出时限（不受服务端写超时和 Timeout 中间件约束），0 取默认 30 分钟，负数不限
+
+	// 导入：POST /path/import（CSV/NDJSON，?dryRun=true 只校验），大文件转后台任务 GET /path/import/:task
+	// 后台任务只存在本进程内存里：多实例部署时查询要落到发起的实例（会话保持），进程重启后任务丢失
+	AllowImport     bool
+	ImportMaxRows   int // 单个文件最多行数，默认 100000
+	ImportAsyncRows int // 超过该行数（或 ?async=true）转后台任务，默认 1000
+	ImportBatchSize int // 每批提交的行数，默认 500
+
+	// 回收站：GET /path/trash、POST /:id/restore、DELETE /:id/purge（需 gorm.DeletedAt）
+	AllowTrash         bool
+	PurgeRoles         []string // 可彻底删除的角色，默认 admin
+	TrashRetentionDays int      // >0 时由 StartTrashSweeper 清理超期条目
 
 	AutoID bool          // 默认 true
 	IDGen  func() string // 默认 utils.NewID
 
 	// 列表排序（列名按模型字段自动转 snake_case），为空则按 ID DESC
 	OrderBy string // 例如 "CreatedAt DESC"
+
+	// 列表过滤/排序白名单（字段名或 json 名），例：?status=eq:active&sort=-createdAt
+	Filterable []string
+	Sortable   []string
+	// ScopeList 等自行读取的查询参数：不当作过滤字段，也不因未声明而 400
+	ExtraQueryKeys []string
+
+	// 分页模式：默认 offset；PageCursor 按 OrderBy + ID 生成 nextCursor/prevCursor
+	Pagination PageMode
+	SkipTotal  bool // 游标模式下不返回 total（省掉 COUNT(*)）
 }
 
 // 反射 & 工具
@@ -56,58 +137,15 @@ func (c *CrudConfig[T]) idFieldCandidates() []string {
 }
 
 func (c *CrudConfig[T]) ownerFieldCandidates() []string {
+	if c.Parent != nil {
+		return []string{c.Parent.Field}
+	}
 	if c.OwnerField != "" {
 		return []string{c.OwnerField, "OwnerID", "UserID", "UID"}
 	}
 	return []string{"OwnerID", "UserID", "UID"}
 }
 
-func getStringFieldPtr(obj any, candidates []string) (*string, bool) {
-	v := reflect.ValueOf(obj)
-	if v.Kind() != reflect.Ptr {
-		return nil, false
-	}
-	v = v.Elem()
-	if v.Kind() != reflect.Struct {
-		return nil, false
-	}
-	t := v.Type()
-	for i := 0; i < t.NumField(); i++ {
-		f := t.Field(i)
-		// 未导出字段跳过
-		if f.PkgPath != "" {
-			continue
-		}
-		for _, cand := range candidates {
-			if f.Name == cand {
-				fv := v.Field(i)
-				if fv.Kind() == reflect.String && fv.CanSet() {
-					p := fv.Addr().Interface().(*string)
-					return p, true
-				}
-			}
-		}
-	}
-	return nil, false
-}
-
-func readStringField(obj any, candidates []string) (string, bool) {
-	p, ok := getStringFieldPtr(obj, candidates)
-	if !ok {
-		return "", false
-	}
-	return *p, true
-}
-
-func writeStringField(obj any, candidates []string, val string) bool {
-	p, ok := getStringFieldPtr(obj, candidates)
-	if !ok {
-		return false
-	}
-	*p = val
-	return true
-}
-
 func atoiDefault(s string, def int) int {
 	if v, err := strconv.Atoi(s); err == nil && v > 0 {
 		return v
@@ -115,29 +153,11 @@ func atoiDefault(s string, def int) int {
 	return def
 }
 
-func toSnake(s string) string {
-	if s == "" {
-		return s
-	}
-	var b strings.Builder
-	for i, r := range s {
-		if unicode.IsUpper(r) {
-			if i > 0 {
-				b.WriteByte('_')
-			}
-			b.WriteRune(unicode.ToLower(r))
-		} else {
-			b.WriteRune(r)
-		}
-	}
-	return b.String()
-}
-
 // CRUD 注册（无需模型实现任何接口）
 func Crud[T any](cfg CrudConfig[T]) {
 	// 默认放开所有操作
-	if !cfg.AllowCreate && !cfg.AllowGet && !cfg.AllowList && !cfg.AllowUpdate && !cfg.AllowDelete {
-		cfg.AllowCreate, cfg.AllowList, cfg.AllowGet, cfg.AllowUpdate, cfg.AllowDelete = true, true, true, true, true
+	if !cfg.AllowCreate && !cfg.AllowGet && !cfg.AllowList && !cfg.AllowUpdate && !cfg.AllowPatch && !cfg.AllowDelete {
+		cfg.AllowCreate, cfg.AllowList, cfg.AllowGet, cfg.AllowUpdate, cfg.AllowPatch, cfg.AllowDelete = true, true, true, true, true, true
 	}
 	// 默认 AutoID/IDGen
 	if cfg.AutoID == false && cfg.IDGen == nil {
@@ -150,60 +170,271 @@ func Crud[T any](cfg CrudConfig[T]) {
 	// 自动迁移
 	_ = cfg.DB.AutoMigrate(cfg.New())
 
-	idFieldNames := cfg.idFieldCandidates()
+	keys := cfg.keys()
+	idFieldNames := keys.fieldNames()
 	ownerFieldNames := cfg.ownerFieldCandidates()
+	cfg.Scope.check(reflect.TypeOf(cfg.New()).Elem().Name())
+
+	sch, err := parseSchema(cfg.DB, cfg.New())
+	if err != nil {
+		panic("ez: parse model schema: " + err.Error())
+	}
+	spec := newListSpec(sch, cfg.Filterable, cfg.Sortable, append([]string{cfg.Scope.ownerParam()}, cfg.ExtraQueryKeys...))
+	ver := newVersionSpec(sch, cfg.VersionField)
+	fields := newFieldSpec(sch, cfg.Fields)
+	preloads := newPreloadSpec(sch, cfg.Preloads)
+	if len(idFieldNames) != len(keys.names) {
+		panic("ez: id field not found on " + sch.Name)
+	}
+	var keyset *Keyset
+	if cfg.Pagination == PageCursor {
+		useCursor(sch.Name)
+		keyset = newKeyset(sch, parseOrderBy(sch, cfg.OrderBy), idFieldNames...)
+		// ?sort= 会换排序列：注册时就拒绝可空列，免得请求时才 panic
+		for _, f := range spec.sorts {
+			checkKeyColumn(sch, f)
+		}
+	}
+
+	ownedFilter := cfg.owned
+
+	// 列表类查询（列表/导出共用）：归属 + ScopeList + 过滤，并解析 ?sort=
+	listQuery := func(c *gin.Context, a access, values url.Values) (*gorm.DB, []keyCol, error) {
+		ownerFilter, err := ownedFilter(a.owner, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		q := ownerFilter.apply(cfg.DB.WithContext(c).Model(cfg.New()))
+		if cfg.Hooks.ScopeList != nil {
+			q = cfg.Hooks.ScopeList(c, q)
+		}
+		if a.all {
+			values.Del(cfg.Scope.ownerParam())
+		}
+		q, err = spec.applyFilters(values, q)
+		if err != nil {
+			return nil, nil, BadRequest(err.Error())
+		}
+		sorts, err := spec.orderBy(c.Query("sort"))
+		if err != nil {
+			return nil, nil, BadRequest(err.Error())
+		}
+		return q, sorts, nil
+	}
+	// 动态排序：优先按 ?sort=，其次配置 OrderBy，否则按 ID DESC
+	listOrder := func(q *gorm.DB, sorts []keyCol) *gorm.DB {
+		if len(sorts) > 0 {
+			return q.Order(newKeyset(sch, sorts, idFieldNames...).order(false))
+		}
+		if cfg.OrderBy != "" {
+			return q.Order(cfg.OrderBy)
+		}
+		// 主键列倒序（复合主键逐列）
+		return q.Order(newKeyset(sch, nil, idFieldNames...).order(false))
+	}
+
+	// 单条写入：配置了写钩子才包事务，保证钩子与写入同进同退
+	write := func(c *gin.Context, fn func(tx *gorm.DB) error) error {
+		db := cfg.DB.WithContext(c)
+		if !cfg.Hooks.hasWriteHooks() {
+			return fn(db)
+		}
+		return db.Transaction(fn)
+	}
+
+	// 单条写入逻辑（单条路由与批量路由共用），出错返回 *AErr
+	prepareCreate := func(c *gin.Context, tx *gorm.DB, a access, m *T) error {
+		// 自动生成 ID（若开启且为空；整数主键交给数据库自增，复合主键由调用方给出）
+		if cfg.AutoID && !keys.composite() {
+			fv, _ := fieldByNames(m, idFieldNames)
+			if fv.IsZero() && !isIntegerKind(indirect(fv.Type()).Kind()) {
+				if _, err := writeField(m, idFieldNames, cfg.IDGen()); err != nil {
+					return Internal("generate id failed", err)
+				}
+			}
+		}
+		// 写 Owner
+		owner, _ := readField(m, ownerFieldNames)
+		found, err := writeField(m, ownerFieldNames, a.createOwner(owner))
+		if !found {
+			return BadRequest("owner field not found")
+		}
+		if err != nil {
+			return BadRequest("invalid owner")
+		}
+		if cfg.Hooks.BeforeCreate != nil {
+			if err := cfg.Hooks.BeforeCreate(c, tx, m); err != nil {
+				return hookErr(err)
+			}
+		}
+		return nil
+	}
+
+	afterCreate := func(c *gin.Context, tx *gorm.DB, m *T) error {
+		if cfg.Hooks.AfterCreate != nil {
+			if err := cfg.Hooks.AfterCreate(c, tx, m); err != nil {
+				return hookErr(err)
+			}
+		}
+		return nil
+	}
+
+	createOne := func(c *gin.Context, tx *gorm.DB, a access, m *T) error {
+		if err := prepareCreate(c, tx, a, m); err != nil {
+			return err
+		}
+		if err := tx.Create(m).Error; err != nil {
+			return BadRequest(err.Error())
+		}
+		return afterCreate(c, tx, m)
+	}
+
+	updateOne := func(c *gin.Context, tx *gorm.DB, a access, id pk, ifMatch string, in *T) error {
+		// 先确认归属
+		check, err := ownedFilter(a.owner, id)
+		if err != nil {
+			return err
+		}
+		cur := cfg.New()
+		if err := check.apply(tx).First(cur).Error; err != nil {
+			return NotFound("not found")
+		}
+		if ver != nil {
+			if err := ver.checkIfMatch(ifMatch, cur, cfg.RequireIfMatch); err != nil {
+				return err
+			}
+		}
+		// 强制保持 ID/Owner（Owner 取库里的当前值，管理员改别人的记录也不会改归属）
+		owner, _ := readField(cur, ownerFieldNames)
+		_ = keys.write(in, id)
+		_, _ = writeField(in, ownerFieldNames, owner)
+
+		if cfg.Hooks.BeforeUpdate != nil {
+			if err := cfg.Hooks.BeforeUpdate(c, tx, cur, in); err != nil {
+				return hookErr(err)
+			}
+		}
+		q := check.apply(tx.Model(cfg.New()))
+		if ver != nil {
+			// 版本校验在 WHERE 里完成：期间被改过则影响 0 行
+			q = q.Where(ver.where(cur))
+			ver.bump(in, cur)
+		}
+		res := q.Updates(in)
+		if res.Error != nil {
+			return BadRequest(res.Error.Error())
+		}
+		if ver != nil && res.RowsAffected == 0 {
+			return ver.conflict(ifMatch)
+		}
+		if cfg.Hooks.AfterUpdate != nil {
+			m := cfg.New()
+			if err := check.apply(tx).First(m).Error; err != nil {
+				return Internal("reload after update failed", err)
+			}
+			if err := cfg.Hooks.AfterUpdate(c, tx, cur, m); err != nil {
+				return hookErr(err)
+			}
+		}
+		return nil
+	}
+
+	deleteOne := func(c *gin.Context, tx *gorm.DB, a access, id pk, ifMatch string) error {
+		filter, err := ownedFilter(a.owner, id)
+		if err != nil {
+			return err
+		}
+		q := filter.apply(tx)
+		checkVer := ver != nil && (cfg.RequireIfMatch || ifMatch != "")
+		// 有版本校验或删除钩子时需先读出当前记录
+		var cur *T
+		if checkVer || cfg.Hooks.BeforeDelete != nil || cfg.Hooks.AfterDelete != nil {
+			cur = cfg.New()
+			if err := filter.apply(tx).First(cur).Error; err != nil {
+				return NotFound("not found")
+			}
+		}
+		if checkVer {
+			if err := ver.checkIfMatch(ifMatch, cur, cfg.RequireIfMatch); err != nil {
+				return err
+			}
+			q = q.Where(ver.where(cur))
+		}
+		if cfg.Hooks.BeforeDelete != nil {
+			if err := cfg.Hooks.BeforeDelete(c, tx, cur); err != nil {
+				return hookErr(err)
+			}
+		}
+		res := q.Delete(cfg.New())
+		if res.Error != nil {
+			return Internal(res.Error.Error(), res.Error)
+		}
+		if res.RowsAffected == 0 {
+			if ver != nil && ifMatch != "" {
+				return ver.conflict(ifMatch)
+			}
+			return NotFound("not found")
+		}
+		if cfg.Hooks.AfterDelete != nil {
+			if err := cfg.Hooks.AfterDelete(c, tx, cur); err != nil {
+				return hookErr(err)
+			}
+		}
+		return nil
+	}
+
+	// 自定义动词：POST /path:verb（如 :batchCreate）
+	verbs := map[string]gin.HandlerFunc{}
 
 	// Create
 	if cfg.AllowCreate {
-		cfg.Group.POST(cfg.Path, func(c *gin.Context) {
-			m := cfg.New()
-			if err := c.ShouldBindJSON(m); err != nil {
-				c.JSON(http.StatusOK, resp.Error(resp.CodeBadRequest, err.Error()))
+		create := func(c *gin.Context) {
+			body, err := c.GetRawData()
+			if err != nil {
+				resp.JSON(c, resp.Error(resp.CodeBadRequest, err.Error()))
 				return
 			}
-			uid := c.GetString("userId")
-			if uid == "" {
-				c.JSON(http.StatusOK, resp.Error(resp.CodeUnauthorized, "unauthorized"))
+			if err := fields.checkWrite(body, auth.Roles(c), opCreate); err != nil {
+				resp.JSON(c, errResp(err))
 				return
 			}
-			// 自动生成 ID（若开启且为空）
-			if cfg.AutoID {
-				if id, ok := readStringField(m, idFieldNames); !ok {
-					c.JSON(http.StatusOK, resp.Error(resp.CodeBadRequest, "id field not found"))
-					return
-				} else if strings.TrimSpace(id) == "" {
-					_ = writeStringField(m, idFieldNames, cfg.IDGen())
-				}
+			m := cfg.New()
+			if err := binding.JSON.BindBody(body, m); err != nil {
+				resp.JSON(c, errResp(invalid(c, err)))
+				return
 			}
-			// 写 Owner
-			if !writeStringField(m, ownerFieldNames, uid) {
-				c.JSON(http.StatusOK, resp.Error(resp.CodeBadRequest, "owner field not found"))
+			a, err := cfg.access(c)
+			if err != nil {
+				resp.JSON(c, errResp(err))
 				return
 			}
-
-			if cfg.Hooks.BeforeCreate != nil {
-				if err := cfg.Hooks.BeforeCreate(c, m); err != nil {
-					c.JSON(http.StatusOK, resp.Error(resp.CodeBadRequest, err.Error()))
-					return
-				}
+			proj, err := fields.projection(c)
+			if err != nil {
+				resp.JSON(c, errResp(err))
+				return
 			}
-			if err := cfg.DB.WithContext(c).Create(m).Error; err != nil {
-				c.JSON(http.StatusOK, resp.Error(resp.CodeBadRequest, err.Error()))
+			if err := write(c, func(tx *gorm.DB) error { return createOne(c, tx, a, m) }); err != nil {
+				resp.JSON(c, errResp(err))
 				return
 			}
 			if cfg.Hooks.AfterGet != nil {
 				cfg.Hooks.AfterGet(c, m)
 			}
-			c.JSON(http.StatusOK, resp.OK(m))
-		})
+			proj.ok(c, m)
+		}
+		if cfg.Idempotent {
+			create = idempotent(create)
+		}
+		cfg.Group.POST(cfg.Path, create)
 	}
 
 	// List（我的）
 	if cfg.AllowList {
 		cfg.Group.GET(cfg.Path, func(c *gin.Context) {
-			uid := c.GetString("userId")
-			if uid == "" {
-				c.JSON(http.StatusOK, resp.Error(resp.CodeUnauthorized, "unauthorized"))
+			a, err := cfg.access(c)
+			if err != nil {
+				resp.JSON(c, errResp(err))
 				return
 			}
 			page := atoiDefault(c.Query("page"), 1)
@@ -212,39 +443,67 @@ func Crud[T any](cfg CrudConfig[T]) {
 				size = 20
 			}
 			offset := (page - 1) * size
+			proj, err := fields.projection(c)
+			if err != nil {
+				resp.JSON(c, errResp(err))
+				return
+			}
+			rels, err := preloads.parse(c)
+			if err != nil {
+				resp.JSON(c, errResp(err))
+				return
+			}
 
-			// 用结构体 Where 自动映射列名，避免手写 owner_id
-			ownerFilter := cfg.New()
-			if !writeStringField(ownerFilter, ownerFieldNames, uid) {
-				c.JSON(http.StatusOK, resp.Error(resp.CodeBadRequest, "owner field not found"))
+			q, sorts, err := listQuery(c, a, c.Request.URL.Query())
+			if err != nil {
+				resp.JSON(c, errResp(err))
 				return
 			}
 
-			q := cfg.DB.WithContext(c).Model(cfg.New()).Where(ownerFilter)
-			if cfg.Hooks.ScopeList != nil {
-				q = cfg.Hooks.ScopeList(c, q)
+			if cfg.Pagination == PageCursor {
+				ks := keyset
+				if len(sorts) > 0 {
+					ks = newKeyset(sch, sorts, idFieldNames...)
+				}
+				out := gin.H{}
+				if !cfg.SkipTotal {
+					var total int64
+					if err := q.Count(&total).Error; err != nil {
+						resp.JSON(c, resp.Error(resp.CodeServerError, err.Error()))
+						return
+					}
+					out["total"] = total
+				}
+				p, err := CursorPaginate[T](preload(q, rels), ks, c.Query("cursor"), size)
+				if err != nil {
+					resp.JSON(c, errResp(err))
+					return
+				}
+				if cfg.Hooks.AfterGet != nil {
+					for i := range p.List {
+						cfg.Hooks.AfterGet(c, &p.List[i])
+					}
+				}
+				list, err := projectList(proj, p.List)
+				if err != nil {
+					resp.JSON(c, errResp(err))
+					return
+				}
+				out["list"], out["size"] = list, p.Size
+				out["nextCursor"], out["prevCursor"] = p.NextCursor, p.PrevCursor
+				resp.JSON(c, resp.OK(out))
+				return
 			}
 
 			var total int64
 			if err := q.Count(&total).Error; err != nil {
-				c.JSON(http.StatusOK, resp.Error(resp.CodeServerError, err.Error()))
+				resp.JSON(c, resp.Error(resp.CodeServerError, err.Error()))
 				return
 			}
 
 			var items []T
-			// 动态排序：优先按配置 OrderBy，否则按 ID DESC
-			if cfg.OrderBy != "" {
-				q = q.Order(cfg.OrderBy)
-			} else {
-				// 用字段名转 snake_case（例如 ID -> id）
-				idCol := toSnake(idFieldNames[0])
-				if idCol == "" {
-					idCol = "id"
-				}
-				q = q.Order(clause.OrderByColumn{Column: clause.Column{Name: idCol}, Desc: true})
-			}
-			if err := q.Limit(size).Offset(offset).Find(&items).Error; err != nil {
-				c.JSON(http.StatusOK, resp.Error(resp.CodeServerError, err.Error()))
+			if err := preload(listOrder(q, sorts), rels).Limit(size).Offset(offset).Find(&items).Error; err != nil {
+				resp.JSON(c, resp.Error(resp.CodeServerError, err.Error()))
 				return
 			}
 			if cfg.Hooks.AfterGet != nil {
@@ -252,107 +511,342 @@ func Crud[T any](cfg CrudConfig[T]) {
 					cfg.Hooks.AfterGet(c, &items[i])
 				}
 			}
-			c.JSON(http.StatusOK, resp.OK(gin.H{
-				"list": items, "total": total, "page": page, "size": size,
+			list, err := projectList(proj, items)
+			if err != nil {
+				resp.JSON(c, errResp(err))
+				return
+			}
+			resp.JSON(c, resp.OK(gin.H{
+				"list": list, "total": total, "page": page, "size": size,
 			}))
 		})
 	}
 
 	// Get
 	if cfg.AllowGet {
-		cfg.Group.GET(cfg.Path+"/:id", func(c *gin.Context) {
-			uid := c.GetString("userId")
-			if uid == "" {
-				c.JSON(http.StatusOK, resp.Error(resp.CodeUnauthorized, "unauthorized"))
+		cfg.Group.GET(cfg.Path+keys.route(), func(c *gin.Context) {
+			a, err := cfg.access(c)
+			if err != nil {
+				resp.JSON(c, errResp(err))
+				return
+			}
+			id := keys.fromParams(c)
+			proj, err := fields.projection(c)
+			if err != nil {
+				resp.JSON(c, errResp(err))
 				return
 			}
-			id := c.Param("id")
 
-			filter := cfg.New()
-			_ = writeStringField(filter, idFieldNames, id)
-			_ = writeStringField(filter, ownerFieldNames, uid)
+			rels, err := preloads.parse(c)
+			if err != nil {
+				resp.JSON(c, errResp(err))
+				return
+			}
 
+			filter, err := ownedFilter(a.owner, id)
+			if err != nil {
+				resp.JSON(c, errResp(err))
+				return
+			}
 			m := cfg.New()
-			if err := cfg.DB.WithContext(c).Where(filter).First(m).Error; err != nil {
-				c.JSON(http.StatusOK, resp.Error(resp.CodeNotFound, "not found"))
+			if err := filter.apply(preload(cfg.DB.WithContext(c), rels)).First(m).Error; err != nil {
+				resp.JSON(c, resp.Error(resp.CodeNotFound, "not found"))
 				return
 			}
+			if ver != nil {
+				tag := ver.etag(m)
+				c.Header("ETag", tag)
+				if inm := c.GetHeader("If-None-Match"); inm != "" && etagMatch(inm, tag) {
+					c.Status(http.StatusNotModified)
+					return
+				}
+			}
 			if cfg.Hooks.AfterGet != nil {
 				cfg.Hooks.AfterGet(c, m)
 			}
-			c.JSON(http.StatusOK, resp.OK(m))
+			proj.ok(c, m)
 		})
 	}
 
 	// Update
 	if cfg.AllowUpdate {
-		cfg.Group.PUT(cfg.Path+"/:id", func(c *gin.Context) {
-			uid := c.GetString("userId")
-			if uid == "" {
-				c.JSON(http.StatusOK, resp.Error(resp.CodeUnauthorized, "unauthorized"))
+		cfg.Group.PUT(cfg.Path+keys.route(), func(c *gin.Context) {
+			a, err := cfg.access(c)
+			if err != nil {
+				resp.JSON(c, errResp(err))
 				return
 			}
-			id := c.Param("id")
+			id := keys.fromParams(c)
 
-			// 先确认归属
-			check := cfg.New()
-			_ = writeStringField(check, idFieldNames, id)
-			_ = writeStringField(check, ownerFieldNames, uid)
-			if err := cfg.DB.WithContext(c).Where(check).First(check).Error; err != nil {
-				c.JSON(http.StatusOK, resp.Error(resp.CodeNotFound, "not found"))
+			body, err := c.GetRawData()
+			if err != nil {
+				resp.JSON(c, resp.Error(resp.CodeBadRequest, err.Error()))
+				return
+			}
+			if err := fields.checkWrite(body, auth.Roles(c), opUpdate); err != nil {
+				resp.JSON(c, errResp(err))
 				return
 			}
-
 			in := cfg.New()
-			if err := c.ShouldBindJSON(in); err != nil {
-				c.JSON(http.StatusOK, resp.Error(resp.CodeBadRequest, err.Error()))
+			if err := binding.JSON.BindBody(body, in); err != nil {
+				resp.JSON(c, errResp(invalid(c, err)))
+				return
+			}
+			db := cfg.DB.WithContext(c)
+			err = write(c, func(tx *gorm.DB) error {
+				return updateOne(c, tx, a, id, c.GetHeader("If-Match"), in)
+			})
+			if err != nil {
+				resp.JSON(c, errResp(err))
 				return
 			}
-			// 强制保持 ID/Owner
-			_ = writeStringField(in, idFieldNames, id)
-			_ = writeStringField(in, ownerFieldNames, uid)
+			if ver != nil {
+				c.Header("ETag", ver.etagAfter(in, func() (any, error) {
+					filter, err := ownedFilter(a.owner, id)
+					if err != nil {
+						return nil, err
+					}
+					m := cfg.New()
+					return m, filter.apply(db).First(m).Error
+				}))
+			}
+			if cfg.Hooks.AfterGet != nil {
+				cfg.Hooks.AfterGet(c, in)
+			}
+			resp.JSON(c, resp.OK(gin.H{"id": keys.out(id)}))
+		})
+	}
 
-			if cfg.Hooks.BeforeUpdate != nil {
-				if err := cfg.Hooks.BeforeUpdate(c, in); err != nil {
-					c.JSON(http.StatusOK, resp.Error(resp.CodeBadRequest, err.Error()))
+	// Patch（只改请求里出现的字段）
+	if cfg.AllowPatch {
+		readOnly := cfg.ReadOnlyFields
+		if cfg.VersionField != "" {
+			readOnly = append(append([]string{}, readOnly...), cfg.VersionField)
+		}
+		patch := newPatchSpec(sch, readOnly, idFieldNames, ownerFieldNames)
+		cfg.Group.PATCH(cfg.Path+keys.route(), func(c *gin.Context) {
+			a, err := cfg.access(c)
+			if err != nil {
+				resp.JSON(c, errResp(err))
+				return
+			}
+			id := keys.fromParams(c)
+			proj, err := fields.projection(c)
+			if err != nil {
+				resp.JSON(c, errResp(err))
+				return
+			}
+
+			check, err := ownedFilter(a.owner, id)
+			if err != nil {
+				resp.JSON(c, errResp(err))
+				return
+			}
+			m := cfg.New()
+			if err := check.apply(cfg.DB.WithContext(c)).First(m).Error; err != nil {
+				resp.JSON(c, resp.Error(resp.CodeNotFound, "not found"))
+				return
+			}
+			ifMatch := c.GetHeader("If-Match")
+			if ver != nil {
+				if err := ver.checkIfMatch(ifMatch, m, cfg.RequireIfMatch); err != nil {
+					resp.JSON(c, errResp(err))
 					return
 				}
 			}
-			if err := cfg.DB.WithContext(c).Model(cfg.New()).Where(check).Updates(in).Error; err != nil {
-				c.JSON(http.StatusOK, resp.Error(resp.CodeBadRequest, err.Error()))
+
+			body, err := c.GetRawData()
+			if err != nil {
+				resp.JSON(c, resp.Error(resp.CodeBadRequest, err.Error()))
+				return
+			}
+			old := cfg.New()
+			*old = *m
+			roles := auth.Roles(c)
+			cols, err := patch.apply(m, body, c.ContentType(), func(f *schema.Field) bool {
+				return fields.writable(f, roles, opUpdate)
+			})
+			if err != nil {
+				resp.JSON(c, errResp(err))
+				return
+			}
+			if len(cols) == 0 {
+				proj.ok(c, m)
 				return
 			}
+			// 合并后的结果按 binding 规则整体校验
+			if err := binding.Validator.ValidateStruct(m); err != nil {
+				resp.JSON(c, errResp(invalid(c, err)))
+				return
+			}
+
+			err = write(c, func(tx *gorm.DB) error {
+				if cfg.Hooks.BeforeUpdate != nil {
+					if err := cfg.Hooks.BeforeUpdate(c, tx, old, m); err != nil {
+						return hookErr(err)
+					}
+				}
+				q := check.apply(tx.Model(m))
+				if ver != nil {
+					q = q.Where(ver.where(m))
+					ver.bump(m, m)
+					if ver.f.AutoUpdateTime == 0 {
+						cols = append(cols, ver.f.DBName)
+					}
+				}
+				res := q.Select(cols).Updates(m)
+				if res.Error != nil {
+					return BadRequest(res.Error.Error())
+				}
+				if ver != nil && res.RowsAffected == 0 {
+					return ver.conflict(ifMatch)
+				}
+				if cfg.Hooks.AfterUpdate != nil {
+					if err := cfg.Hooks.AfterUpdate(c, tx, old, m); err != nil {
+						return hookErr(err)
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				resp.JSON(c, errResp(err))
+				return
+			}
+			if ver != nil {
+				c.Header("ETag", ver.etagAfter(m, func() (any, error) {
+					return m, check.apply(cfg.DB.WithContext(c)).First(m).Error
+				}))
+			}
 			if cfg.Hooks.AfterGet != nil {
-				cfg.Hooks.AfterGet(c, in)
+				cfg.Hooks.AfterGet(c, m)
 			}
-			c.JSON(http.StatusOK, resp.OK(gin.H{"id": id}))
+			proj.ok(c, m)
 		})
 	}
 
 	// Delete
 	if cfg.AllowDelete {
-		cfg.Group.DELETE(cfg.Path+"/:id", func(c *gin.Context) {
-			uid := c.GetString("userId")
-			if uid == "" {
-				c.JSON(http.StatusOK, resp.Error(resp.CodeUnauthorized, "unauthorized"))
+		cfg.Group.DELETE(cfg.Path+keys.route(), func(c *gin.Context) {
+			a, err := cfg.access(c)
+			if err != nil {
+				resp.JSON(c, errResp(err))
 				return
 			}
-			id := c.Param("id")
+			id := keys.fromParams(c)
+			err = write(c, func(tx *gorm.DB) error {
+				return deleteOne(c, tx, a, id, c.GetHeader("If-Match"))
+			})
+			if err != nil {
+				resp.JSON(c, errResp(err))
+				return
+			}
+			resp.JSON(c, resp.OK(gin.H{"id": keys.out(id)}))
+		})
+	}
 
-			filter := cfg.New()
-			_ = writeStringField(filter, idFieldNames, id)
-			_ = writeStringField(filter, ownerFieldNames, uid)
+	// 批量：一个事务内逐条执行（Owner/钩子照常），可选全有或全无
+	if cfg.AllowBatch {
+		b := batchSpec[T]{cfg: &cfg, keys: keys, fields: fields}
+		if cfg.AllowCreate {
+			verbs[":batchCreate"] = b.handler(func(c *gin.Context, tx *gorm.DB, a access, req *batchReq, atomic bool) ([]BatchResult, error) {
+				return b.create(c, tx, a, req.Items, atomic, prepareCreate, afterCreate)
+			})
+		}
+		if cfg.AllowUpdate {
+			verbs[":batchUpdate"] = b.handler(func(c *gin.Context, tx *gorm.DB, a access, req *batchReq, atomic bool) ([]BatchResult, error) {
+				return b.each(tx, len(req.Items), atomic, func(tx *gorm.DB, i int) (any, error) {
+					in := cfg.New()
+					if err := fields.checkWrite(req.Items[i], auth.Roles(c), opUpdate); err != nil {
+						return nil, err
+					}
+					if err := decodeItem(c, req.Items[i], in); err != nil {
+						return nil, err
+					}
+					id := keys.read(in)
+					// 条目自带版本号时视作 If-Match
+					ifMatch := ""
+					if ver != nil {
+						ifMatch = ver.itemIfMatch(in)
+					}
+					return keys.outOf(in), updateOne(c, tx, a, id, ifMatch, in)
+				})
+			})
+		}
+		if cfg.AllowDelete {
+			verbs[":batchDelete"] = b.handler(func(c *gin.Context, tx *gorm.DB, a access, req *batchReq, atomic bool) ([]BatchResult, error) {
+				return b.each(tx, len(req.IDs), atomic, func(tx *gorm.DB, i int) (any, error) {
+					id, err := keys.fromJSON(req.IDs[i])
+					if err != nil {
+						return nil, err
+					}
+					return keys.out(id), deleteOne(c, tx, a, id, "")
+				})
+			})
+		}
+	}
 
-			res := cfg.DB.WithContext(c).Where(filter).Delete(cfg.New())
-			if res.Error != nil {
-				c.JSON(http.StatusOK, resp.Error(resp.CodeServerError, res.Error.Error()))
+	// Export：同列表的过滤/归属/排序/fields，流式输出 CSV/NDJSON/XLSX
+	if cfg.AllowExport {
+		// 导出列：模型上的数据库字段（关联不导出），按结构体字段顺序
+		var exportCols []string
+		for _, name := range structColumns(reflect.TypeOf(cfg.New())) {
+			if f := lookupField(sch, name); f != nil && jsonName(f) == name {
+				exportCols = append(exportCols, name)
+			}
+		}
+		cfg.Group.GET(cfg.Path+"/export", func(c *gin.Context) {
+			a, err := cfg.access(c)
+			if err != nil {
+				resp.JSON(c, errResp(err))
+				return
+			}
+			proj, err := fields.projection(c)
+			if err != nil {
+				resp.JSON(c, errResp(err))
+				return
+			}
+			values := c.Request.URL.Query()
+			values.Del("format")
+			q, sorts, err := listQuery(c, a, values)
+			if err != nil {
+				resp.JSON(c, errResp(err))
 				return
 			}
-			if res.RowsAffected == 0 {
-				c.JSON(http.StatusOK, resp.Error(resp.CodeNotFound, "not found"))
+			opt := ExportOptions[T]{Filename: sch.Table, Columns: proj.columns(exportCols), WriteTimeout: cfg.ExportTimeout}
+			if cfg.Hooks.AfterGet != nil {
+				opt.Row = func(m *T) any {
+					cfg.Hooks.AfterGet(c, m)
+					return m
+				}
+			}
+			if err := Export(c, listOrder(q, sorts), c.Query("format"), opt); err != nil && !c.Writer.Written() {
+				resp.JSON(c, errResp(err))
+			}
+		})
+	}
+
+	if cfg.AllowImport {
+		importSpec[T]{
+			cfg: &cfg, sch: sch,
+			batch:   batchSpec[T]{cfg: &cfg, keys: keys, fields: fields},
+			prepare: prepareCreate, after: afterCreate,
+		}.mount()
+	}
+
+	if cfg.AllowTrash {
+		newTrashSpec(&cfg, sch, ownedFilter).mount(spec, fields)
+	}
+
+	recordCrud(&cfg, keys, fields, spec, preloads)
+
+	if len(verbs) > 0 {
+		cfg.Group.POST(cfg.Path+":verb", func(c *gin.Context) {
+			h, ok := verbs[c.Param("verb")]
+			if !ok {
+				resp.JSON(c, resp.Error(resp.CodeNotFound, "not found"))
 				return
 			}
-			c.JSON(http.StatusOK, resp.OK(gin.H{"id": id}))
+			h(c)
 		})
 	}
 }
@@ -0,0 +1,238 @@
+package ez
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"gorm.io/gorm"
+
+	"go-gin-gorm-starter/internal/core/auth"
+	resp "go-gin-gorm-starter/internal/transport/http/response"
+)
+
+/* ================== 批量写入 ==================
+   POST /path:batchCreate  {"items":[{...}], "allOrNothing":true}
+   POST /path:batchUpdate  {"items":[{"id":"..",...}]}
+   POST /path:batchDelete  {"ids":["..",".."]}
+   - 全部在一个事务里；allOrNothing=false 时每条一个保存点，失败只回滚本条
+   - 创建按 CreateBatchSize 分块插入
+*/
+
+type batchReq struct {
+	Items        []json.RawMessage `json:"items"`
+	IDs          []json.RawMessage `json:"ids"`          // 复合主键为 {"param": v} 或 [v1, v2]
+	AllOrNothing *bool             `json:"allOrNothing"` // 默认 true
+}
+
+// 单条结果
+type BatchResult struct {
+	Index  int          `json:"index"`
+	ID     any          `json:"id,omitempty"`
+	OK     bool         `json:"ok"`
+	Code   int          `json:"code,omitempty"`
+	Msg    string       `json:"msg,omitempty"`
+	Errors []FieldError `json:"errors,omitempty"` // 校验失败时的字段错误
+}
+
+func batchResult(i int, id any, err error) BatchResult {
+	if err == nil {
+		return BatchResult{Index: i, ID: id, OK: true}
+	}
+	r := errResp(err)
+	return BatchResult{Index: i, ID: id, Code: r.Code, Msg: r.Msg, Errors: fieldErrors(err)}
+}
+
+type batchRun func(c *gin.Context, tx *gorm.DB, a access, req *batchReq, atomic bool) ([]BatchResult, error)
+
+type batchSpec[T any] struct {
+	cfg    *CrudConfig[T]
+	keys   keySpec
+	fields *fieldSpec
+}
+
+func (b batchSpec[T]) handler(run batchRun) gin.HandlerFunc {
+	limit := b.cfg.BatchMaxItems
+	if limit <= 0 {
+		limit = 1000
+	}
+	return func(c *gin.Context) {
+		a, err := b.cfg.access(c)
+		if err != nil {
+			resp.JSON(c, errResp(err))
+			return
+		}
+		var req batchReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			resp.JSON(c, errResp(invalid(c, err)))
+			return
+		}
+		n := len(req.Items) + len(req.IDs)
+		if n == 0 {
+			resp.JSON(c, resp.Error(resp.CodeBadRequest, "empty batch"))
+			return
+		}
+		if n > limit {
+			resp.JSON(c, resp.Error(resp.CodeBadRequest, fmt.Sprintf("too many items (max %d)", limit)))
+			return
+		}
+		atomic := req.AllOrNothing == nil || *req.AllOrNothing
+
+		var results []BatchResult
+		err = b.cfg.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+			r, err := run(c, tx, a, &req, atomic)
+			results = r
+			return err
+		})
+
+		out := gin.H{"results": results, "succeeded": 0, "failed": 0}
+		ok := 0
+		for _, r := range results {
+			if r.OK {
+				ok++
+			}
+		}
+		out["succeeded"], out["failed"] = ok, len(results)-ok
+		if err != nil {
+			// 全有或全无：整体回滚，code 取失败条目的 code
+			var ae *AErr
+			if !errors.As(err, &ae) {
+				ae = &AErr{Code: resp.CodeServerError, Msg: err.Error()}
+			}
+			out["succeeded"] = 0
+			resp.JSON(c, resp.New(ae.Code, "batch rolled back: "+ae.Error(), out))
+			return
+		}
+		resp.JSON(c, resp.OK(out))
+	}
+}
+
+// each 逐条执行 fn；非原子模式下每条包一个保存点
+func (b batchSpec[T]) each(tx *gorm.DB, n int, atomic bool, fn func(tx *gorm.DB, i int) (any, error)) ([]BatchResult, error) {
+	results := make([]BatchResult, 0, n)
+	for i := 0; i < n; i++ {
+		var id any
+		var err error
+		if atomic {
+			id, err = fn(tx, i)
+		} else {
+			err = tx.Transaction(func(sp *gorm.DB) error {
+				var e error
+				id, e = fn(sp, i)
+				return e
+			})
+		}
+		results = append(results, batchResult(i, id, err))
+		if err != nil && atomic {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// create 先逐条校验，再按 CreateBatchSize 分块执行 Before 钩子 + 插入 + After 钩子
+// 非原子模式下每块一个保存点（钩子的写入与插入同进同退），分块失败时逐条各一个保存点重来，定位失败项
+func (b batchSpec[T]) create(c *gin.Context, tx *gorm.DB, a access, items []json.RawMessage, atomic bool,
+	prepare func(c *gin.Context, tx *gorm.DB, a access, m *T) error,
+	after func(c *gin.Context, tx *gorm.DB, m *T) error) ([]BatchResult, error) {
+	results := make([]BatchResult, len(items))
+	var ready []*T
+	var index []int
+	for i, raw := range items {
+		m, err := b.decodeCreate(c, raw)
+		if err == nil && atomic {
+			err = prepare(c, tx, a, m)
+		}
+		results[i] = batchResult(i, b.keys.outOf(m), err)
+		if err != nil {
+			if atomic {
+				return results[:i+1], err
+			}
+			continue
+		}
+		ready = append(ready, m)
+		index = append(index, i)
+	}
+
+	// 原子模式下 Before 钩子已在上面逐条跑过
+	insert := func(tx *gorm.DB, ms []*T) error {
+		if !atomic {
+			for _, m := range ms {
+				if err := prepare(c, tx, a, m); err != nil {
+					return err
+				}
+			}
+		}
+		if err := tx.Create(ms).Error; err != nil {
+			return BadRequest(err.Error())
+		}
+		for _, m := range ms {
+			if err := after(c, tx, m); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	size := tx.CreateBatchSize
+	if size <= 0 {
+		size = 100
+	}
+	for start := 0; start < len(ready); start += size {
+		end := min(start+size, len(ready))
+		chunk := ready[start:end]
+		if atomic {
+			if err := insert(tx, chunk); err != nil {
+				for k := range chunk {
+					i := index[start+k]
+					results[i] = batchResult(i, results[i].ID, err)
+				}
+				return results, err
+			}
+		} else if err := tx.Transaction(func(sp *gorm.DB) error { return insert(sp, chunk) }); err != nil {
+			// 整块已回滚：按原始请求重新解码再逐条重来，
+			// 丢掉失败那次留在模型上的改动（数据库回填的自增主键、Before 钩子生成的值）
+			for k := range chunk {
+				i := index[start+k]
+				m, err := b.decodeCreate(c, items[i])
+				if err == nil {
+					err = tx.Transaction(func(sp *gorm.DB) error { return insert(sp, []*T{m}) })
+				}
+				chunk[k] = m
+				if err != nil {
+					results[i] = batchResult(i, results[i].ID, err)
+				}
+			}
+		}
+		// 自增主键插入后才有值
+		for k, m := range chunk {
+			if i := index[start+k]; results[i].OK {
+				results[i].ID = b.keys.outOf(m)
+			}
+		}
+	}
+	return results, nil
+}
+
+// 单条：字段写权限 + 解码校验
+func (b batchSpec[T]) decodeCreate(c *gin.Context, raw json.RawMessage) (*T, error) {
+	m := b.cfg.New()
+	err := b.fields.checkWrite(raw, auth.Roles(c), opCreate)
+	if err == nil {
+		err = decodeItem(c, raw, m)
+	}
+	return m, err
+}
+
+// 单条解码 + binding 校验
+func decodeItem(c *gin.Context, raw json.RawMessage, m any) error {
+	if err := json.Unmarshal(raw, m); err != nil {
+		return invalid(c, err)
+	}
+	if err := binding.Validator.ValidateStruct(m); err != nil {
+		return invalid(c, err)
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package ez_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	httpez "go-gin-gorm-starter/internal/transport/http/ez"
+)
+
+type batchItem struct {
+	ID      int64  `gorm:"primaryKey" json:"id"`
+	OwnerID string `gorm:"size:36;index" json:"ownerId"`
+	SKU     string `gorm:"size:32;uniqueIndex" json:"sku" binding:"required"`
+	Qty     int    `json:"qty" binding:"gte=0"`
+}
+
+type batchOut struct {
+	Results   []httpez.BatchResult `json:"results"`
+	Succeeded int                  `json:"succeeded"`
+	Failed    int                  `json:"failed"`
+}
+
+func TestBatchCreate(t *testing.T) {
+	db := newDB(t)
+	r, g := newEngine(t)
+	httpez.Crud(httpez.CrudConfig[batchItem]{
+		DB: db, Group: g, Path: "/items", New: func() *batchItem { return &batchItem{} },
+		AllowCreate: true, AllowBatch: true, BatchMaxItems: 5,
+	})
+	alice := as("alice")
+	batch := func(body any) (int, batchOut) {
+		t.Helper()
+		w, res := call(t, r, http.MethodPost, "/api/items:batchCreate", alice, body)
+		return w.Code, decode[batchOut](t, res)
+	}
+
+	code, out := batch(map[string]any{"items": []any{
+		map[string]any{"sku": "a", "qty": 1}, map[string]any{"sku": "b"}, map[string]any{"sku": "c"},
+	}})
+	if code != http.StatusOK || out.Succeeded != 3 || out.Failed != 0 {
+		t.Fatalf("create: %d %+v", code, out)
+	}
+	for _, res := range out.Results {
+		if !res.OK || res.ID == nil {
+			t.Fatalf("result without id: %+v", res)
+		}
+	}
+
+	// 全有或全无（默认）：一条校验失败整体回滚，失败条目带字段错误
+	code, out = batch(map[string]any{"items": []any{
+		map[string]any{"sku": "d"}, map[string]any{"sku": "e", "qty": -1},
+	}})
+	if code != http.StatusBadRequest || out.Succeeded != 0 {
+		t.Fatalf("atomic invalid: %d %+v", code, out)
+	}
+	if last := out.Results[len(out.Results)-1]; last.Index != 1 || last.OK || len(last.Errors) == 0 || last.Errors[0].Field != "qty" {
+		t.Fatalf("failed item: %+v", last)
+	}
+	// 插入阶段失败（唯一键冲突）同样整体回滚
+	code, _ = batch(map[string]any{"items": []any{map[string]any{"sku": "f"}, map[string]any{"sku": "a"}}})
+	if code != http.StatusBadRequest {
+		t.Fatalf("atomic duplicate: %d, want 400", code)
+	}
+	if n := count[batchItem](t, db); n != 3 {
+		t.Fatalf("%d rows after rolled back batches, want 3", n)
+	}
+
+	// 部分成功：失败条目单独回滚，其余照常写入
+	code, out = batch(map[string]any{"allOrNothing": false, "items": []any{
+		map[string]any{"sku": "g"}, map[string]any{"qty": 1}, map[string]any{"sku": "b"}, map[string]any{"sku": "h"},
+	}})
+	if code != http.StatusOK || out.Succeeded != 2 || out.Failed != 2 {
+		t.Fatalf("partial: %d %+v", code, out)
+	}
+	for i, ok := range []bool{true, false, false, true} {
+		if out.Results[i].OK != ok || out.Results[i].Index != i {
+			t.Fatalf("partial result %d: %+v", i, out.Results[i])
+		}
+	}
+	if n := count[batchItem](t, db); n != 5 {
+		t.Fatalf("%d rows after partial batch, want 5", n)
+	}
+	var owners []string
+	db.Model(&batchItem{}).Distinct().Pluck("owner_id", &owners)
+	if len(owners) != 1 || owners[0] != "alice" {
+		t.Fatalf("batch rows owners: %v", owners)
+	}
+
+	// 空批次、超出上限
+	if code, _ := batch(map[string]any{"items": []any{}}); code != http.StatusBadRequest {
+		t.Fatalf("empty batch: %d, want 400", code)
+	}
+	six := make([]any, 6)
+	for i := range six {
+		six[i] = map[string]any{"sku": "x"}
+	}
+	if code, _ := batch(map[string]any{"items": six}); code != http.StatusBadRequest {
+		t.Fatalf("oversized batch: %d, want 400", code)
+	}
+	if w, _ := call(t, r, http.MethodPost, "/api/items:batchDelete", alice, map[string]any{"ids": []int{1}}); w.Code != http.StatusNotFound {
+		t.Fatalf("batchDelete not enabled: %d, want 404", w.Code)
+	}
+}
+
+func TestBatchUpdateAndDelete(t *testing.T) {
+	db := newDB(t)
+	r, g := newEngine(t)
+	httpez.Crud(httpez.CrudConfig[batchItem]{
+		DB: db, Group: g, Path: "/items", New: func() *batchItem { return &batchItem{} },
+		AllowCreate: true, AllowUpdate: true, AllowDelete: true, AllowBatch: true,
+	})
+	alice, bob := as("alice"), as("bob")
+	ids := map[string]int64{}
+	for _, s := range []struct {
+		sku string
+		hdr http.Header
+	}{{"a", alice}, {"b", alice}, {"bob", bob}} {
+		_, res := call(t, r, http.MethodPost, "/api/items", s.hdr, map[string]any{"sku": s.sku})
+		ids[s.sku] = decode[batchItem](t, res).ID
+	}
+	qty := func(sku string) int {
+		t.Helper()
+		var m batchItem
+		if err := db.Where("sku = ?", sku).First(&m).Error; err != nil {
+			t.Fatal(err)
+		}
+		return m.Qty
+	}
+
+	// 更新别人的记录：该条 404，原子模式整体回滚
+	w, res := call(t, r, http.MethodPost, "/api/items:batchUpdate", alice, map[string]any{"items": []any{
+		map[string]any{"id": ids["a"], "sku": "a", "qty": 5},
+		map[string]any{"id": ids["bob"], "sku": "bob", "qty": 5},
+	}})
+	out := decode[batchOut](t, res)
+	if w.Code != http.StatusNotFound || out.Results[1].Code != http.StatusNotFound {
+		t.Fatalf("atomic update: %d %+v", w.Code, out)
+	}
+	if qty("a") != 0 || qty("bob") != 0 {
+		t.Fatalf("rolled back update was written")
+	}
+	w, res = call(t, r, http.MethodPost, "/api/items:batchUpdate", alice, map[string]any{"allOrNothing": false, "items": []any{
+		map[string]any{"id": ids["a"], "sku": "a", "qty": 5},
+		map[string]any{"id": ids["bob"], "sku": "bob", "qty": 5},
+		map[string]any{"id": ids["b"], "sku": "b", "qty": 7},
+	}})
+	if out := decode[batchOut](t, res); w.Code != http.StatusOK || out.Succeeded != 2 || out.Failed != 1 {
+		t.Fatalf("partial update: %d %+v", w.Code, out)
+	}
+	if qty("a") != 5 || qty("b") != 7 || qty("bob") != 0 {
+		t.Fatalf("partial update: a=%d b=%d bob=%d", qty("a"), qty("b"), qty("bob"))
+	}
+
+	// 删除：不存在或不属于自己的 id 404，id 0 不会误删
+	w, res = call(t, r, http.MethodPost, "/api/items:batchDelete", alice, map[string]any{"ids": []int64{ids["a"], 0}})
+	if out := decode[batchOut](t, res); w.Code != http.StatusNotFound || out.Results[1].OK {
+		t.Fatalf("atomic delete: %d %+v", w.Code, out)
+	}
+	if n := count[batchItem](t, db); n != 3 {
+		t.Fatalf("%d rows after rolled back delete, want 3", n)
+	}
+	w, res = call(t, r, http.MethodPost, "/api/items:batchDelete", alice, map[string]any{"allOrNothing": false, "ids": []int64{ids["a"], ids["bob"], ids["b"]}})
+	if out := decode[batchOut](t, res); w.Code != http.StatusOK || out.Succeeded != 2 || out.Failed != 1 {
+		t.Fatalf("partial delete: %d %+v", w.Code, out)
+	}
+	if n := count[batchItem](t, db); n != 1 {
+		t.Fatalf("%d rows after partial delete, want 1", n)
+	}
+}
+
+func TestBatchCreateHooksShareSavepoint(t *testing.T) {
+	db := newDB(t)
+	if err := db.AutoMigrate(&auditEntry{}); err != nil {
+		t.Fatal(err)
+	}
+	r, g := newEngine(t)
+	httpez.Crud(httpez.CrudConfig[batchItem]{
+		DB: db, Group: g, Path: "/items", New: func() *batchItem { return &batchItem{} },
+		AllowCreate: true, AllowBatch: true,
+		Hooks: httpez.CrudHooks[batchItem]{
+			// Before 钩子既写库又改模型：重试时两者都不能叠加
+			BeforeCreate: func(c *gin.Context, tx *gorm.DB, m *batchItem) error {
+				m.Qty++
+				return tx.Create(&auditEntry{Action: "before", Detail: m.SKU}).Error
+			},
+			AfterCreate: func(c *gin.Context, tx *gorm.DB, m *batchItem) error {
+				return tx.Create(&auditEntry{Action: "after", DocID: m.ID, Detail: m.SKU}).Error
+			},
+		},
+	})
+	alice := as("alice")
+	call(t, r, http.MethodPost, "/api/items", alice, map[string]any{"sku": "dup"})
+	db.Where("1 = 1").Delete(&auditEntry{})
+
+	// 非原子：中间一条插入冲突，分块回滚后逐条重来
+	w, res := call(t, r, http.MethodPost, "/api/items:batchCreate", alice, map[string]any{"allOrNothing": false, "items": []any{
+		map[string]any{"sku": "x"}, map[string]any{"sku": "dup"}, map[string]any{"sku": "y", "qty": 5},
+	}})
+	out := decode[batchOut](t, res)
+	if w.Code != http.StatusOK || out.Succeeded != 2 || out.Failed != 1 || out.Results[1].OK {
+		t.Fatalf("partial create: %d %+v", w.Code, out)
+	}
+	// 失败条目的 Before 写入随它一起回滚
+	var audits []auditEntry
+	db.Order("id").Find(&audits)
+	got := map[string]int{}
+	for _, a := range audits {
+		got[a.Action+":"+a.Detail]++
+	}
+	if len(audits) != 4 || got["before:x"] != 1 || got["before:y"] != 1 || got["after:x"] != 1 || got["after:y"] != 1 {
+		t.Fatalf("audit rows: %+v", audits)
+	}
+	// 返回的 id 是重试后真正落库的那一行；模型改动没有在重试时叠加
+	for _, tc := range []struct {
+		idx int
+		sku string
+		qty int
+	}{{0, "x", 1}, {2, "y", 6}} {
+		var m batchItem
+		if err := db.Where("sku = ?", tc.sku).First(&m).Error; err != nil {
+			t.Fatal(err)
+		}
+		if id, _ := out.Results[tc.idx].ID.(float64); int64(id) != m.ID || m.Qty != tc.qty {
+			t.Fatalf("%s: result id %v, row %+v, want qty %d", tc.sku, out.Results[tc.idx].ID, m, tc.qty)
+		}
+		for _, a := range audits {
+			if a.Action == "after" && a.Detail == tc.sku && a.DocID != m.ID {
+				t.Fatalf("after hook saw id %d, row id %d", a.DocID, m.ID)
+			}
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package ez
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+/* ================== 入参绑定 ==================
+   单一来源直接走 gin 的 ShouldBindXxx（含校验）；
+   组合来源 Bind(BindURI, BindQuery, BindJSON)：各来源按各自 tag（uri / header / form / json）
+   填同一个结构体，同一字段多个来源都有值时按参数顺序后者覆盖，全部合并后只校验一次；
+   例外：路由参数总是最后映射——URL 指定的资源不能被 body / query 里的同名字段改掉
+   （encoding/json 按字段名不区分大小写匹配，没有 json tag 的 ID 字段也会被 {"id": ...} 填上）
+*/
+
+const multipartMemory = 32 << 20
+
+// Bind 组合多个来源：Bind(BindURI, BindJSON) → "uri+json"
+func Bind(sources ...Binder) Binder {
+	parts := make([]string, len(sources))
+	for i, s := range sources {
+		parts[i] = string(s)
+	}
+	return Binder(strings.Join(parts, "+"))
+}
+
+func bindInput(c *gin.Context, b Binder, in any) error {
+	switch b {
+	case BindJSON:
+		return c.ShouldBindJSON(in)
+	case BindQuery:
+		return c.ShouldBindQuery(in)
+	case BindURI:
+		return c.ShouldBindUri(in)
+	case BindHeader:
+		return c.ShouldBindHeader(in)
+	case BindForm:
+		return c.ShouldBindWith(in, binding.FormPost)
+	case BindMultipart:
+		return c.ShouldBindWith(in, binding.FormMultipart)
+	case BindNone, "":
+		return nil
+	}
+	if !strings.Contains(string(b), "+") {
+		return errors.New("unknown binder: " + string(b))
+	}
+	if err := bindSources(c, strings.Split(string(b), "+"), in); err != nil {
+		return err
+	}
+	if binding.Validator == nil {
+		return nil
+	}
+	return binding.Validator.ValidateStruct(in)
+}
+
+// bindSources 只映射不校验；query / form / multipart 的值先合并再映射一次（default= 只生效一次）
+func bindSources(c *gin.Context, sources []string, in any) error {
+	sources = uriLast(sources)
+	var formVals url.Values
+	var files map[string][]*multipart.FileHeader
+	mapFormVals := func() error {
+		if formVals == nil {
+			return nil
+		}
+		vals := formVals
+		formVals = nil
+		return binding.MapFormWithTag(in, vals, "form")
+	}
+	mergeForm := func(vals url.Values) {
+		if formVals == nil {
+			formVals = url.Values{}
+		}
+		for k, v := range vals {
+			formVals[k] = v
+		}
+	}
+
+	for i, src := range sources {
+		// 紧随其后的也是表单类来源时先攒着
+		nextIsForm := i+1 < len(sources) && isFormSource(sources[i+1])
+		switch Binder(src) {
+		case BindURI:
+			m := make(map[string][]string, len(c.Params))
+			for _, p := range c.Params {
+				m[p.Key] = []string{p.Value}
+			}
+			if err := binding.MapFormWithTag(in, m, "uri"); err != nil {
+				return err
+			}
+		case BindHeader:
+			m := map[string][]string{}
+			for _, name := range tagNames(reflect.TypeOf(in), "header") {
+				if v := c.Request.Header.Values(name); len(v) > 0 {
+					m[name] = v
+				}
+			}
+			if err := binding.MapFormWithTag(in, m, "header"); err != nil {
+				return err
+			}
+		case BindQuery:
+			mergeForm(c.Request.URL.Query())
+		case BindForm:
+			if err := c.Request.ParseForm(); err != nil {
+				return err
+			}
+			mergeForm(c.Request.PostForm)
+		case BindMultipart:
+			if err := c.Request.ParseMultipartForm(multipartMemory); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+				return err
+			}
+			if mf := c.Request.MultipartForm; mf != nil {
+				mergeForm(mf.Value)
+				files = mf.File
+			}
+		case BindJSON:
+			if c.Request.Body == nil {
+				continue
+			}
+			if err := json.NewDecoder(c.Request.Body).Decode(in); err != nil && !errors.Is(err, io.EOF) {
+				return err
+			}
+		default:
+			return errors.New("unknown binder: " + src)
+		}
+		if !nextIsForm {
+			if err := mapFormVals(); err != nil {
+				return err
+			}
+		}
+	}
+	if files != nil {
+		setFiles(reflect.ValueOf(in).Elem(), files)
+	}
+	return nil
+}
+
+// uriLast 把 uri 来源挪到最后，其余保持顺序
+func uriLast(sources []string) []string {
+	out := make([]string, 0, len(sources))
+	uri := false
+	for _, s := range sources {
+		if Binder(s) == BindURI {
+			uri = true
+			continue
+		}
+		out = append(out, s)
+	}
+	if uri {
+		out = append(out, string(BindURI))
+	}
+	return out
+}
+
+func isFormSource(s string) bool {
+	switch Binder(s) {
+	case BindQuery, BindForm, BindMultipart:
+		return true
+	}
+	return false
+}
+
+// tagNames 收集结构体（含嵌套结构体）上某个 tag 的名字
+func tagNames(t reflect.Type, tag string) []string {
+	t = indirect(t)
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var out []string
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get(tag), ",")
+		switch {
+		case name == "-":
+		case name != "":
+			out = append(out, name)
+		case indirect(sf.Type).Kind() == reflect.Struct:
+			out = append(out, tagNames(sf.Type, tag)...)
+		}
+	}
+	return out
+}
+
+var (
+	fileHeaderType  = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeadersType = reflect.TypeOf([]*multipart.FileHeader(nil))
+)
+
+// setFiles 把上传文件填进 form tag 对应的 *multipart.FileHeader / []*multipart.FileHeader 字段
+func setFiles(v reflect.Value, files map[string][]*multipart.FileHeader) {
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(sf.Tag.Get("form"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fv := v.Field(i)
+		switch {
+		case sf.Type == fileHeaderType:
+			if fs := files[name]; len(fs) > 0 {
+				fv.Set(reflect.ValueOf(fs[0]))
+			}
+		case sf.Type == fileHeadersType:
+			if fs := files[name]; len(fs) > 0 {
+				fv.Set(reflect.ValueOf(fs))
+			}
+		case sf.Type.Kind() == reflect.Struct:
+			setFiles(fv, files)
+		case sf.Type.Kind() == reflect.Pointer && sf.Type.Elem().Kind() == reflect.Struct && !fv.IsNil():
+			setFiles(fv.Elem(), files)
+		}
+	}
+}
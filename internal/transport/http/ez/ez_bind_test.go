@@ -0,0 +1,117 @@
+package ez_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	httpez "go-gin-gorm-starter/internal/transport/http/ez"
+)
+
+type bindIn struct {
+	ID    string                  `uri:"id" binding:"required"` // 故意不带 json tag：{"id": ...} 也能填上它
+	Trace string                  `header:"X-Trace-Id" json:"-"`
+	Page  int                     `form:"page,default=1" json:"-"`
+	Name  string                  `form:"name" json:"name" binding:"required"`
+	File  *multipart.FileHeader   `form:"file" json:"-"`
+	More  []*multipart.FileHeader `form:"more" json:"-"`
+}
+
+type bindOut struct {
+	ID, Trace, Name string
+	Page            int
+	File            string
+	More            int
+}
+
+func bindEngine(t *testing.T, binders ...httpez.Binder) *gin.Engine {
+	t.Helper()
+	r, g := newEngine(t)
+	db := newDB(t)
+	for _, b := range binders {
+		httpez.RegisterAction(httpez.New(g), db, httpez.Action[bindIn, bindOut]{
+			Method: http.MethodPost, Path: "/" + string(b) + "/:id", Binder: b,
+			Handler: func(c *gin.Context, _ *gorm.DB, in *bindIn) (bindOut, error) {
+				out := bindOut{ID: in.ID, Trace: in.Trace, Name: in.Name, Page: in.Page, More: len(in.More)}
+				if in.File != nil {
+					out.File = in.File.Filename
+				}
+				return out, nil
+			},
+		})
+	}
+	return r
+}
+
+func TestCompositeBinderPathWins(t *testing.T) {
+	r := bindEngine(t,
+		httpez.Bind(httpez.BindURI, httpez.BindJSON),
+		httpez.Bind(httpez.BindJSON, httpez.BindURI),
+		httpez.Bind(httpez.BindURI, httpez.BindQuery, httpez.BindJSON),
+	)
+	for _, path := range []string{"/api/uri+json/42", "/api/json+uri/42", "/api/uri+query+json/42?page=3"} {
+		w, res := call(t, r, http.MethodPost, path, nil, map[string]any{"id": "999", "ID": "999", "name": "al"})
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: %d %s", path, w.Code, w.Body)
+		}
+		if got := decode[bindOut](t, res); got.ID != "42" || got.Name != "al" {
+			t.Fatalf("%s: bound %+v, want id from path", path, got)
+		}
+	}
+	_, res := call(t, r, http.MethodPost, "/api/uri+query+json/42?page=3", nil, map[string]any{"name": "al"})
+	if got := decode[bindOut](t, res); got.Page != 3 {
+		t.Fatalf("query not merged: %+v", got)
+	}
+	// 合并后统一校验
+	if w, _ := call(t, r, http.MethodPost, "/api/uri+json/42", nil, map[string]any{}); w.Code != http.StatusBadRequest {
+		t.Fatalf("missing required name: %d, want 400", w.Code)
+	}
+}
+
+func TestCompositeBinderMultipart(t *testing.T) {
+	r := bindEngine(t, httpez.Bind(httpez.BindURI, httpez.BindHeader, httpez.BindQuery, httpez.BindMultipart))
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	_ = mw.WriteField("name", "bob")
+	_ = mw.WriteField("id", "999") // 表单同样不能改写路由参数
+	for _, f := range []string{"file:a.txt", "more:b.txt", "more:c.txt"} {
+		field, name, _ := bytes.Cut([]byte(f), []byte(":"))
+		fw, _ := mw.CreateFormFile(string(field), string(name))
+		_, _ = fw.Write([]byte("x"))
+	}
+	_ = mw.Close()
+	body := buf.Bytes()
+
+	do := func(path string, hdr http.Header) bindOut {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		for k, v := range hdr {
+			req.Header[k] = v
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: %d %s", path, w.Code, w.Body)
+		}
+		var e envelope
+		_ = json.Unmarshal(w.Body.Bytes(), &e)
+		return decode[bindOut](t, e)
+	}
+
+	got := do("/api/uri+header+query+multipart/42?page=3", http.Header{"X-Trace-Id": {"tr"}})
+	want := bindOut{ID: "42", Trace: "tr", Name: "bob", Page: 3, File: "a.txt", More: 2}
+	if got != want {
+		t.Fatalf("bound %+v, want %+v", got, want)
+	}
+	// form default= 只生效一次
+	if got := do("/api/uri+header+query+multipart/42", nil); got.Page != 1 {
+		t.Fatalf("default page = %d, want 1", got.Page)
+	}
+}
@@ -0,0 +1,114 @@
+package ez_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	httpez "go-gin-gorm-starter/internal/transport/http/ez"
+	resp "go-gin-gorm-starter/internal/transport/http/response"
+)
+
+// 注册表是全局的：测试用码在包级登记一次（-count=N 也不会重复注册）
+var (
+	codeOutOfStock = resp.Register(resp.CodeDef{Code: 19001, Key: "test_out_of_stock", Status: http.StatusConflict,
+		Messages: map[string]string{"en": "{sku} is out of stock ({left} left)", "zh": "{sku} 库存不足（剩 {left} 件）"}})
+	codeOrderGone = resp.Register(resp.CodeDef{Code: 40419, Key: "test_order_gone",
+		Messages: map[string]string{"en": "order is gone"}})
+	// 故意与 codeOutOfStock 撞码、与 codeOrderGone 撞 key：交给 CheckCodes 报告
+	_ = resp.Register(resp.CodeDef{Code: 19001, Key: "test_dup_code", Messages: map[string]string{"en": "dup"}})
+	_ = resp.Register(resp.CodeDef{Code: 19002, Key: "test_order_gone", Messages: map[string]string{"en": "dup"}})
+)
+
+type failIn struct {
+	Kind string `form:"kind"`
+}
+
+func codesEngine(t *testing.T) *gin.Engine {
+	t.Helper()
+	r, g := newEngine(t)
+	httpez.RegisterAction(httpez.New(g), newDB(t), httpez.Action[failIn, gin.H]{
+		Method: http.MethodGet, Path: "/fail", Binder: httpez.BindQuery,
+		Handler: func(c *gin.Context, _ *gorm.DB, in *failIn) (gin.H, error) {
+			switch in.Kind {
+			case "stock":
+				return nil, httpez.Fail(codeOutOfStock, map[string]any{"sku": "A-1", "left": 0})
+			case "gone":
+				return nil, httpez.Fail(codeOrderGone, nil)
+			case "custom":
+				return nil, &httpez.AErr{Code: codeOutOfStock, Msg: "explicit message wins"}
+			case "builtin":
+				return nil, httpez.Fail(resp.CodeNotFound, nil)
+			}
+			return gin.H{}, nil
+		},
+	})
+	r.GET("/error-codes", resp.ListCodes)
+	return r
+}
+
+func TestCodeRegistry(t *testing.T) {
+	r := codesEngine(t)
+	cases := []struct {
+		kind, lang string
+		status     int
+		code       int
+		msg        string
+	}{
+		{"stock", "", http.StatusConflict, codeOutOfStock, "A-1 is out of stock (0 left)"},
+		{"stock", "zh-CN,zh;q=0.9", http.StatusConflict, codeOutOfStock, "A-1 库存不足（剩 0 件）"},
+		{"stock", "fr, zh;q=0.5", http.StatusConflict, codeOutOfStock, "A-1 库存不足（剩 0 件）"},
+		{"stock", "fr", http.StatusConflict, codeOutOfStock, "A-1 is out of stock (0 left)"},
+		{"gone", "zh", http.StatusNotFound, codeOrderGone, "order is gone"}, // 子码推断状态；缺翻译回退 en
+		{"custom", "zh", http.StatusConflict, codeOutOfStock, "explicit message wins"},
+		{"builtin", "zh", http.StatusNotFound, resp.CodeNotFound, "资源不存在"},
+	}
+	for _, tc := range cases {
+		w, res := call(t, r, http.MethodGet, "/api/fail?kind="+tc.kind, with(nil, "Accept-Language", tc.lang), nil)
+		if w.Code != tc.status || res.Code != tc.code || res.Msg != tc.msg {
+			t.Errorf("%s [%s]: %d code=%d msg=%q, want %d code=%d msg=%q", tc.kind, tc.lang, w.Code, res.Code, res.Msg, tc.status, tc.code, tc.msg)
+		}
+	}
+
+	// /error-codes：按请求语言渲染 message，并带全部翻译
+	_, res := call(t, r, http.MethodGet, "/error-codes", with(nil, "Accept-Language", "zh"), nil)
+	type item struct {
+		Code     int
+		Key      string
+		Status   int
+		Message  string
+		Messages map[string]string
+	}
+	var found *item
+	for _, it := range decode[struct{ Items []item }](t, res).Items {
+		if it.Code == codeOutOfStock {
+			found = &it
+		}
+	}
+	if found == nil || found.Key != "test_out_of_stock" || found.Status != http.StatusConflict ||
+		found.Message != "{sku} 库存不足（剩 {left} 件）" || len(found.Messages) != 2 {
+		t.Fatalf("error-codes entry: %+v", found)
+	}
+}
+
+func TestCheckCodesReportsConflicts(t *testing.T) {
+	err := resp.CheckCodes()
+	if err == nil {
+		t.Fatal("duplicate registrations not reported")
+	}
+	for _, want := range []string{"code 19001 registered twice", `key "test_order_gone" used by codes 40419`, "ez_codes_test.go"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("CheckCodes error %q lacks %q", err, want)
+		}
+	}
+	// 先注册的保留，后来者不覆盖
+	if d, _ := resp.LookupCode(codeOutOfStock); d.Key != "test_out_of_stock" {
+		t.Fatalf("duplicate overwrote code %d: %+v", codeOutOfStock, d)
+	}
+	if _, ok := resp.LookupCode(19002); ok {
+		t.Fatal("code with a duplicate key was registered")
+	}
+}
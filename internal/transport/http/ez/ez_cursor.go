@@ -0,0 +1,323 @@
+package ez
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+/* ================== 游标（keyset）分页 ==================
+   - 游标 = 边界行的排序列取值 + 方向，HMAC 签名防篡改，对客户端不透明
+   - 排序列 = 配置的 OrderBy + ID 兜底（保证全序）
+   - 排序列须为 NOT NULL：可空列（指针 / sql.Null* 且未标 not null）在构造 Keyset 时直接 panic，
+     NULL 的比较与排序位置各库不一致，会漏行或翻页死循环
+   - 签名密钥用独立配置（SetCursorSecret），不与 JWT 等其它密钥共用；启动时 CheckCursorSecret 校验
+*/
+
+// 分页模式
+type PageMode string
+
+const (
+	PageOffset PageMode = "offset" // page/size + COUNT(*)（默认）
+	PageCursor PageMode = "cursor" // cursor/size，可跳过总数
+)
+
+var cursorKey = func() []byte {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return b
+}()
+
+var (
+	cursorMu     sync.Mutex
+	cursorSecret bool     // 是否设置过密钥
+	cursorUsers  []string // 用到游标分页的模型
+)
+
+// SetCursorSecret 设置游标签名密钥（多实例部署需一致）；为空时不生效，仍用进程内随机密钥
+func SetCursorSecret(secret []byte) {
+	if len(secret) == 0 {
+		return
+	}
+	h := sha256.Sum256(append([]byte("ez-cursor:"), secret...))
+	cursorMu.Lock()
+	defer cursorMu.Unlock()
+	cursorKey, cursorSecret = h[:], true
+}
+
+// CheckCursorSecret 启动时调用（引擎注册完之后）：用到了游标分页却没设置密钥时返回错误
+// 随机密钥下重启或多实例之间游标互不认
+func CheckCursorSecret() error {
+	cursorMu.Lock()
+	defer cursorMu.Unlock()
+	if cursorSecret || len(cursorUsers) == 0 {
+		return nil
+	}
+	return fmt.Errorf("cursor pagination is used by %s but no cursor secret is configured", strings.Join(cursorUsers, ", "))
+}
+
+func useCursor(name string) {
+	cursorMu.Lock()
+	defer cursorMu.Unlock()
+	cursorUsers = append(cursorUsers, name)
+}
+
+type keyCol struct {
+	f    *schema.Field
+	desc bool
+}
+
+// Keyset 游标分页器：可在 RegisterAction handler 中复用
+type Keyset struct {
+	cols []keyCol
+}
+
+// NewKeyset 按 orderBy（如 "CreatedAt DESC, Name"）+ idField 兜底构造；配置错误直接 panic
+func NewKeyset(db *gorm.DB, model any, orderBy, idField string) *Keyset {
+	sch, err := parseSchema(db, model)
+	if err != nil {
+		panic("ez: parse model schema: " + err.Error())
+	}
+	useCursor(sch.Name)
+	return newKeyset(sch, parseOrderBy(sch, orderBy), idField)
+}
+
+// 解析 "CreatedAt DESC, Name" 形式的排序配置
+func parseOrderBy(sch *schema.Schema, orderBy string) []keyCol {
+	var cols []keyCol
+	for _, part := range strings.Split(orderBy, ",") {
+		fs := strings.Fields(part)
+		if len(fs) == 0 {
+			continue
+		}
+		f := lookupField(sch, fs[0])
+		if f == nil {
+			panic(fmt.Sprintf("ez: order field %q not found on %s", fs[0], sch.Name))
+		}
+		cols = append(cols, keyCol{f: f, desc: len(fs) > 1 && strings.EqualFold(fs[1], "DESC")})
+	}
+	return cols
+}
+
+// 末尾补上主键列（复合主键逐列）作为唯一的决胜排序
+func newKeyset(sch *schema.Schema, cols []keyCol, idFields ...string) *Keyset {
+	var ids []*schema.Field
+	for _, name := range idFields {
+		if f := lookupField(sch, name); f != nil {
+			ids = append(ids, f)
+		}
+	}
+	if len(ids) == 0 && sch.PrioritizedPrimaryField != nil {
+		ids = append(ids, sch.PrioritizedPrimaryField)
+	}
+	if len(ids) == 0 {
+		panic("ez: keyset needs an id field on " + sch.Name)
+	}
+	for _, c := range cols {
+		checkKeyColumn(sch, c.f)
+	}
+	desc := true
+	if len(cols) > 0 {
+		desc = cols[len(cols)-1].desc
+	}
+	cols = append([]keyCol(nil), cols...)
+next:
+	for _, id := range ids {
+		for _, c := range cols {
+			if c.f == id {
+				continue next
+			}
+		}
+		cols = append(cols, keyCol{f: id, desc: desc})
+	}
+	return &Keyset{cols: cols}
+}
+
+func checkKeyColumn(sch *schema.Schema, f *schema.Field) {
+	if nullableField(f) {
+		panic(fmt.Sprintf("ez: keyset column %s.%s is nullable; mark it not null or leave it out of cursor ordering", sch.Name, f.Name))
+	}
+}
+
+// 可空列：指针或 sql.Null* 一类的 Valuer 结构体，且没有 not null 约束
+func nullableField(f *schema.Field) bool {
+	if f.NotNull || f.PrimaryKey {
+		return false
+	}
+	t := f.FieldType
+	if t.Kind() == reflect.Ptr {
+		return true
+	}
+	_, valuer := reflect.New(t).Interface().(driver.Valuer)
+	return valuer && t.Kind() == reflect.Struct
+}
+
+// 排序签名：游标只能用于生成它的排序
+func (k *Keyset) sig() string {
+	parts := make([]string, len(k.cols))
+	for i, c := range k.cols {
+		parts[i] = c.f.DBName
+		if c.desc {
+			parts[i] += "-"
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+type cursorPayload struct {
+	K    string   `json:"k"`
+	V    []string `json:"v"`
+	Prev bool     `json:"p,omitempty"`
+}
+
+func (k *Keyset) encode(row reflect.Value, prev bool) string {
+	p := cursorPayload{K: k.sig(), Prev: prev}
+	for _, c := range k.cols {
+		v, _ := c.f.ValueOf(context.Background(), row)
+		p.V = append(p.V, formatKeyValue(v))
+	}
+	b, _ := json.Marshal(p)
+	return base64.RawURLEncoding.EncodeToString(b) + "." + base64.RawURLEncoding.EncodeToString(signCursor(b))
+}
+
+func (k *Keyset) decode(s string) (vals []any, prev bool, err error) {
+	bad := BadRequest("invalid cursor")
+	body, mac, ok := strings.Cut(s, ".")
+	if !ok {
+		return nil, false, bad
+	}
+	b, err1 := base64.RawURLEncoding.DecodeString(body)
+	m, err2 := base64.RawURLEncoding.DecodeString(mac)
+	if err1 != nil || err2 != nil || !hmac.Equal(m, signCursor(b)) {
+		return nil, false, bad
+	}
+	var p cursorPayload
+	if json.Unmarshal(b, &p) != nil || p.K != k.sig() || len(p.V) != len(k.cols) {
+		return nil, false, bad
+	}
+	for i, c := range k.cols {
+		v, err := convertQueryValue(c.f.FieldType, p.V[i])
+		if err != nil {
+			return nil, false, bad
+		}
+		vals = append(vals, v)
+	}
+	return vals, p.Prev, nil
+}
+
+func signCursor(b []byte) []byte {
+	h := hmac.New(sha256.New, cursorKey)
+	h.Write(b)
+	return h.Sum(nil)[:16]
+}
+
+// 指针先解引用（NOT NULL 的指针列读出来不会是 nil），否则 fmt 打印的是地址
+func formatKeyValue(v any) string {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return ""
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return ""
+	}
+	if t, ok := rv.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(rv.Interface())
+}
+
+// 越过边界行的条件：(c1 > v1) OR (c1 = v1 AND c2 > v2) ...（降序列取 <，向前翻页整体取反）
+func (k *Keyset) after(vals []any, prev bool) clause.Expression {
+	var ors []clause.Expression
+	for i, c := range k.cols {
+		var ands []clause.Expression
+		for j := 0; j < i; j++ {
+			ands = append(ands, clause.Eq{Column: column(k.cols[j].f), Value: vals[j]})
+		}
+		if c.desc != prev {
+			ands = append(ands, clause.Lt{Column: column(c.f), Value: vals[i]})
+		} else {
+			ands = append(ands, clause.Gt{Column: column(c.f), Value: vals[i]})
+		}
+		ors = append(ors, clause.And(ands...))
+	}
+	return clause.Or(ors...)
+}
+
+func (k *Keyset) order(reverse bool) clause.OrderBy {
+	cols := make([]clause.OrderByColumn, len(k.cols))
+	for i, c := range k.cols {
+		cols[i] = clause.OrderByColumn{Column: column(c.f), Desc: c.desc != reverse}
+	}
+	return clause.OrderBy{Columns: cols}
+}
+
+// 游标分页结果
+type CursorPage[T any] struct {
+	List       []T    `json:"list"`
+	Size       int    `json:"size"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	PrevCursor string `json:"prevCursor,omitempty"`
+}
+
+// CursorPaginate 在 q（已带过滤条件，勿带 Order/Limit）上取一页
+// 非法游标返回 400 的 *AErr，DB 错误返回 500 的 *AErr
+func CursorPaginate[T any](q *gorm.DB, ks *Keyset, cursor string, size int) (CursorPage[T], error) {
+	page := CursorPage[T]{Size: size}
+	prev := false
+	if cursor != "" {
+		vals, p, err := ks.decode(cursor)
+		if err != nil {
+			return page, err
+		}
+		prev = p
+		q = q.Where(ks.after(vals, prev))
+	}
+
+	var items []T
+	if err := q.Order(ks.order(prev)).Limit(size + 1).Find(&items).Error; err != nil {
+		return page, Internal("list failed", err)
+	}
+	more := len(items) > size
+	if more {
+		items = items[:size]
+	}
+	if prev {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+	page.List = items
+	if len(items) == 0 {
+		page.List = []T{}
+		return page, nil
+	}
+
+	first := reflect.ValueOf(&items[0]).Elem()
+	last := reflect.ValueOf(&items[len(items)-1]).Elem()
+	// 向后翻：有更多才给 next；带了游标说明前面还有
+	// 向前翻：有更多才给 prev；后面一定还有
+	if (!prev && more) || prev {
+		page.NextCursor = ks.encode(last, false)
+	}
+	if (prev && more) || (!prev && cursor != "") {
+		page.PrevCursor = ks.encode(first, true)
+	}
+	return page, nil
+}
@@ -0,0 +1,168 @@
+package ez_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	httpez "go-gin-gorm-starter/internal/transport/http/ez"
+)
+
+type cursorItem struct {
+	ID      int64  `gorm:"primaryKey" json:"id"`
+	OwnerID string `gorm:"size:36;index" json:"ownerId"`
+	Rank    int    `gorm:"not null" json:"rank"`
+}
+
+type cursorPage struct {
+	List       []cursorItem
+	Total      int
+	NextCursor string
+	PrevCursor string
+}
+
+func TestCursorPagination(t *testing.T) {
+	db := newDB(t)
+	r, g := newEngine(t)
+	httpez.Crud(httpez.CrudConfig[cursorItem]{
+		DB: db, Group: g, Path: "/cursor-items", New: func() *cursorItem { return &cursorItem{} },
+		AllowList: true, Pagination: httpez.PageCursor, OrderBy: "Rank DESC", Sortable: []string{"id", "rank"},
+	})
+	// 用到游标分页却没配密钥：启动校验应报错
+	if err := httpez.CheckCursorSecret(); err == nil {
+		t.Fatal("CheckCursorSecret: want error without secret")
+	}
+	httpez.SetCursorSecret(nil)
+	if err := httpez.CheckCursorSecret(); err == nil {
+		t.Fatal("CheckCursorSecret: empty secret accepted")
+	}
+	httpez.SetCursorSecret([]byte("test-cursor-secret"))
+	if err := httpez.CheckCursorSecret(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i <= 5; i++ {
+		db.Create(&cursorItem{OwnerID: "alice", Rank: i * 10})
+	}
+	alice := as("alice")
+	page := func(cursor string) cursorPage {
+		t.Helper()
+		w, res := call(t, r, http.MethodGet, "/api/cursor-items?size=2&cursor="+url.QueryEscape(cursor), alice, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("list: %d %s", w.Code, w.Body)
+		}
+		return decode[cursorPage](t, res)
+	}
+	ranks := func(p cursorPage) (out []int) {
+		for _, it := range p.List {
+			out = append(out, it.Rank)
+		}
+		return out
+	}
+
+	p1 := page("")
+	if got := ranks(p1); len(got) != 2 || got[0] != 50 || got[1] != 40 || p1.Total != 5 {
+		t.Fatalf("page 1: %v total %d", got, p1.Total)
+	}
+	p2 := page(p1.NextCursor)
+	if got := ranks(p2); len(got) != 2 || got[0] != 30 || got[1] != 20 {
+		t.Fatalf("page 2: %v", got)
+	}
+	p3 := page(p2.NextCursor)
+	if got := ranks(p3); len(got) != 1 || got[0] != 10 || p3.NextCursor != "" {
+		t.Fatalf("page 3: %v next %q", got, p3.NextCursor)
+	}
+	if got := ranks(page(p2.PrevCursor)); len(got) != 2 || got[0] != 50 || got[1] != 40 {
+		t.Fatalf("prev of page 2: %v", got)
+	}
+
+	if w, _ := call(t, r, http.MethodGet, "/api/cursor-items?sort=id", alice, nil); w.Code != http.StatusOK {
+		t.Fatalf("sort=id: %d %s", w.Code, w.Body)
+	}
+
+	// 篡改游标：改值后签名不符、乱码、换排序复用，一律 400
+	body, mac, _ := strings.Cut(p1.NextCursor, ".")
+	raw, _ := base64.RawURLEncoding.DecodeString(body)
+	var payload map[string]any
+	_ = json.Unmarshal(raw, &payload)
+	payload["v"] = []string{"1000", "1"}
+	forged, _ := json.Marshal(payload)
+	for name, path := range map[string]string{
+		"forged": "/api/cursor-items?cursor=" + base64.RawURLEncoding.EncodeToString(forged) + "." + mac,
+		"junk":   "/api/cursor-items?cursor=not-a-cursor",
+		"resort": "/api/cursor-items?sort=id&cursor=" + url.QueryEscape(p1.NextCursor),
+	} {
+		if w, _ := call(t, r, http.MethodGet, path, alice, nil); w.Code != http.StatusBadRequest {
+			t.Fatalf("%s cursor: %d, want 400 (%s)", name, w.Code, w.Body)
+		}
+	}
+}
+
+type pointerKeyItem struct {
+	ID      int64   `gorm:"primaryKey" json:"id"`
+	OwnerID string  `gorm:"size:36;index" json:"ownerId"`
+	Code    *string `gorm:"not null" json:"code"`
+}
+
+type nullableKeyItem struct {
+	ID      int64   `gorm:"primaryKey" json:"id"`
+	OwnerID string  `gorm:"size:36;index" json:"ownerId"`
+	Code    *string `json:"code"`
+}
+
+func TestCursorPointerKey(t *testing.T) {
+	db := newDB(t)
+	r, g := newEngine(t)
+	httpez.Crud(httpez.CrudConfig[pointerKeyItem]{
+		DB: db, Group: g, Path: "/codes", New: func() *pointerKeyItem { return &pointerKeyItem{} },
+		AllowList: true, Pagination: httpez.PageCursor, OrderBy: "Code", Sortable: []string{"code"},
+	})
+	for _, code := range []string{"a", "b", "c", "d", "e"} {
+		db.Create(&pointerKeyItem{OwnerID: "alice", Code: &code})
+	}
+	// 指针排序列按值编码进游标：逐页前进，不会原地打转
+	for _, sort := range []string{"", "&sort=-code"} {
+		var seen []string
+		cursor := ""
+		for i := 0; i < 5; i++ {
+			_, res := call(t, r, http.MethodGet, "/api/codes?size=2"+sort+"&cursor="+url.QueryEscape(cursor), as("alice"), nil)
+			p := decode[struct {
+				List       []pointerKeyItem
+				NextCursor string
+			}](t, res)
+			for _, it := range p.List {
+				seen = append(seen, *it.Code)
+			}
+			if cursor = p.NextCursor; cursor == "" {
+				break
+			}
+		}
+		want := "a,b,c,d,e"
+		if sort != "" {
+			want = "e,d,c,b,a"
+		}
+		if got := strings.Join(seen, ","); got != want {
+			t.Fatalf("sort %q: pages %s, want %s", sort, got, want)
+		}
+	}
+
+	// 可空列不能做游标排序列：注册时即 panic
+	for name, cfg := range map[string]httpez.CrudConfig[nullableKeyItem]{
+		"order by": {OrderBy: "Code"},
+		"sortable": {Sortable: []string{"code"}},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s nullable column: no panic", name)
+				}
+			}()
+			cfg.DB, cfg.Group, cfg.Path = db, g, "/nullable-"+strings.ReplaceAll(name, " ", "-")
+			cfg.New, cfg.AllowList, cfg.Pagination = func() *nullableKeyItem { return &nullableKeyItem{} }, true, httpez.PageCursor
+			httpez.Crud(cfg)
+		}()
+	}
+}
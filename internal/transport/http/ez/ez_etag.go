@@ -0,0 +1,136 @@
+package ez
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	resp "go-gin-gorm-starter/internal/transport/http/response"
+)
+
+/* ================== 乐观并发：ETag / If-Match ==================
+   - 版本字段：整数（每次更新 +1）或时间（UpdatedAt，由 gorm 自动刷新）
+   - Get 输出 ETag，If-None-Match 命中返回 304
+   - Update/Delete 的版本校验放在 UPDATE/DELETE 的 WHERE 里，影响 0 行即冲突
+*/
+
+type versionSpec struct {
+	f *schema.Field
+}
+
+func newVersionSpec(sch *schema.Schema, name string) *versionSpec {
+	if name == "" {
+		return nil
+	}
+	f := lookupField(sch, name)
+	if f == nil {
+		panic(fmt.Sprintf("ez: version field %q not found on %s", name, sch.Name))
+	}
+	switch k := indirect(f.FieldType); {
+	case k == reflect.TypeOf(time.Time{}):
+	case k.Kind() >= reflect.Int && k.Kind() <= reflect.Uint64:
+	default:
+		panic(fmt.Sprintf("ez: version field %q must be an integer or time.Time", name))
+	}
+	return &versionSpec{f: f}
+}
+
+func (v *versionSpec) value(m any) any {
+	val, _ := v.f.ValueOf(context.Background(), reflect.ValueOf(m).Elem())
+	return val
+}
+
+// 强 ETag：整数原样，时间取 UnixNano
+func (v *versionSpec) etag(m any) string {
+	switch t := v.value(m).(type) {
+	case time.Time:
+		return strconv.Quote(strconv.FormatInt(t.UnixNano(), 10))
+	case *time.Time:
+		if t == nil {
+			return `"0"`
+		}
+		return strconv.Quote(strconv.FormatInt(t.UnixNano(), 10))
+	default:
+		return strconv.Quote(fmt.Sprint(t))
+	}
+}
+
+// 请求体里携带的版本号 → ETag；零值视为未携带
+func (v *versionSpec) itemIfMatch(in any) string {
+	if _, zero := v.f.ValueOf(context.Background(), reflect.ValueOf(in).Elem()); zero {
+		return ""
+	}
+	return v.etag(in)
+}
+
+// 写入后的 ETag：时间版本由数据库截断精度，需回读
+func (v *versionSpec) etagAfter(written any, reload func() (any, error)) string {
+	if indirect(v.f.FieldType) != reflect.TypeOf(time.Time{}) {
+		return v.etag(written)
+	}
+	m, err := reload()
+	if err != nil {
+		return ""
+	}
+	return v.etag(m)
+}
+
+// 版本条件：列 = 读到的当前值
+func (v *versionSpec) where(cur any) clause.Expression {
+	return clause.Eq{Column: column(v.f), Value: v.value(cur)}
+}
+
+// 整数版本在写入对象上设为 当前值+1；时间版本交给 autoUpdateTime
+func (v *versionSpec) bump(in, cur any) {
+	fv := v.f.ReflectValueOf(context.Background(), reflect.ValueOf(in).Elem())
+	cv := v.f.ReflectValueOf(context.Background(), reflect.ValueOf(cur).Elem())
+	switch indirect(fv.Type()).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		fv.SetInt(cv.Int() + 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		fv.SetUint(cv.Uint() + 1)
+	default:
+		if v.f.AutoUpdateTime == 0 && fv.Type() == reflect.TypeOf(time.Time{}) {
+			fv.Set(reflect.ValueOf(time.Now()))
+		}
+	}
+}
+
+// 匹配 If-Match / If-None-Match 列表（支持 *，忽略弱标记 W/）
+func etagMatch(header, tag string) bool {
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimPrefix(strings.TrimSpace(part), "W/")
+		if part == "*" || part == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch 校验前置条件（当前记录已读出）
+func (v *versionSpec) checkIfMatch(ifMatch string, cur any, required bool) error {
+	if ifMatch == "" {
+		if required {
+			return &AErr{Code: resp.CodePreconditionRequired, Msg: "If-Match header required"}
+		}
+		return nil
+	}
+	if !etagMatch(ifMatch, v.etag(cur)) {
+		return &AErr{Code: resp.CodePreconditionFailed, Msg: "resource has been modified"}
+	}
+	return nil
+}
+
+// 写入时 WHERE 未命中（期间被别人改过）
+func (v *versionSpec) conflict(ifMatch string) error {
+	if ifMatch != "" {
+		return &AErr{Code: resp.CodePreconditionFailed, Msg: "resource has been modified"}
+	}
+	return &AErr{Code: resp.CodeConflict, Msg: "concurrent modification, please retry"}
+}
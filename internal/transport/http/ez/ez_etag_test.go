@@ -0,0 +1,143 @@
+package ez_test
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	httpez "go-gin-gorm-starter/internal/transport/http/ez"
+)
+
+type versioned struct {
+	ID      int64  `gorm:"primaryKey" json:"id"`
+	OwnerID string `gorm:"size:36;index" json:"ownerId"`
+	Name    string `json:"name"`
+	Version int64  `json:"version"`
+}
+
+type stamped struct {
+	ID        int64     `gorm:"primaryKey" json:"id"`
+	OwnerID   string    `gorm:"size:36;index" json:"ownerId"`
+	Name      string    `json:"name"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func TestETagIfMatch(t *testing.T) {
+	db := newDB(t)
+	r, g := newEngine(t)
+	// 模拟“读完之后、写入之前被别人改了”：钩子里直接把版本号推进
+	var race bool
+	httpez.Crud(httpez.CrudConfig[versioned]{
+		DB: db, Group: g, Path: "/docs", New: func() *versioned { return &versioned{} },
+		VersionField: "Version",
+		Hooks: httpez.CrudHooks[versioned]{
+			BeforeUpdate: func(c *gin.Context, tx *gorm.DB, old, in *versioned) error {
+				if race {
+					return tx.Model(&versioned{}).Where("id = ?", old.ID).Update("version", gorm.Expr("version + 1")).Error
+				}
+				return nil
+			},
+		},
+	})
+	alice := as("alice")
+	_, res := call(t, r, http.MethodPost, "/api/docs", alice, map[string]any{"name": "a"})
+	path := "/api/docs/" + strconv.FormatInt(decode[versioned](t, res).ID, 10)
+
+	w, _ := call(t, r, http.MethodGet, path, alice, nil)
+	tag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || tag != `"0"` {
+		t.Fatalf("get: %d etag %q", w.Code, tag)
+	}
+	if w, _ := call(t, r, http.MethodGet, path, with(alice, "If-None-Match", `W/"9", `+tag), nil); w.Code != http.StatusNotModified {
+		t.Fatalf("If-None-Match hit: %d, want 304", w.Code)
+	}
+
+	// PUT：版本匹配则写入并 +1，过期则 412
+	w, _ = call(t, r, http.MethodPut, path, with(alice, "If-Match", tag), map[string]any{"name": "b"})
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"1"` {
+		t.Fatalf("put: %d etag %q %s", w.Code, w.Header().Get("ETag"), w.Body)
+	}
+	if w, _ := call(t, r, http.MethodPut, path, with(alice, "If-Match", tag), map[string]any{"name": "stale"}); w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("stale put: %d, want 412", w.Code)
+	}
+
+	// PATCH：版本字段只读，过期 412
+	merge := with(alice, "Content-Type", "application/merge-patch+json")
+	if w, _ := call(t, r, http.MethodPatch, path, with(merge, "If-Match", tag), `{"name":"stale"}`); w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("stale patch: %d, want 412", w.Code)
+	}
+	if w, _ := call(t, r, http.MethodPatch, path, merge, `{"version":7}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("patch version: %d, want 400", w.Code)
+	}
+	w, _ = call(t, r, http.MethodPatch, path, with(merge, "If-Match", `"1"`), `{"name":"c"}`)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"2"` {
+		t.Fatalf("patch: %d etag %q %s", w.Code, w.Header().Get("ETag"), w.Body)
+	}
+
+	// 校验通过后被并发修改：带 If-Match 412，不带则 409
+	race = true
+	if w, _ := call(t, r, http.MethodPut, path, with(alice, "If-Match", `"2"`), map[string]any{"name": "lost"}); w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("racing put with If-Match: %d, want 412", w.Code)
+	}
+	if w, _ := call(t, r, http.MethodPut, path, alice, map[string]any{"name": "lost"}); w.Code != http.StatusConflict {
+		t.Fatalf("racing put: %d, want 409", w.Code)
+	}
+	race = false
+	var cur versioned
+	if err := db.First(&cur).Error; err != nil {
+		t.Fatal(err)
+	}
+	if cur.Name != "c" || cur.Version != 2 {
+		t.Fatalf("racing update was written: %+v", cur)
+	}
+
+	// DELETE：过期 412，匹配则删除
+	if w, _ := call(t, r, http.MethodDelete, path, with(alice, "If-Match", `"1"`), nil); w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("stale delete: %d, want 412", w.Code)
+	}
+	if w, _ := call(t, r, http.MethodDelete, path, with(alice, "If-Match", strconv.Quote(strconv.FormatInt(cur.Version, 10))), nil); w.Code != http.StatusOK {
+		t.Fatalf("delete: %d %s", w.Code, w.Body)
+	}
+}
+
+func TestETagRequireIfMatch(t *testing.T) {
+	db := newDB(t)
+	r, g := newEngine(t)
+	httpez.Crud(httpez.CrudConfig[stamped]{
+		DB: db, Group: g, Path: "/docs", New: func() *stamped { return &stamped{} },
+		VersionField: "UpdatedAt", RequireIfMatch: true,
+	})
+	alice := as("alice")
+	_, res := call(t, r, http.MethodPost, "/api/docs", alice, map[string]any{"name": "a"})
+	path := "/api/docs/" + strconv.FormatInt(decode[stamped](t, res).ID, 10)
+
+	if w, _ := call(t, r, http.MethodPut, path, alice, map[string]any{"name": "b"}); w.Code != http.StatusPreconditionRequired {
+		t.Fatalf("put without If-Match: %d, want 428", w.Code)
+	}
+	if w, _ := call(t, r, http.MethodDelete, path, alice, nil); w.Code != http.StatusPreconditionRequired {
+		t.Fatalf("delete without If-Match: %d, want 428", w.Code)
+	}
+
+	w, _ := call(t, r, http.MethodGet, path, alice, nil)
+	tag := w.Header().Get("ETag")
+	time.Sleep(2 * time.Millisecond) // 时间版本：保证下次写入的时间戳不同
+	w, _ = call(t, r, http.MethodPut, path, with(alice, "If-Match", tag), map[string]any{"name": "b"})
+	next := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || next == "" || next == tag {
+		t.Fatalf("put: %d etag %q (was %q) %s", w.Code, next, tag, w.Body)
+	}
+	// 写入后返回的 ETag 与重新读取的一致（已按数据库精度回读）
+	if w, _ := call(t, r, http.MethodGet, path, alice, nil); w.Header().Get("ETag") != next {
+		t.Fatalf("etag after put %q, get returns %q", next, w.Header().Get("ETag"))
+	}
+	if w, _ := call(t, r, http.MethodPut, path, with(alice, "If-Match", tag), map[string]any{"name": "stale"}); w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("stale put: %d, want 412", w.Code)
+	}
+	if w, _ := call(t, r, http.MethodDelete, path, with(alice, "If-Match", "*"), nil); w.Code != http.StatusOK {
+		t.Fatalf("delete If-Match *: %d %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,394 @@
+package ez
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+/* ================== 导出：CSV / NDJSON / XLSX 流式输出 ==================
+   GET /path/export?format=csv|ndjson|xlsx&<同列表的过滤/排序/fields 参数>
+   逐行游标读取（Rows + ScanRows），边读边写、按批 Flush，内存不随行数增长
+   RegisterAction 里可直接调用 Export（handler 写出响应后 RegisterAction 不再输出 JSON）：
+     return struct{}{}, ez.Export(c, q, in.Format, ez.ExportOptions[User]{Filename: "users", Row: toRow})
+   响应头写出之后出错只能中断输出，错误记到 c.Errors（访问日志可见）
+   大导出会超过 http.Server.WriteTimeout 和 Timeout 中间件的请求时限：开始输出前撤掉连接写超时、
+   查询改用脱离请求时限的 ctx，整体时长由 ExportOptions.WriteTimeout 控制：0 取默认 30 分钟，负数不限；
+   Crud 生成的导出用 CrudConfig.ExportTimeout 覆盖（同样 0 默认、负数不限）；客户端断开时写失败即停止
+*/
+
+type ExportFormat string
+
+const (
+	ExportCSV    ExportFormat = "csv"
+	ExportNDJSON ExportFormat = "ndjson"
+	ExportXLSX   ExportFormat = "xlsx"
+)
+
+// 每写出多少行 Flush 一次
+const exportFlushRows = 500
+
+// 未配置 WriteTimeout 时整个导出的时限：撤掉连接写超时后总得有个兜底，卡住的导出不会永远占着连接和 DB 游标
+const defaultExportTimeout = 30 * time.Minute
+
+type ExportOptions[T any] struct {
+	Filename string         // 下载文件名（不含扩展名），默认 "export"
+	Columns  []string       // 输出列（json 名），默认按行结构体的 json 字段顺序；Row 返回 map 时必填
+	Row      func(m *T) any // 行映射（如隐藏敏感字段），默认整条记录
+
+	WriteTimeout time.Duration // 整个导出的时限，0 取默认 30 分钟，负数不限
+}
+
+// ParseExportFormat 解析 ?format=，为空时默认 CSV
+func ParseExportFormat(s string) (ExportFormat, error) {
+	switch f := ExportFormat(strings.ToLower(strings.TrimSpace(s))); f {
+	case "":
+		return ExportCSV, nil
+	case ExportCSV, ExportNDJSON, ExportXLSX:
+		return f, nil
+	}
+	return "", BadRequest(fmt.Sprintf("unsupported export format %q", s))
+}
+
+// Export 把 q 的结果集按 format 流式写给客户端；q 需已带好过滤/归属/排序
+// 返回错误时若 c.Writer.Written() 为 false，调用方仍可正常输出错误响应
+func Export[T any](c *gin.Context, q *gorm.DB, format string, opt ExportOptions[T]) error {
+	f, err := ParseExportFormat(format)
+	if err != nil {
+		return err
+	}
+	row := opt.Row
+	if row == nil {
+		row = func(m *T) any { return m }
+	}
+	cols := opt.Columns
+	if len(cols) == 0 {
+		cols = structColumns(reflect.TypeOf(row(new(T))))
+	}
+	if len(cols) == 0 {
+		return Internal("export columns required", nil)
+	}
+
+	// 撤掉（或改设）连接写超时；测试里的 ResponseRecorder 等不支持的 writer 本来就没有写超时
+	timeout := opt.WriteTimeout
+	if timeout == 0 {
+		timeout = defaultExportTimeout
+	}
+	deadline, ctx := time.Time{}, context.WithoutCancel(c.Request.Context())
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return Internal("export set write deadline failed", err)
+	}
+
+	q = q.WithContext(ctx)
+	rows, err := q.Rows()
+	if err != nil {
+		return Internal("export query failed", err)
+	}
+	defer rows.Close()
+
+	name := opt.Filename
+	if name == "" {
+		name = "export"
+	}
+	w := newExportWriter(f, c.Writer)
+	c.Header("Content-Type", w.contentType())
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, f))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Status(http.StatusOK)
+
+	if err := w.header(cols); err != nil {
+		return err
+	}
+	n := 0
+	for rows.Next() {
+		var m T
+		if err := q.ScanRows(rows, &m); err != nil {
+			return exportAbort(c, err)
+		}
+		doc, err := rowDoc(row(&m))
+		if err != nil {
+			return exportAbort(c, err)
+		}
+		vals := make([]any, len(cols))
+		for i, col := range cols {
+			vals[i] = doc[col]
+		}
+		if err := w.row(vals); err != nil {
+			return exportAbort(c, err)
+		}
+		if n++; n%exportFlushRows == 0 {
+			if err := w.flush(); err != nil {
+				return exportAbort(c, err)
+			}
+			c.Writer.Flush()
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return exportAbort(c, err)
+	}
+	if err := w.close(); err != nil {
+		return exportAbort(c, err)
+	}
+	c.Writer.Flush()
+	return nil
+}
+
+// 已开始输出后出错：记到 c.Errors，响应就此截断
+func exportAbort(c *gin.Context, err error) error {
+	_ = c.Error(err)
+	return Internal("export interrupted", err)
+}
+
+// structColumns 按 encoding/json 的规则列出结构体字段的 json 名（含嵌入结构体提升的字段）
+func structColumns(t reflect.Type) []string {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	var out []string
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				out = append(out, structColumns(ft)...)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		out = append(out, jsonTagName(sf))
+	}
+	return out
+}
+
+// rowDoc 与 toDoc 相同，但数字保持 json.Number（大整数 ID 不丢精度）
+func rowDoc(v any) (map[string]any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// 单元格文本：嵌套对象/数组写成 JSON
+func cellText(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case json.Number:
+		return x.String()
+	case bool:
+		return strconv.FormatBool(x)
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+/* ---------- 各格式写出 ---------- */
+
+type exportWriter interface {
+	contentType() string
+	header(cols []string) error
+	row(vals []any) error
+	flush() error
+	close() error
+}
+
+func newExportWriter(f ExportFormat, w io.Writer) exportWriter {
+	switch f {
+	case ExportNDJSON:
+		return &ndjsonWriter{enc: json.NewEncoder(w)}
+	case ExportXLSX:
+		return &xlsxWriter{zw: zip.NewWriter(w)}
+	}
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+type csvWriter struct {
+	w   *csv.Writer
+	buf []string
+}
+
+func (w *csvWriter) contentType() string { return "text/csv; charset=utf-8" }
+
+func (w *csvWriter) header(cols []string) error {
+	w.buf = make([]string, len(cols))
+	return w.w.Write(cols)
+}
+
+func (w *csvWriter) row(vals []any) error {
+	for i, v := range vals {
+		s := cellText(v)
+		// 防 CSV 公式注入：以 = + - @ 开头的文本在表格软件里会被当作公式
+		if _, ok := v.(string); ok && s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+			s = "'" + s
+		}
+		w.buf[i] = s
+	}
+	return w.w.Write(w.buf)
+}
+
+func (w *csvWriter) flush() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+func (w *csvWriter) close() error { return w.flush() }
+
+type ndjsonWriter struct {
+	enc  *json.Encoder
+	cols []string
+}
+
+func (w *ndjsonWriter) contentType() string { return "application/x-ndjson" }
+
+func (w *ndjsonWriter) header(cols []string) error {
+	w.cols = cols
+	return nil
+}
+
+func (w *ndjsonWriter) row(vals []any) error {
+	doc := make(map[string]any, len(vals))
+	for i, v := range vals {
+		doc[w.cols[i]] = v
+	}
+	return w.enc.Encode(doc)
+}
+
+func (w *ndjsonWriter) flush() error { return nil }
+func (w *ndjsonWriter) close() error { return nil }
+
+// xlsxWriter 最小可用的 XLSX：单 sheet、inlineStr 单元格，sheet XML 直接流式写进 zip
+type xlsxWriter struct {
+	zw    *zip.Writer
+	sheet io.Writer
+}
+
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`
+	xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`
+	xlsxSheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetEnd = `</sheetData></worksheet>`
+)
+
+func (w *xlsxWriter) contentType() string {
+	return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+}
+
+func (w *xlsxWriter) header(cols []string) error {
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+	for _, p := range parts {
+		f, err := w.zw.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, p.body); err != nil {
+			return err
+		}
+	}
+	sheet, err := w.zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	w.sheet = sheet
+	if _, err := io.WriteString(sheet, xlsxSheetStart); err != nil {
+		return err
+	}
+	vals := make([]any, len(cols))
+	for i, col := range cols {
+		vals[i] = col
+	}
+	return w.row(vals)
+}
+
+func (w *xlsxWriter) row(vals []any) error {
+	var b bytes.Buffer
+	b.WriteString("<row>")
+	for _, v := range vals {
+		switch x := v.(type) {
+		case nil:
+			b.WriteString("<c/>")
+		case json.Number:
+			// 超过 15 位有效数字 Excel 会丢精度（如雪花 ID），按文本写
+			if len(strings.TrimLeft(x.String(), "-")) <= 15 {
+				b.WriteString(`<c t="n"><v>` + x.String() + `</v></c>`)
+				break
+			}
+			b.WriteString(`<c t="inlineStr"><is><t>` + x.String() + `</t></is></c>`)
+		case bool:
+			n := "0"
+			if x {
+				n = "1"
+			}
+			b.WriteString(`<c t="b"><v>` + n + `</v></c>`)
+		default:
+			b.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+			_ = xml.EscapeText(&b, []byte(cellText(v)))
+			b.WriteString(`</t></is></c>`)
+		}
+	}
+	b.WriteString("</row>")
+	_, err := w.sheet.Write(b.Bytes())
+	return err
+}
+
+func (w *xlsxWriter) flush() error { return w.zw.Flush() }
+
+func (w *xlsxWriter) close() error {
+	if _, err := io.WriteString(w.sheet, xlsxSheetEnd); err != nil {
+		return err
+	}
+	return w.zw.Close()
+}
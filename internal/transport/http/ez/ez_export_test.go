@@ -0,0 +1,123 @@
+package ez_test
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	httpez "go-gin-gorm-starter/internal/transport/http/ez"
+	mdw "go-gin-gorm-starter/internal/transport/http/middleware"
+)
+
+type exportItem struct {
+	ID      int64  `gorm:"primaryKey" json:"id"`
+	OwnerID string `gorm:"size:36;index" json:"ownerId"`
+	Name    string `json:"name"`
+	Secret  string `json:"-"`
+}
+
+func exportEngine(t *testing.T, hooks httpez.CrudHooks[exportItem], use ...gin.HandlerFunc) *gin.Engine {
+	t.Helper()
+	db := newDB(t)
+	r, g := newEngine(t)
+	g.Use(use...)
+	httpez.Crud(httpez.CrudConfig[exportItem]{
+		DB: db, Group: g, Path: "/exp-items", New: func() *exportItem { return &exportItem{} },
+		AllowList: true, AllowExport: true, Filterable: []string{"name"}, Sortable: []string{"id"}, Hooks: hooks,
+	})
+	db.Create(&[]exportItem{
+		{OwnerID: "alice", Name: "=SUM(A1)", Secret: "s"},
+		{OwnerID: "alice", Name: "b"},
+		{OwnerID: "bob", Name: "c"},
+	})
+	return r
+}
+
+func TestExportFormats(t *testing.T) {
+	r := exportEngine(t, httpez.CrudHooks[exportItem]{})
+	alice := as("alice")
+
+	w, _ := call(t, r, http.MethodGet, "/api/exp-items/export?sort=id", alice, nil)
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("csv: %d %s", w.Code, w.Header())
+	}
+	recs, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 只导出自己的行；json:"-" 字段不导出；公式前缀被转义
+	if len(recs) != 3 || strings.Join(recs[0], ",") != "id,ownerId,name" || !strings.HasPrefix(recs[1][2], "'") {
+		t.Fatalf("csv rows %q", recs)
+	}
+
+	w, _ = call(t, r, http.MethodGet, "/api/exp-items/export?format=ndjson&name=b&fields=name", alice, nil)
+	if got := strings.TrimSpace(w.Body.String()); got != `{"id":2,"name":"b"}` {
+		t.Fatalf("ndjson: %q", got)
+	}
+
+	w, _ = call(t, r, http.MethodGet, "/api/exp-items/export?format=xlsx", alice, nil)
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Body.String(), "PK") {
+		t.Fatalf("xlsx: %d", w.Code)
+	}
+	if w, _ := call(t, r, http.MethodGet, "/api/exp-items/export?format=pdf", alice, nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("bad format: %d, want 400", w.Code)
+	}
+}
+
+// 慢导出不受服务端写超时和 Timeout 中间件截断
+func TestExportOutlivesWriteTimeout(t *testing.T) {
+	r := exportEngine(t, httpez.CrudHooks[exportItem]{
+		AfterGet: func(*gin.Context, *exportItem) { time.Sleep(150 * time.Millisecond) },
+	}, mdw.Timeout(100*time.Millisecond))
+	srv := httptest.NewUnstartedServer(r)
+	srv.Config.WriteTimeout = 100 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/api/exp-items/export?format=ndjson", nil)
+	req.Header.Set("X-User", "alice")
+	res, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("export cut off: %v (got %q)", err, body)
+	}
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	if len(lines) != 2 || !json.Valid([]byte(lines[1])) {
+		t.Fatalf("export body %q", body)
+	}
+}
+
+// 超过导出时限：停止读行，响应被截断
+func TestExportTimeout(t *testing.T) {
+	db := newDB(t)
+	if err := db.AutoMigrate(&exportItem{}); err != nil {
+		t.Fatal(err)
+	}
+	db.Create(&[]exportItem{{OwnerID: "alice", Name: "a"}, {OwnerID: "alice", Name: "b"}, {OwnerID: "alice", Name: "c"}})
+	r := gin.New()
+	r.GET("/export", func(c *gin.Context) {
+		_ = httpez.Export(c, db.Model(&exportItem{}).Order("id"), "ndjson", httpez.ExportOptions[exportItem]{
+			Row: func(m *exportItem) any {
+				time.Sleep(60 * time.Millisecond)
+				return m
+			},
+			WriteTimeout: 100 * time.Millisecond,
+		})
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/export", nil))
+	if n := strings.Count(w.Body.String(), "\n"); n >= 3 {
+		t.Fatalf("export ran past its timeout: %q", w.Body)
+	}
+}
@@ -0,0 +1,290 @@
+package ez
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/schema"
+
+	"go-gin-gorm-starter/internal/core/auth"
+	resp "go-gin-gorm-starter/internal/transport/http/response"
+)
+
+/* ================== 字段级权限 & 输出裁剪 ==================
+   struct tag（CrudConfig.Fields 同名配置优先）：
+     ez:"-"                   不输出、不可写（如 PasswordHash）
+     ez:"writeonly"           可写但不输出
+     ez:"read=admin"          仅 admin 可见
+     ez:"write=admin|ops"     仅 admin/ops 可写（create + update）
+     ez:"create=admin"        创建时可写角色；update=- 表示任何人都不能改
+   ?fields=id,name            稀疏字段集（主键与关联字段总会返回）
+   ?include= 预加载的关联按关联模型上的 ez tag 裁剪（多级同理）
+*/
+
+type FieldRule struct {
+	Hidden    bool     // 不输出、不可写
+	WriteOnly bool     // 可写但不输出
+	Read      []string // 可见角色，空 = 所有人
+	Create    []string // 创建时可写角色，空 = 所有人，"-" = 任何人都不行
+	Update    []string // 更新时可写角色（PUT/PATCH/批量更新）
+}
+
+type fieldOp int
+
+const (
+	opCreate fieldOp = iota
+	opUpdate
+)
+
+func parseFieldTag(tag string) (FieldRule, error) {
+	var r FieldRule
+	for _, part := range strings.Split(tag, ",") {
+		part = strings.TrimSpace(part)
+		key, val, _ := strings.Cut(part, "=")
+		roles := strings.Split(val, "|")
+		switch key {
+		case "":
+		case "-":
+			r.Hidden = true
+		case "writeonly":
+			r.WriteOnly = true
+		case "read":
+			r.Read = roles
+		case "write":
+			r.Create, r.Update = roles, roles
+		case "create":
+			r.Create = roles
+		case "update":
+			r.Update = roles
+		default:
+			return r, fmt.Errorf("unknown option %q", key)
+		}
+	}
+	return r, nil
+}
+
+func roleAllowed(have, roles []string) bool {
+	return len(roles) == 0 || hasRole(have, roles)
+}
+
+type fieldSpec struct {
+	sch    *schema.Schema
+	rules  map[*schema.Field]FieldRule
+	rels   map[string]*fieldSpec // 关联字段 json 名 → 关联模型的规则（取关联模型上的 ez tag）
+	nested bool                  // 关联模型（含多级）上是否有规则，没有则 ?include= 的结果无需裁剪
+}
+
+func newFieldSpec(sch *schema.Schema, cfg map[string]FieldRule) *fieldSpec {
+	s := tagFieldSpec(sch, map[*schema.Schema]*fieldSpec{})
+	for name, r := range cfg {
+		f := lookupField(sch, name)
+		if f == nil {
+			panic(fmt.Sprintf("ez: field rule %q not found on %s", name, sch.Name))
+		}
+		s.rules[f] = r
+	}
+	s.nested = s.relsHaveRules(map[*fieldSpec]bool{})
+	return s
+}
+
+// tagFieldSpec 按模型上的 ez tag 建规则，并递归到关联模型（seen 处理循环引用）
+func tagFieldSpec(sch *schema.Schema, seen map[*schema.Schema]*fieldSpec) *fieldSpec {
+	if s, ok := seen[sch]; ok {
+		return s
+	}
+	s := &fieldSpec{sch: sch, rules: map[*schema.Field]FieldRule{}, rels: map[string]*fieldSpec{}}
+	seen[sch] = s
+	for _, f := range sch.Fields {
+		tag, ok := f.Tag.Lookup("ez")
+		if !ok || f.DBName == "" {
+			continue
+		}
+		r, err := parseFieldTag(tag)
+		if err != nil {
+			panic(fmt.Sprintf("ez: field %s.%s: %v", sch.Name, f.Name, err))
+		}
+		s.rules[f] = r
+	}
+	for _, rel := range sch.Relationships.Relations {
+		if rel.FieldSchema != nil {
+			s.rels[jsonName(rel.Field)] = tagFieldSpec(rel.FieldSchema, seen)
+		}
+	}
+	return s
+}
+
+func (s *fieldSpec) relsHaveRules(seen map[*fieldSpec]bool) bool {
+	seen[s] = true
+	for _, rs := range s.rels {
+		if len(rs.rules) > 0 || (!seen[rs] && rs.relsHaveRules(seen)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *fieldSpec) readable(f *schema.Field, roles []string) bool {
+	r, ok := s.rules[f]
+	return !ok || (!r.Hidden && !r.WriteOnly && roleAllowed(roles, r.Read))
+}
+
+func (s *fieldSpec) writable(f *schema.Field, roles []string, op fieldOp) bool {
+	r, ok := s.rules[f]
+	if !ok {
+		return true
+	}
+	if r.Hidden {
+		return false
+	}
+	if op == opCreate {
+		return roleAllowed(roles, r.Create)
+	}
+	return roleAllowed(roles, r.Update)
+}
+
+// checkWrite 拒绝请求体里出现的、当前角色不可写的字段
+func (s *fieldSpec) checkWrite(body []byte, roles []string, op fieldOp) error {
+	if len(s.rules) == 0 {
+		return nil
+	}
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(body, &keys); err != nil {
+		return BadRequest(err.Error())
+	}
+	for k := range keys {
+		if f := lookupField(s.sch, k); f != nil && !s.writable(f, roles, op) {
+			return Forbidden(fmt.Sprintf("field %q is not writable", k))
+		}
+	}
+	return nil
+}
+
+// 输出裁剪：drop 为当前角色不可见的 json key，keep 为 ?fields= 指定的 json key；
+// 预加载的关联按关联模型自己的规则裁剪（?fields= 只作用于顶层）
+type projection struct {
+	drop  map[string]struct{}
+	keep  map[string]struct{}
+	spec  *fieldSpec
+	roles []string
+}
+
+func (s *fieldSpec) projection(c *gin.Context) (*projection, error) {
+	roles := auth.Roles(c)
+	p := &projection{spec: s, roles: roles}
+	for f := range s.rules {
+		if !s.readable(f, roles) {
+			if p.drop == nil {
+				p.drop = map[string]struct{}{}
+			}
+			p.drop[jsonName(f)] = struct{}{}
+		}
+	}
+	if raw := strings.TrimSpace(c.Query("fields")); raw != "" {
+		p.keep = map[string]struct{}{}
+		for _, f := range s.sch.PrimaryFields {
+			p.keep[jsonName(f)] = struct{}{}
+		}
+		// 关联字段由 ?include= 控制，不受 fields 影响
+		for _, rel := range s.sch.Relationships.Relations {
+			p.keep[jsonName(rel.Field)] = struct{}{}
+		}
+		for _, name := range strings.Split(raw, ",") {
+			f := lookupField(s.sch, name)
+			if f == nil {
+				return nil, BadRequest(fmt.Sprintf("unknown field %q", strings.TrimSpace(name)))
+			}
+			p.keep[jsonName(f)] = struct{}{}
+		}
+	}
+	if p.drop == nil && p.keep == nil && !s.nested {
+		return nil, nil
+	}
+	return p, nil
+}
+
+// strip 就地删掉关联文档（对象或对象数组）里当前角色不可见的字段
+func (s *fieldSpec) strip(v any, roles []string) {
+	switch d := v.(type) {
+	case []any:
+		for _, e := range d {
+			s.strip(e, roles)
+		}
+	case map[string]any:
+		for f := range s.rules {
+			if !s.readable(f, roles) {
+				delete(d, jsonName(f))
+			}
+		}
+		for k, sub := range d {
+			if rs := s.rels[k]; rs != nil {
+				rs.strip(sub, roles)
+			}
+		}
+	}
+}
+
+// one 返回裁剪后的文档；无需裁剪时原样返回。转换失败时报错而不是退回原模型（否则会漏出隐藏字段）
+func (p *projection) one(m any) (any, error) {
+	if p == nil {
+		return m, nil
+	}
+	doc, err := toDoc(m)
+	if err != nil {
+		return nil, Internal("render response failed", err)
+	}
+	for k := range doc {
+		if _, ok := p.drop[k]; ok {
+			delete(doc, k)
+		} else if _, ok := p.keep[k]; p.keep != nil && !ok {
+			delete(doc, k)
+		} else if rs := p.spec.rels[k]; rs != nil {
+			rs.strip(doc[k], p.roles)
+		}
+	}
+	return doc, nil
+}
+
+// ok 写出裁剪后的单条记录
+func (p *projection) ok(c *gin.Context, m any) {
+	out, err := p.one(m)
+	if err != nil {
+		resp.JSON(c, errResp(err))
+		return
+	}
+	resp.JSON(c, resp.OK(out))
+}
+
+// columns 按裁剪规则过滤导出列
+func (p *projection) columns(cols []string) []string {
+	if p == nil {
+		return cols
+	}
+	var out []string
+	for _, k := range cols {
+		if _, ok := p.drop[k]; ok {
+			continue
+		}
+		if _, ok := p.keep[k]; p.keep != nil && !ok {
+			continue
+		}
+		out = append(out, k)
+	}
+	return out
+}
+
+func projectList[T any](p *projection, items []T) (any, error) {
+	if p == nil {
+		return items, nil
+	}
+	out := make([]any, len(items))
+	for i := range items {
+		doc, err := p.one(&items[i])
+		if err != nil {
+			return nil, err
+		}
+		out[i] = doc
+	}
+	return out, nil
+}
@@ -0,0 +1,163 @@
+package ez_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	httpez "go-gin-gorm-starter/internal/transport/http/ez"
+)
+
+type staffProfile struct {
+	ID           int64  `gorm:"primaryKey" json:"id"`
+	OwnerID      string `gorm:"size:36;index" json:"ownerId"`
+	Name         string `json:"name"`
+	Nick         string `json:"nick"`
+	PasswordHash string `json:"passwordHash" ez:"-"`
+	Secret       string `json:"secret" ez:"writeonly"`
+	Salary       int    `json:"salary" ez:"read=hr|admin"`
+	Level        int    `json:"level" ez:"write=admin"`
+	Plan         string `json:"plan" ez:"create=admin,update=-"`
+}
+
+// keysOf 返回 data 里的 json key（排序后逗号拼接）
+func keysOf(t *testing.T, raw json.RawMessage) string {
+	t.Helper()
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		t.Fatalf("decode %s: %v", raw, err)
+	}
+	keys := make([]string, 0, len(doc))
+	for k := range doc {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+func TestFieldRules(t *testing.T) {
+	db := newDB(t)
+	r, g := newEngine(t)
+	httpez.Crud(httpez.CrudConfig[staffProfile]{
+		DB: db, Group: g, Path: "/profiles", New: func() *staffProfile { return &staffProfile{} },
+		Scope:  httpez.Scope{Mode: httpez.ScopeRoleBypass, BypassRoles: []string{"hr", "admin"}},
+		Fields: map[string]httpez.FieldRule{"nick": {Read: []string{"admin"}}},
+	})
+	alice, hr, admin := as("alice"), as("hank", "hr"), as("root", "admin")
+	merge := func(h http.Header) http.Header { return with(h, "Content-Type", "application/merge-patch+json") }
+
+	// 创建：不可写字段 403
+	for _, body := range []map[string]any{
+		{"name": "a", "passwordHash": "x"},
+		{"name": "a", "level": 3},
+		{"name": "a", "plan": "pro"},
+	} {
+		if w, _ := call(t, r, http.MethodPost, "/api/profiles", alice, body); w.Code != http.StatusForbidden {
+			t.Errorf("create %v: %d, want 403", body, w.Code)
+		}
+	}
+	w, res := call(t, r, http.MethodPost, "/api/profiles", alice, map[string]any{"name": "alice", "nick": "al", "secret": "s3", "salary": 10})
+	if w.Code != http.StatusOK {
+		t.Fatalf("create: %d %s", w.Code, w.Body)
+	}
+	if got := keysOf(t, res.Data); got != "id,level,name,ownerId,plan" {
+		t.Fatalf("create response keys %s", got)
+	}
+	id := decode[staffProfile](t, res).ID
+	path := "/api/profiles/" + strconv.FormatInt(id, 10)
+	var stored staffProfile
+	db.First(&stored, id)
+	if stored.Secret != "s3" || stored.Salary != 10 || stored.Nick != "al" {
+		t.Fatalf("write-only fields not stored: %+v", stored)
+	}
+
+	// 读取：按角色裁剪
+	for _, tc := range []struct {
+		name string
+		hdr  http.Header
+		want string
+	}{
+		{"owner", alice, "id,level,name,ownerId,plan"},
+		{"hr", hr, "id,level,name,ownerId,plan,salary"},
+		{"admin", admin, "id,level,name,nick,ownerId,plan,salary"},
+	} {
+		_, res := call(t, r, http.MethodGet, path, tc.hdr, nil)
+		if got := keysOf(t, res.Data); got != tc.want {
+			t.Errorf("%s sees %s, want %s", tc.name, got, tc.want)
+		}
+	}
+
+	// ?fields=：稀疏字段集，主键总会返回，不可见字段仍然裁掉
+	_, res = call(t, r, http.MethodGet, path+"?fields=name,salary", alice, nil)
+	if got := keysOf(t, res.Data); got != "id,name" {
+		t.Fatalf("alice ?fields=name,salary: %s", got)
+	}
+	_, res = call(t, r, http.MethodGet, "/api/profiles?fields=salary", hr, nil)
+	list := decode[struct{ List []json.RawMessage }](t, res).List
+	if len(list) != 1 || keysOf(t, list[0]) != "id,salary" {
+		t.Fatalf("hr list ?fields=salary: %s", res.Data)
+	}
+	if w, _ := call(t, r, http.MethodGet, path+"?fields=bogus", alice, nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("unknown field in ?fields=: %d, want 400", w.Code)
+	}
+
+	// 更新：write=admin 仅管理员可改，update=- 任何人都不能改（原样回传放行）
+	if w, _ := call(t, r, http.MethodPatch, path, merge(alice), `{"level":2}`); w.Code != http.StatusForbidden {
+		t.Fatalf("alice patch level: %d, want 403", w.Code)
+	}
+	if w, _ := call(t, r, http.MethodPut, path, alice, map[string]any{"name": "alice", "level": 2}); w.Code != http.StatusForbidden {
+		t.Fatalf("alice put level: %d, want 403", w.Code)
+	}
+	if w, _ := call(t, r, http.MethodPatch, path, merge(admin), `{"level":2}`); w.Code != http.StatusOK {
+		t.Fatalf("admin patch level: %d %s", w.Code, w.Body)
+	}
+	if w, _ := call(t, r, http.MethodPatch, path, merge(admin), `{"plan":"pro"}`); w.Code != http.StatusForbidden {
+		t.Fatalf("admin patch plan: %d, want 403", w.Code)
+	}
+	if w, _ := call(t, r, http.MethodPatch, path, merge(admin), `{"plan":"","name":"Alice"}`); w.Code != http.StatusOK {
+		t.Fatalf("admin patch echoing plan: %d %s", w.Code, w.Body)
+	}
+	if w, _ := call(t, r, http.MethodPatch, path, merge(alice), `{"passwordHash":"x"}`); w.Code != http.StatusForbidden {
+		t.Fatalf("alice patch hidden field: %d, want 403", w.Code)
+	}
+	db.First(&stored, id)
+	if stored.Level != 2 || stored.Name != "Alice" || stored.Plan != "" || stored.PasswordHash != "" {
+		t.Fatalf("after updates: %+v", stored)
+	}
+}
+
+// 序列化失败的字段：裁剪时无法转成文档
+type brokenJSON string
+
+func (b brokenJSON) MarshalJSON() ([]byte, error) {
+	if b == "boom" {
+		return nil, errors.New("cannot marshal")
+	}
+	return json.Marshal(string(b))
+}
+
+type brokenProfile struct {
+	ID      int64      `gorm:"primaryKey" json:"id"`
+	OwnerID string     `gorm:"size:36;index" json:"ownerId"`
+	Status  brokenJSON `json:"status"`
+	Secret  string     `json:"secret" ez:"-"`
+}
+
+func TestProjectionFailsClosed(t *testing.T) {
+	db := newDB(t)
+	r, g := newEngine(t)
+	httpez.Crud(httpez.CrudConfig[brokenProfile]{
+		DB: db, Group: g, Path: "/profiles", New: func() *brokenProfile { return &brokenProfile{} },
+	})
+	db.Create(&brokenProfile{ID: 1, OwnerID: "alice", Status: "boom", Secret: "TOPSECRET"})
+	for _, path := range []string{"/api/profiles/1", "/api/profiles"} {
+		w, _ := call(t, r, http.MethodGet, path, as("alice"), nil)
+		if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "TOPSECRET") {
+			t.Errorf("%s: %d %s, want 500 without hidden fields", path, w.Code, w.Body)
+		}
+	}
+}
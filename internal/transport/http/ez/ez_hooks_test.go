@@ -0,0 +1,143 @@
+package ez_test
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	httpez "go-gin-gorm-starter/internal/transport/http/ez"
+)
+
+type hookedDoc struct {
+	ID      int64  `gorm:"primaryKey" json:"id"`
+	OwnerID string `gorm:"size:36;index" json:"ownerId"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	Locked  bool   `json:"locked"`
+	Preview string `gorm:"-" json:"preview"`
+}
+
+type auditEntry struct {
+	ID     int64 `gorm:"primaryKey"`
+	Action string
+	DocID  int64
+	Detail string
+}
+
+func TestCrudHooks(t *testing.T) {
+	db := newDB(t)
+	if err := db.AutoMigrate(&auditEntry{}); err != nil {
+		t.Fatal(err)
+	}
+	r, g := newEngine(t)
+	audit := func(tx *gorm.DB, action string, id int64, detail string) error {
+		return tx.Create(&auditEntry{Action: action, DocID: id, Detail: detail}).Error
+	}
+	httpez.Crud(httpez.CrudConfig[hookedDoc]{
+		DB: db, Group: g, Path: "/docs", New: func() *hookedDoc { return &hookedDoc{} },
+		Hooks: httpez.CrudHooks[hookedDoc]{
+			AfterCreate: func(c *gin.Context, tx *gorm.DB, m *hookedDoc) error {
+				if err := audit(tx, "create", m.ID, m.Title); err != nil {
+					return err
+				}
+				switch m.Title {
+				case "fail":
+					return errors.New("rejected by hook")
+				case "dup":
+					return &httpez.AErr{Code: http.StatusConflict, Msg: "duplicate title"}
+				}
+				return nil
+			},
+			AfterUpdate: func(c *gin.Context, tx *gorm.DB, old, m *hookedDoc) error {
+				// m 是更新后的完整记录（PUT 未传的字段也在）
+				return audit(tx, "update", m.ID, old.Title+"->"+m.Title+"|"+m.Body)
+			},
+			BeforeDelete: func(c *gin.Context, tx *gorm.DB, m *hookedDoc) error {
+				if m.Locked {
+					return httpez.Forbidden("document is locked")
+				}
+				return nil
+			},
+			AfterDelete: func(c *gin.Context, tx *gorm.DB, m *hookedDoc) error {
+				return audit(tx, "delete", m.ID, m.Title)
+			},
+			AfterGet: func(c *gin.Context, m *hookedDoc) {
+				m.Preview = m.Title + ": " + m.Body
+			},
+		},
+	})
+	alice := as("alice")
+	entries := func() []auditEntry {
+		t.Helper()
+		var out []auditEntry
+		if err := db.Order("id").Find(&out).Error; err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	// AfterCreate 在同一事务内：钩子出错时记录与审计一起回滚
+	w, res := call(t, r, http.MethodPost, "/api/docs", alice, map[string]any{"title": "t1", "body": "b1"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("create: %d %s", w.Code, w.Body)
+	}
+	doc := decode[hookedDoc](t, res)
+	if e := entries(); len(e) != 1 || e[0].DocID != doc.ID || doc.ID == 0 {
+		t.Fatalf("AfterCreate audit %+v for doc %d", e, doc.ID)
+	}
+	if w, _ := call(t, r, http.MethodPost, "/api/docs", alice, map[string]any{"title": "fail"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("AfterCreate plain error: %d, want 400", w.Code)
+	}
+	if w, _ := call(t, r, http.MethodPost, "/api/docs", alice, map[string]any{"title": "dup"}); w.Code != http.StatusConflict {
+		t.Fatalf("AfterCreate AErr: %d, want 409", w.Code)
+	}
+	if n := count[hookedDoc](t, db); n != 1 || len(entries()) != 1 {
+		t.Fatalf("failed creates left %d docs / %d audit rows", n, len(entries()))
+	}
+
+	// AfterUpdate 拿到旧值与更新后的完整记录
+	path := "/api/docs/" + strconv.FormatInt(doc.ID, 10)
+	if w, _ := call(t, r, http.MethodPut, path, alice, map[string]any{"title": "t2"}); w.Code != http.StatusOK {
+		t.Fatalf("put: %d %s", w.Code, w.Body)
+	}
+	merge := with(alice, "Content-Type", "application/merge-patch+json")
+	if w, _ := call(t, r, http.MethodPatch, path, merge, `{"title":"t3"}`); w.Code != http.StatusOK {
+		t.Fatalf("patch: %d %s", w.Code, w.Body)
+	}
+	e := entries()
+	if len(e) != 3 || e[1].Detail != "t1->t2|b1" || e[2].Detail != "t2->t3|b1" {
+		t.Fatalf("AfterUpdate audit %+v", e)
+	}
+
+	// AfterGet 加工输出
+	_, res = call(t, r, http.MethodGet, path, alice, nil)
+	if got := decode[hookedDoc](t, res).Preview; got != "t3: b1" {
+		t.Fatalf("AfterGet preview %q", got)
+	}
+
+	// BeforeDelete 可拒绝；AfterDelete 拿到被删记录
+	if w, _ := call(t, r, http.MethodPatch, path, merge, `{"locked":true}`); w.Code != http.StatusOK {
+		t.Fatalf("lock: %d %s", w.Code, w.Body)
+	}
+	if w, _ := call(t, r, http.MethodDelete, path, alice, nil); w.Code != http.StatusForbidden {
+		t.Fatalf("delete locked: %d, want 403", w.Code)
+	}
+	if n := count[hookedDoc](t, db); n != 1 {
+		t.Fatalf("locked doc deleted")
+	}
+	call(t, r, http.MethodPatch, path, merge, `{"locked":false}`)
+	if w, _ := call(t, r, http.MethodDelete, path, alice, nil); w.Code != http.StatusOK {
+		t.Fatalf("delete: %d %s", w.Code, w.Body)
+	}
+	e = entries()
+	if last := e[len(e)-1]; last.Action != "delete" || last.DocID != doc.ID || last.Detail != "t3" {
+		t.Fatalf("AfterDelete audit %+v", last)
+	}
+	if w, _ := call(t, r, http.MethodDelete, path, alice, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("delete twice: %d, want 404", w.Code)
+	}
+}
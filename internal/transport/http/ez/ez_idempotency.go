@@ -0,0 +1,419 @@
+package ez
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"go-gin-gorm-starter/internal/core/auth"
+	"go-gin-gorm-starter/internal/core/cache"
+	resp "go-gin-gorm-starter/internal/transport/http/response"
+)
+
+/* ================== 幂等：Idempotency-Key ==================
+   Action{Idempotent: true} / CrudConfig{Idempotent: true}（仅 Create）开启；请求不带 Idempotency-Key 时照常执行
+   - 作用域：用户（未登录时按客户端 IP）+ 方法 + 路由 + Key；请求指纹：query + body 的 sha256
+   - 首次：抢占 Key（并发的重复请求直接 409 in-flight），执行后保存响应（状态码 / Content-Type / body）
+   - 占位时长 SetIdempotencyLockTTL（默认 1 分钟）；handler 执行期间每 1/3 时长续一次，进程崩溃后最多锁这么久
+   - 重试：指纹一致 → 原样重放并带 Idempotent-Replayed: true；不一致 → 409
+   - 5xx（含信封 code >= 500）不保存，释放 Key 以便客户端重试
+   存储：SetIdempotencyStore(NewDBIdempotencyStore / NewRedisIdempotencyStore)，不设时用进程内存储（仅单实例）
+*/
+
+const (
+	HeaderIdempotencyKey = "Idempotency-Key"
+	idempotencyMaxKeyLen = 255
+)
+
+var (
+	CodeIdempotencyKeyReused = resp.Register(resp.CodeDef{
+		Code: 40901, Key: "idempotency_key_reused", Status: http.StatusConflict,
+		Messages: map[string]string{
+			"en": "Idempotency-Key has already been used with a different request",
+			"zh": "Idempotency-Key 已用于另一个不同的请求",
+		},
+	})
+	CodeIdempotencyInFlight = resp.Register(resp.CodeDef{
+		Code: 40902, Key: "idempotency_in_flight", Status: http.StatusConflict,
+		Messages: map[string]string{
+			"en": "a request with the same Idempotency-Key is still in progress",
+			"zh": "相同 Idempotency-Key 的请求仍在处理中",
+		},
+	})
+)
+
+// ErrIdempotencyInFlight Begin 时同一 Key 的请求仍在处理中
+var ErrIdempotencyInFlight = errors.New("idempotency: request in flight")
+
+// IdempotencyRecord 一次已完成请求的响应快照
+type IdempotencyRecord struct {
+	Hash        string `json:"hash"`
+	Done        bool   `json:"done"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// IdempotencyStore 幂等记录存储
+type IdempotencyStore interface {
+	// Begin 抢占 key：抢到返回 (nil, nil)；已完成返回其记录；仍在处理中返回 ErrIdempotencyInFlight
+	Begin(ctx context.Context, key, hash string, lockTTL time.Duration) (*IdempotencyRecord, error)
+	// Complete 保存响应，记录保留 ttl
+	Complete(ctx context.Context, key string, rec IdempotencyRecord, ttl time.Duration) error
+	// Release 放弃占位（执行失败，允许重试）
+	Release(ctx context.Context, key string) error
+	// Extend 把仍在处理中的占位续到 lockTTL 之后；已完成或不存在时不做任何事
+	Extend(ctx context.Context, key string, lockTTL time.Duration) error
+}
+
+var (
+	idemStore   IdempotencyStore = NewMemoryIdempotencyStore()
+	idemTTL                      = 24 * time.Hour
+	idemLockTTL                  = time.Minute
+)
+
+// SetIdempotencyLockTTL 设置进行中占位的时长（<= 0 时 1 分钟），启动时调用
+func SetIdempotencyLockTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+	idemLockTTL = ttl
+}
+
+// SetIdempotencyStore 设置幂等存储与响应保留时长（ttl <= 0 时 24h），启动时调用
+func SetIdempotencyStore(s IdempotencyStore, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	idemStore, idemTTL = s, ttl
+}
+
+// idempotent 包装写接口：按 Idempotency-Key 去重 / 重放
+func idempotent(h gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimSpace(c.GetHeader(HeaderIdempotencyKey))
+		if key == "" {
+			h(c)
+			return
+		}
+		if len(key) > idempotencyMaxKeyLen {
+			resp.JSON(c, resp.Error(resp.CodeBadRequest, "Idempotency-Key too long"))
+			return
+		}
+		body, err := c.GetRawData()
+		if err != nil {
+			resp.JSON(c, resp.Error(resp.CodeBadRequest, err.Error()))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.URL.RawQuery + "\n"))
+		hash.Write(body)
+		fp := hex.EncodeToString(hash.Sum(nil))
+		// 未登录的请求按客户端 IP 隔开，避免匿名调用方共用同一批 Key
+		who := "user:" + auth.UserID(c)
+		if auth.UserID(c) == "" {
+			who = "ip:" + c.ClientIP()
+		}
+		scope := sha256.Sum256([]byte(who + "\x00" + c.Request.Method + " " + c.FullPath() + "\x00" + key))
+		storeKey := "idem:" + hex.EncodeToString(scope[:])
+
+		ctx := context.WithoutCancel(c.Request.Context())
+		lockTTL := idemLockTTL
+		rec, err := idemStore.Begin(ctx, storeKey, fp, lockTTL)
+		switch {
+		case errors.Is(err, ErrIdempotencyInFlight):
+			resp.JSON(c, resp.Coded(CodeIdempotencyInFlight, nil))
+			return
+		case err != nil:
+			resp.JSON(c, errResp(Internal("idempotency store failed", err)))
+			return
+		case rec != nil:
+			if rec.Hash != fp {
+				resp.JSON(c, resp.Coded(CodeIdempotencyKeyReused, nil))
+				return
+			}
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(rec.Status, rec.ContentType, rec.Body)
+			return
+		}
+
+		// 执行期间续占位，防止慢请求的占位过期后被重复执行
+		stop, stopped := make(chan struct{}), make(chan struct{})
+		go func() {
+			defer close(stopped)
+			t := time.NewTicker(lockTTL / 3)
+			defer t.Stop()
+			for {
+				select {
+				case <-stop:
+					return
+				case <-t.C:
+					_ = idemStore.Extend(ctx, storeKey, lockTTL)
+				}
+			}
+		}()
+
+		w := &captureWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		func() {
+			// handler panic 时也要放掉占位
+			defer func() {
+				close(stop)
+				<-stopped
+				c.Writer = w.ResponseWriter
+				if p := recover(); p != nil {
+					_ = idemStore.Release(ctx, storeKey)
+					panic(p)
+				}
+			}()
+			h(c)
+		}()
+
+		status := w.Status()
+		if status >= http.StatusInternalServerError || envelopeCode(w.buf.Bytes()) >= resp.CodeServerError {
+			_ = idemStore.Release(ctx, storeKey)
+			return
+		}
+		_ = idemStore.Complete(ctx, storeKey, IdempotencyRecord{
+			Hash: fp, Done: true, Status: status,
+			ContentType: w.Header().Get("Content-Type"), Body: w.buf.Bytes(),
+		}, idemTTL)
+	}
+}
+
+// captureWriter 边写边留一份响应体
+type captureWriter struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	w.buf.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *captureWriter) WriteString(s string) (int, error) {
+	w.buf.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// 信封 / problem 里的业务码（legacy 模式下 5xx 也是 HTTP 200）
+func envelopeCode(body []byte) int {
+	var v struct {
+		Code int `json:"code"`
+	}
+	_ = json.Unmarshal(body, &v)
+	return v.Code
+}
+
+/* ---------- 进程内存储（单实例 / 开发） ---------- */
+
+type memoryIdempotencyStore struct {
+	mu   sync.Mutex
+	recs map[string]memoryIdemEntry
+}
+
+type memoryIdemEntry struct {
+	rec     IdempotencyRecord
+	expires time.Time
+}
+
+func NewMemoryIdempotencyStore() IdempotencyStore {
+	return &memoryIdempotencyStore{recs: map[string]memoryIdemEntry{}}
+}
+
+func (s *memoryIdempotencyStore) Begin(_ context.Context, key, hash string, lockTTL time.Duration) (*IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for k, e := range s.recs {
+		if now.After(e.expires) {
+			delete(s.recs, k)
+		}
+	}
+	if e, ok := s.recs[key]; ok {
+		if !e.rec.Done {
+			return nil, ErrIdempotencyInFlight
+		}
+		rec := e.rec
+		return &rec, nil
+	}
+	s.recs[key] = memoryIdemEntry{rec: IdempotencyRecord{Hash: hash}, expires: now.Add(lockTTL)}
+	return nil, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(_ context.Context, key string, rec IdempotencyRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recs[key] = memoryIdemEntry{rec: rec, expires: time.Now().Add(ttl)}
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.recs, key)
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Extend(_ context.Context, key string, lockTTL time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.recs[key]; ok && !e.rec.Done {
+		e.expires = time.Now().Add(lockTTL)
+		s.recs[key] = e
+	}
+	return nil
+}
+
+/* ---------- 数据库存储 ---------- */
+
+// IdempotencyKeyModel 表 idempotency_keys
+type IdempotencyKeyModel struct {
+	Key         string `gorm:"primaryKey;size:80"`
+	Hash        string `gorm:"size:64;not null"`
+	Done        bool   `gorm:"not null;default:false"`
+	Status      int
+	ContentType string `gorm:"size:128"`
+	Body        []byte
+	ExpiresAt   time.Time `gorm:"index;not null"`
+}
+
+func (IdempotencyKeyModel) TableName() string { return "idempotency_keys" }
+
+type dbIdempotencyStore struct{ db *gorm.DB }
+
+// NewDBIdempotencyStore 用数据库存幂等记录（会自动建表）；过期记录在再次命中时清理
+func NewDBIdempotencyStore(db *gorm.DB) (IdempotencyStore, error) {
+	if err := db.AutoMigrate(&IdempotencyKeyModel{}); err != nil {
+		return nil, err
+	}
+	return &dbIdempotencyStore{db: db}, nil
+}
+
+func (s *dbIdempotencyStore) Begin(ctx context.Context, key, hash string, lockTTL time.Duration) (*IdempotencyRecord, error) {
+	db := s.db.WithContext(ctx)
+	for attempt := 0; attempt < 2; attempt++ {
+		now := time.Now()
+		res := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&IdempotencyKeyModel{Key: key, Hash: hash, ExpiresAt: now.Add(lockTTL)})
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected == 1 {
+			return nil, nil
+		}
+		var m IdempotencyKeyModel
+		err := db.Where(map[string]any{"key": key}).Take(&m).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue // 刚被释放，重抢
+		}
+		if err != nil {
+			return nil, err
+		}
+		if now.After(m.ExpiresAt) {
+			// 过期（含崩溃遗留的占位）：删掉重抢；带上 expires_at 防止误删别人刚抢到的
+			if err := db.Where(map[string]any{"key": key, "expires_at": m.ExpiresAt}).Delete(&IdempotencyKeyModel{}).Error; err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if !m.Done {
+			return nil, ErrIdempotencyInFlight
+		}
+		return &IdempotencyRecord{Hash: m.Hash, Done: true, Status: m.Status, ContentType: m.ContentType, Body: m.Body}, nil
+	}
+	return nil, ErrIdempotencyInFlight
+}
+
+func (s *dbIdempotencyStore) Complete(ctx context.Context, key string, rec IdempotencyRecord, ttl time.Duration) error {
+	return s.db.WithContext(ctx).Model(&IdempotencyKeyModel{}).Where(map[string]any{"key": key}).Updates(map[string]any{
+		"done": true, "status": rec.Status, "content_type": rec.ContentType, "body": rec.Body,
+		"expires_at": time.Now().Add(ttl),
+	}).Error
+}
+
+func (s *dbIdempotencyStore) Release(ctx context.Context, key string) error {
+	return s.db.WithContext(ctx).Where(map[string]any{"key": key, "done": false}).Delete(&IdempotencyKeyModel{}).Error
+}
+
+func (s *dbIdempotencyStore) Extend(ctx context.Context, key string, lockTTL time.Duration) error {
+	return s.db.WithContext(ctx).Model(&IdempotencyKeyModel{}).Where(map[string]any{"key": key, "done": false}).
+		Update("expires_at", time.Now().Add(lockTTL)).Error
+}
+
+/* ---------- Redis 存储 ---------- */
+
+type redisIdempotencyStore struct{ rdb *redis.Client }
+
+// NewRedisIdempotencyStore 用 Redis 存幂等记录（SETNX 占位，过期交给 Redis）
+func NewRedisIdempotencyStore(c *cache.Cache) IdempotencyStore {
+	return &redisIdempotencyStore{rdb: c.RDB}
+}
+
+func (s *redisIdempotencyStore) Begin(ctx context.Context, key, hash string, lockTTL time.Duration) (*IdempotencyRecord, error) {
+	pending, _ := json.Marshal(IdempotencyRecord{Hash: hash})
+	for attempt := 0; attempt < 2; attempt++ {
+		ok, err := s.rdb.SetNX(ctx, key, pending, lockTTL).Result()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return nil, nil
+		}
+		b, err := s.rdb.Get(ctx, key).Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue // 刚过期 / 被释放，重抢
+		}
+		if err != nil {
+			return nil, err
+		}
+		var rec IdempotencyRecord
+		if err := json.Unmarshal(b, &rec); err != nil {
+			return nil, err
+		}
+		if !rec.Done {
+			return nil, ErrIdempotencyInFlight
+		}
+		return &rec, nil
+	}
+	return nil, ErrIdempotencyInFlight
+}
+
+func (s *redisIdempotencyStore) Complete(ctx context.Context, key string, rec IdempotencyRecord, ttl time.Duration) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return s.rdb.Set(ctx, key, b, ttl).Err()
+}
+
+func (s *redisIdempotencyStore) Release(ctx context.Context, key string) error {
+	return s.rdb.Del(ctx, key).Err()
+}
+
+// 只续仍在处理中的占位：已完成的记录不能被改短过期时间
+var redisIdemExtend = redis.NewScript(`
+local v = redis.call('GET', KEYS[1])
+if v and not string.find(v, '"done":true', 1, true) then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return 0`)
+
+func (s *redisIdempotencyStore) Extend(ctx context.Context, key string, lockTTL time.Duration) error {
+	return redisIdemExtend.Run(ctx, s.rdb, []string{key}, lockTTL.Milliseconds()).Err()
+}
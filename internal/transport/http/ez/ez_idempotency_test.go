@@ -0,0 +1,115 @@
+package ez_test
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	httpez "go-gin-gorm-starter/internal/transport/http/ez"
+)
+
+type payIn struct {
+	N     int `json:"n"`
+	Sleep int `json:"sleep"` // 毫秒
+}
+
+// payEngine 幂等动作 POST /api/pay，返回第几次真正执行
+func payEngine(t *testing.T, store httpez.IdempotencyStore) (*gin.Engine, *atomic.Int32) {
+	t.Helper()
+	httpez.SetIdempotencyStore(store, time.Hour)
+	t.Cleanup(func() {
+		httpez.SetIdempotencyStore(httpez.NewMemoryIdempotencyStore(), 0)
+		httpez.SetIdempotencyLockTTL(0)
+	})
+	r, g := newEngine(t)
+	var calls atomic.Int32
+	httpez.RegisterAction(httpez.New(g), newDB(t), httpez.Action[payIn, struct{ Call int32 }]{
+		Method: http.MethodPost, Path: "/pay", Binder: httpez.BindJSON, Idempotent: true,
+		Handler: func(c *gin.Context, _ *gorm.DB, in *payIn) (struct{ Call int32 }, error) {
+			n := calls.Add(1)
+			time.Sleep(time.Duration(in.Sleep) * time.Millisecond)
+			if in.N == 500 {
+				return struct{ Call int32 }{}, httpez.Internal("boom", nil)
+			}
+			return struct{ Call int32 }{n}, nil
+		},
+	})
+	return r, &calls
+}
+
+func TestIdempotentReplayAndConflict(t *testing.T) {
+	r, calls := payEngine(t, httpez.NewMemoryIdempotencyStore())
+	alice := with(as("alice"), "Idempotency-Key", "k1")
+
+	w1, res1 := call(t, r, http.MethodPost, "/api/pay", alice, map[string]any{"n": 1})
+	w2, res2 := call(t, r, http.MethodPost, "/api/pay", alice, map[string]any{"n": 1})
+	if w1.Code != http.StatusOK || w2.Header().Get("Idempotent-Replayed") != "true" || string(res1.Data) != string(res2.Data) || calls.Load() != 1 {
+		t.Fatalf("replay: %d %s / %s %s, calls %d", w1.Code, res1.Data, w2.Header(), res2.Data, calls.Load())
+	}
+	// 同 Key 不同请求体 → 409
+	if w, res := call(t, r, http.MethodPost, "/api/pay", alice, map[string]any{"n": 2}); w.Code != http.StatusConflict || res.Code != httpez.CodeIdempotencyKeyReused {
+		t.Fatalf("reused key: %d %d", w.Code, res.Code)
+	}
+	// 同 Key 换个用户互不影响
+	if w, _ := call(t, r, http.MethodPost, "/api/pay", with(as("bob"), "Idempotency-Key", "k1"), map[string]any{"n": 1}); w.Header().Get("Idempotent-Replayed") != "" || calls.Load() != 2 {
+		t.Fatalf("bob replayed alice's response")
+	}
+	// 5xx 不保存：可重试
+	bad := with(as("alice"), "Idempotency-Key", "k500")
+	call(t, r, http.MethodPost, "/api/pay", bad, map[string]any{"n": 500})
+	call(t, r, http.MethodPost, "/api/pay", bad, map[string]any{"n": 500})
+	if calls.Load() != 4 {
+		t.Fatalf("5xx response was replayed: calls %d", calls.Load())
+	}
+}
+
+func TestIdempotentAnonymousScopedByIP(t *testing.T) {
+	r, calls := payEngine(t, httpez.NewMemoryIdempotencyStore())
+	from := func(ip string) http.Header {
+		return http.Header{"Idempotency-Key": {"anon"}, "X-Forwarded-For": {ip}}
+	}
+	call(t, r, http.MethodPost, "/api/pay", from("10.0.0.1"), map[string]any{"n": 1})
+	if w, _ := call(t, r, http.MethodPost, "/api/pay", from("10.0.0.2"), map[string]any{"n": 1}); w.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatal("anonymous caller got another client's response")
+	}
+	if w, _ := call(t, r, http.MethodPost, "/api/pay", from("10.0.0.1"), map[string]any{"n": 1}); w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatal("same client not replayed")
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("calls %d, want 2", calls.Load())
+	}
+}
+
+// 执行时间超过占位时长：占位被续上，重复请求仍是 409 in-flight 而不是再执行一次
+func TestIdempotentLockOutlivesSlowHandler(t *testing.T) {
+	store, err := httpez.NewDBIdempotencyStore(newDB(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, calls := payEngine(t, store)
+	httpez.SetIdempotencyLockTTL(90 * time.Millisecond)
+	hdr := with(as("alice"), "Idempotency-Key", "slow")
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if w, _ := call(t, r, http.MethodPost, "/api/pay", hdr, map[string]any{"sleep": 400}); w.Code != http.StatusOK {
+			t.Errorf("slow request: %d", w.Code)
+		}
+	}()
+	time.Sleep(250 * time.Millisecond)
+	w, res := call(t, r, http.MethodPost, "/api/pay", hdr, map[string]any{"sleep": 400})
+	if w.Code != http.StatusConflict || res.Code != httpez.CodeIdempotencyInFlight {
+		t.Errorf("duplicate during slow request: %d %d, want 409 in-flight", w.Code, res.Code)
+	}
+	wg.Wait()
+	if w, _ := call(t, r, http.MethodPost, "/api/pay", hdr, map[string]any{"sleep": 400}); w.Header().Get("Idempotent-Replayed") != "true" || calls.Load() != 1 {
+		t.Fatalf("after completion: replayed=%q calls %d", w.Header().Get("Idempotent-Replayed"), calls.Load())
+	}
+}
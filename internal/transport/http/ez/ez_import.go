@@ -0,0 +1,433 @@
+package ez
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"go-gin-gorm-starter/internal/core/auth"
+	resp "go-gin-gorm-starter/internal/transport/http/response"
+	"go-gin-gorm-starter/pkg/utils"
+)
+
+/* ================== 批量导入：CSV / NDJSON ==================
+   POST /path/import            multipart file=<.csv|.ndjson>（或 ?format=csv|ndjson）
+        ?dryRun=true            只校验：整份文件在一个事务里跑完再回滚，返回逐行报告；不调 AfterCreate（避免发信等副作用）
+        ?async=true             转后台任务（行数超过 ImportAsyncRows 时自动转）
+   GET  /path/import/:task      查询后台任务进度与报告（仅发起人可见）
+   - CSV 表头按 json 名 / 字段名 / 列名匹配字段，未知列 400；单元格按字段类型转换
+   - 每行与单条创建一致：字段写权限 → binding 校验 → BeforeCreate → 插入 → AfterCreate
+   - 按 ImportBatchSize 分批提交，失败行只回滚本行；报告里的 line 为文件中的行号
+   - 后台任务存在进程内存（单进程有效，重启即丢）；任务 panic 时标记为 failed
+*/
+
+type ImportRowError struct {
+	Line   int          `json:"line"`
+	Code   int          `json:"code"`
+	Msg    string       `json:"msg"`
+	Errors []FieldError `json:"errors,omitempty"` // 校验失败时的字段错误
+}
+
+// 报告里最多保留的错误行数
+const importMaxErrors = 1000
+
+type ImportReport struct {
+	Total     int              `json:"total"`
+	Succeeded int              `json:"succeeded"` // dryRun 时为“可成功”的行数
+	Failed    int              `json:"failed"`
+	DryRun    bool             `json:"dryRun"`
+	Errors    []ImportRowError `json:"errors"`
+	Truncated bool             `json:"errorsTruncated,omitempty"`
+}
+
+func (r *ImportReport) fail(line int, err error) {
+	r.Failed++
+	if len(r.Errors) >= importMaxErrors {
+		r.Truncated = true
+		return
+	}
+	e := errResp(err)
+	r.Errors = append(r.Errors, ImportRowError{Line: line, Code: e.Code, Msg: e.Msg, Errors: fieldErrors(err)})
+}
+
+type importRow struct {
+	line int
+	raw  json.RawMessage
+	err  error // 解析阶段的错误（该行直接记失败）
+}
+
+var errDryRun = errors.New("ez: import dry run")
+
+type importSpec[T any] struct {
+	cfg     *CrudConfig[T]
+	sch     *schema.Schema
+	batch   batchSpec[T]
+	prepare func(c *gin.Context, tx *gorm.DB, a access, m *T) error
+	after   func(c *gin.Context, tx *gorm.DB, m *T) error
+}
+
+func (s importSpec[T]) mount() {
+	cfg := s.cfg
+	maxRows := cfg.ImportMaxRows
+	if maxRows <= 0 {
+		maxRows = 100000
+	}
+	asyncRows := cfg.ImportAsyncRows
+	if asyncRows <= 0 {
+		asyncRows = 1000
+	}
+
+	POSTFILES(New(cfg.Group), cfg.Path+"/import", "file", func(c *gin.Context, files []*multipart.FileHeader) (any, error) {
+		a, err := cfg.access(c)
+		if err != nil {
+			return nil, err
+		}
+		rows, err := s.parse(files[0], c.Query("format"), maxRows)
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == 0 {
+			return nil, BadRequest("empty file")
+		}
+		dryRun, _ := strconv.ParseBool(c.Query("dryRun"))
+		async, _ := strconv.ParseBool(c.Query("async"))
+		if !async && len(rows) <= asyncRows {
+			return s.run(c, a, rows, dryRun, nil)
+		}
+
+		task := startImportTask(auth.UserID(c), cfg.Path, len(rows), dryRun)
+		// 后台任务不能复用请求的 Context：拷一份并去掉请求结束时的取消
+		cc := c.Copy()
+		cc.Request = c.Request.WithContext(context.WithoutCancel(c.Request.Context()))
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					task.abort(fmt.Errorf("import panicked: %v", r))
+				}
+			}()
+			rep, err := s.run(cc, a, rows, dryRun, task.progress)
+			task.finish(rep, err)
+		}()
+		return task.snapshot(), nil
+	})
+
+	cfg.Group.GET(cfg.Path+"/import/:task", func(c *gin.Context) {
+		uid := auth.UserID(c)
+		if uid == "" {
+			resp.JSON(c, resp.Error(resp.CodeUnauthorized, "unauthorized"))
+			return
+		}
+		t := findImportTask(c.Param("task"))
+		if t == nil || t.owner != uid || t.path != cfg.Path {
+			resp.JSON(c, resp.Error(resp.CodeNotFound, "import task not found"))
+			return
+		}
+		resp.JSON(c, resp.OK(t.snapshot()))
+	})
+}
+
+// run 分批执行导入；progress 可选，每批结束后回调
+func (s importSpec[T]) run(c *gin.Context, a access, rows []importRow, dryRun bool, progress func(done int, rep ImportReport)) (ImportReport, error) {
+	size := s.cfg.ImportBatchSize
+	if size <= 0 {
+		size = 500
+	}
+	rep := ImportReport{Total: len(rows), DryRun: dryRun, Errors: []ImportRowError{}}
+	after := s.after
+	if dryRun {
+		after = func(*gin.Context, *gorm.DB, *T) error { return nil }
+	}
+
+	chunk := func(tx *gorm.DB, part []importRow) error {
+		var items []json.RawMessage
+		var lines []int
+		for _, r := range part {
+			if r.err != nil {
+				rep.fail(r.line, r.err)
+				continue
+			}
+			items = append(items, r.raw)
+			lines = append(lines, r.line)
+		}
+		results, err := s.batch.create(c, tx, a, items, false, s.prepare, after)
+		if err != nil {
+			return err
+		}
+		for _, r := range results {
+			if r.OK {
+				rep.Succeeded++
+			} else {
+				rep.fail(lines[r.Index], &AErr{Code: r.Code, Msg: r.Msg, Data: validationData{Errors: r.Errors}})
+			}
+		}
+		return nil
+	}
+	each := func(fn func(part []importRow) error) error {
+		for start := 0; start < len(rows); start += size {
+			end := min(start+size, len(rows))
+			if err := fn(rows[start:end]); err != nil {
+				return err
+			}
+			if progress != nil {
+				progress(end, rep)
+			}
+		}
+		return nil
+	}
+
+	db := s.cfg.DB.WithContext(c)
+	var err error
+	if dryRun {
+		// 整份文件一个事务，文件内的重复也能校验出来，最后统一回滚
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := each(func(part []importRow) error { return chunk(tx, part) }); err != nil {
+				return err
+			}
+			return errDryRun
+		})
+		if errors.Is(err, errDryRun) {
+			err = nil
+		}
+	} else {
+		err = each(func(part []importRow) error {
+			return db.Transaction(func(tx *gorm.DB) error { return chunk(tx, part) })
+		})
+	}
+	if err != nil {
+		// 已提交的批次不会回滚，报告里的计数即已处理部分
+		return rep, Internal(fmt.Sprintf("import aborted after %d succeeded rows", rep.Succeeded), err)
+	}
+	return rep, nil
+}
+
+/* ---------- 文件解析 ---------- */
+
+func importFormat(fh *multipart.FileHeader, format string) (string, error) {
+	f := strings.ToLower(strings.TrimSpace(format))
+	if f == "" {
+		switch strings.ToLower(filepath.Ext(fh.Filename)) {
+		case ".csv":
+			f = "csv"
+		case ".ndjson", ".jsonl":
+			f = "ndjson"
+		default:
+			ct := fh.Header.Get("Content-Type")
+			if strings.Contains(ct, "csv") {
+				f = "csv"
+			} else if strings.Contains(ct, "ndjson") || strings.Contains(ct, "jsonl") {
+				f = "ndjson"
+			}
+		}
+	}
+	if f != "csv" && f != "ndjson" {
+		return "", BadRequest("unsupported import format, expect csv or ndjson")
+	}
+	return f, nil
+}
+
+func (s importSpec[T]) parse(fh *multipart.FileHeader, format string, maxRows int) ([]importRow, error) {
+	f, err := importFormat(fh, format)
+	if err != nil {
+		return nil, err
+	}
+	file, err := fh.Open()
+	if err != nil {
+		return nil, BadRequest("open upload failed: " + err.Error())
+	}
+	defer file.Close()
+	if f == "ndjson" {
+		return parseNDJSON(file, maxRows)
+	}
+	return s.parseCSV(file, maxRows)
+}
+
+func (s importSpec[T]) parseCSV(r io.Reader, maxRows int) ([]importRow, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, BadRequest("invalid csv header: " + err.Error())
+	}
+	// Excel 导出的 CSV 带 UTF-8 BOM
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	cols := make([]*schema.Field, len(header))
+	for i, h := range header {
+		if strings.TrimSpace(h) == "" {
+			continue
+		}
+		if cols[i] = lookupField(s.sch, h); cols[i] == nil {
+			return nil, BadRequest(fmt.Sprintf("unknown column %q", strings.TrimSpace(h)))
+		}
+	}
+
+	var rows []importRow
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil && !errors.Is(err, csv.ErrFieldCount) {
+			return nil, BadRequest("invalid csv: " + err.Error())
+		}
+		line, _ := cr.FieldPos(0)
+		if len(rows) >= maxRows {
+			return nil, BadRequest(fmt.Sprintf("too many rows (max %d)", maxRows))
+		}
+		row := importRow{line: line}
+		if err != nil {
+			row.err = BadRequest(fmt.Sprintf("expected %d columns, got %d", len(header), len(rec)))
+			rows = append(rows, row)
+			continue
+		}
+		doc := map[string]any{}
+		for i, cell := range rec {
+			if cols[i] == nil || cell == "" {
+				continue
+			}
+			v, err := convertQueryValue(cols[i].FieldType, cell)
+			if err != nil {
+				row.err = BadRequest(fmt.Sprintf("column %q: invalid value %q", header[i], cell))
+				break
+			}
+			doc[jsonName(cols[i])] = v
+		}
+		if row.err == nil {
+			row.raw, row.err = json.Marshal(doc)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func parseNDJSON(r io.Reader, maxRows int) ([]importRow, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	var rows []importRow
+	line := 0
+	for sc.Scan() {
+		line++
+		b := bytes.TrimSpace(sc.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+		if len(rows) >= maxRows {
+			return nil, BadRequest(fmt.Sprintf("too many rows (max %d)", maxRows))
+		}
+		row := importRow{line: line}
+		if !json.Valid(b) || b[0] != '{' {
+			row.err = BadRequest("invalid json object")
+		} else {
+			row.raw = append(json.RawMessage(nil), b...)
+		}
+		rows = append(rows, row)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, BadRequest(fmt.Sprintf("invalid ndjson at line %d: %v", line+1, err))
+	}
+	return rows, nil
+}
+
+/* ---------- 后台任务 ---------- */
+
+// 已结束的任务保留多久
+const importTaskTTL = 24 * time.Hour
+
+type ImportTask struct {
+	ID         string       `json:"id"`
+	Status     string       `json:"status"` // running | done | failed
+	Processed  int          `json:"processed"`
+	Report     ImportReport `json:"report"`
+	Error      string       `json:"error,omitempty"`
+	CreatedAt  time.Time    `json:"createdAt"`
+	FinishedAt *time.Time   `json:"finishedAt,omitempty"`
+
+	mu    sync.Mutex
+	owner string
+	path  string
+}
+
+var (
+	importMu    sync.Mutex
+	importTasks = map[string]*ImportTask{}
+)
+
+func startImportTask(owner, path string, total int, dryRun bool) *ImportTask {
+	now := time.Now()
+	t := &ImportTask{
+		ID: utils.NewID(), Status: "running", CreatedAt: now,
+		Report: ImportReport{Total: total, DryRun: dryRun, Errors: []ImportRowError{}},
+		owner:  owner, path: path,
+	}
+	importMu.Lock()
+	defer importMu.Unlock()
+	for id, old := range importTasks {
+		old.mu.Lock()
+		expired := old.FinishedAt != nil && now.Sub(*old.FinishedAt) > importTaskTTL
+		old.mu.Unlock()
+		if expired {
+			delete(importTasks, id)
+		}
+	}
+	importTasks[t.ID] = t
+	return t
+}
+
+func findImportTask(id string) *ImportTask {
+	importMu.Lock()
+	defer importMu.Unlock()
+	return importTasks[id]
+}
+
+func (t *ImportTask) progress(done int, rep ImportReport) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Processed, t.Report = done, rep
+}
+
+func (t *ImportTask) finish(rep ImportReport, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	t.Report, t.FinishedAt = rep, &now
+	t.Processed = rep.Succeeded + rep.Failed
+	t.Status = "done"
+	if err != nil {
+		t.Status, t.Error = "failed", err.Error()
+	}
+}
+
+// abort 任务异常中止（panic）：保留已有进度，标记 failed
+func (t *ImportTask) abort(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	t.FinishedAt = &now
+	t.Status, t.Error = "failed", err.Error()
+}
+
+// snapshot 供序列化的只读副本（Errors 只追加，共享底层数组是安全的）
+func (t *ImportTask) snapshot() *ImportTask {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return &ImportTask{
+		ID: t.ID, Status: t.Status, Processed: t.Processed, Report: t.Report,
+		Error: t.Error, CreatedAt: t.CreatedAt, FinishedAt: t.FinishedAt,
+	}
+}
@@ -0,0 +1,159 @@
+package ez_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	httpez "go-gin-gorm-starter/internal/transport/http/ez"
+)
+
+type importItem struct {
+	ID      int64  `gorm:"primaryKey" json:"id"`
+	OwnerID string `gorm:"size:36;index" json:"ownerId"`
+	Name    string `gorm:"uniqueIndex" json:"name" binding:"required"`
+	Qty     int    `json:"qty"`
+}
+
+// upload 以 multipart 上传 name 文件
+func upload(t *testing.T, h http.Handler, path string, hdr http.Header, name, content string) (*httptest.ResponseRecorder, envelope) {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, _ := mw.CreateFormFile("file", name)
+	_, _ = fw.Write([]byte(content))
+	_ = mw.Close()
+	req := httptest.NewRequest(http.MethodPost, path, &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	for k, vs := range hdr {
+		req.Header[k] = vs
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	var e envelope
+	if err := json.Unmarshal(w.Body.Bytes(), &e); err != nil {
+		t.Fatalf("POST %s: bad body %q", path, w.Body.String())
+	}
+	return w, e
+}
+
+type importReport struct {
+	Total, Succeeded, Failed int
+	DryRun                   bool
+	Errors                   []struct{ Line, Code int }
+}
+
+func importEngine(t *testing.T, hooks httpez.CrudHooks[importItem]) (*gin.Engine, *gorm.DB) {
+	t.Helper()
+	db := newDB(t)
+	r, g := newEngine(t)
+	httpez.Crud(httpez.CrudConfig[importItem]{
+		DB: db, Group: g, Path: "/imp-items", New: func() *importItem { return &importItem{} },
+		AllowImport: true, ImportAsyncRows: 3, ImportBatchSize: 2, Hooks: hooks,
+	})
+	return r, db
+}
+
+func TestImportReportsRowErrors(t *testing.T) {
+	var after atomic.Int32
+	r, db := importEngine(t, httpez.CrudHooks[importItem]{
+		AfterCreate: func(*gin.Context, *gorm.DB, *importItem) error { after.Add(1); return nil },
+	})
+	alice := as("alice")
+	csv := "name,qty\na,1\n,2\na,x\n"
+
+	// dryRun：只校验，不落库，不调 AfterCreate
+	w, res := upload(t, r, "/api/imp-items/import?dryRun=true", alice, "items.csv", csv)
+	if w.Code != http.StatusOK {
+		t.Fatalf("dry run: %d %s", w.Code, w.Body)
+	}
+	if rep := decode[importReport](t, res); !rep.DryRun || rep.Succeeded != 1 || rep.Failed != 2 {
+		t.Fatalf("dry run report %+v", rep)
+	}
+	if n := count[importItem](t, db); n != 0 || after.Load() != 0 {
+		t.Fatalf("dry run wrote %d rows, ran %d after-hooks", n, after.Load())
+	}
+
+	_, res = upload(t, r, "/api/imp-items/import", alice, "items.csv", csv)
+	rep := decode[importReport](t, res)
+	if rep.Succeeded != 1 || rep.Failed != 2 || len(rep.Errors) != 2 || rep.Errors[0].Line != 3 || rep.Errors[1].Line != 4 {
+		t.Fatalf("report %+v", rep)
+	}
+	if n := count[importItem](t, db); n != 1 || after.Load() != 1 {
+		t.Fatalf("%d rows, %d after-hooks, want 1 and 1", n, after.Load())
+	}
+	if w, _ := upload(t, r, "/api/imp-items/import", alice, "items.csv", "nope\nx\n"); w.Code != http.StatusBadRequest {
+		t.Fatalf("unknown column: %d, want 400", w.Code)
+	}
+}
+
+type importTask struct {
+	ID     string
+	Status string
+	Error  string
+	Report importReport
+}
+
+func waitTask(t *testing.T, r http.Handler, hdr http.Header, id string) importTask {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		w, res := call(t, r, http.MethodGet, "/api/imp-items/import/"+id, hdr, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("task: %d %s", w.Code, w.Body)
+		}
+		if task := decode[importTask](t, res); task.Status != "running" {
+			return task
+		}
+	}
+	t.Fatal("task still running")
+	return importTask{}
+}
+
+func TestImportBackgroundTask(t *testing.T) {
+	r, db := importEngine(t, httpez.CrudHooks[importItem]{
+		AfterCreate: func(_ *gin.Context, _ *gorm.DB, m *importItem) error {
+			if m.Name == "boom" {
+				panic("hook exploded")
+			}
+			return nil
+		},
+	})
+	alice := as("alice")
+
+	_, res := upload(t, r, "/api/imp-items/import", alice, "items.ndjson", `{"name":"a"}
+{"name":"b"}
+{"name":"c"}
+{"name":"d"}
+`)
+	task := decode[importTask](t, res)
+	if task.ID == "" {
+		t.Fatalf("not moved to background: %s", res.Data)
+	}
+	if w, _ := call(t, r, http.MethodGet, "/api/imp-items/import/"+task.ID, as("bob"), nil); w.Code != http.StatusNotFound {
+		t.Fatalf("other user sees task: %d", w.Code)
+	}
+	if done := waitTask(t, r, alice, task.ID); done.Status != "done" || done.Report.Succeeded != 4 {
+		t.Fatalf("task %+v", done)
+	}
+
+	// 后台任务 panic：进程不崩，任务标记 failed，已提交的批次保留
+	_, res = upload(t, r, "/api/imp-items/import?async=true", alice, "items.ndjson", `{"name":"e"}
+{"name":"f"}
+{"name":"boom"}
+`)
+	failed := waitTask(t, r, alice, decode[importTask](t, res).ID)
+	if failed.Status != "failed" || failed.Error == "" {
+		t.Fatalf("panicked task %+v", failed)
+	}
+	if n := count[importItem](t, db); n != 6 {
+		t.Fatalf("%d rows, want 6 (first batch of the failed task committed)", n)
+	}
+}
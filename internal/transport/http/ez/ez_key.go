@@ -0,0 +1,386 @@
+package ez
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+/* ================== 主键 / 归属字段：按字段类型读写 ==================
+   ID/Owner 字段可以是 string、整数、uuid.UUID（TextUnmarshaler）或实现 sql.Scanner 的自定义类型
+   路由参数、当前用户 ID 都是字符串，写入时按字段类型解析，非法值 400
+   复合主键：IDFields: []string{"TenantID", "Code"} → /path/:tenantId/:code
+*/
+
+// 按候选名找字段（含嵌入结构体提升的字段，如 gorm.Model.ID），候选名靠前的优先
+func fieldByNames(obj any, candidates []string) (reflect.Value, bool) {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr {
+		return reflect.Value{}, false
+	}
+	v = v.Elem()
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+	for _, name := range candidates {
+		sf, ok := v.Type().FieldByName(name)
+		if !ok || !sf.IsExported() {
+			continue
+		}
+		if fv, err := v.FieldByIndexErr(sf.Index); err == nil && fv.CanSet() {
+			return fv, true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// readField 读出字段的字符串形式，零值为 ""
+func readField(obj any, candidates []string) (string, bool) {
+	fv, ok := fieldByNames(obj, candidates)
+	if !ok {
+		return "", false
+	}
+	return formatField(fv), true
+}
+
+// writeField 按字段类型解析 raw 并写入；raw 为空写零值
+func writeField(obj any, candidates []string, raw string) (bool, error) {
+	fv, ok := fieldByNames(obj, candidates)
+	if !ok {
+		return false, nil
+	}
+	if raw == "" {
+		fv.Set(reflect.Zero(fv.Type()))
+		return true, nil
+	}
+	v, err := parseFieldValue(fv.Type(), raw)
+	if err != nil {
+		return true, err
+	}
+	fv.Set(v)
+	return true, nil
+}
+
+func formatField(fv reflect.Value) string {
+	if fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			return ""
+		}
+		fv = fv.Elem()
+	}
+	if fv.IsZero() {
+		return ""
+	}
+	if m, ok := fv.Interface().(encoding.TextMarshaler); ok {
+		if b, err := m.MarshalText(); err == nil {
+			return string(b)
+		}
+	}
+	if v, ok := fv.Interface().(driver.Valuer); ok {
+		if dv, err := v.Value(); err == nil && dv != nil {
+			if b, ok := dv.([]byte); ok {
+				return string(b)
+			}
+			return fmt.Sprint(dv)
+		}
+	}
+	return fmt.Sprint(fv.Interface())
+}
+
+func parseFieldValue(t reflect.Type, raw string) (reflect.Value, error) {
+	if t.Kind() == reflect.Ptr {
+		v, err := parseFieldValue(t.Elem(), raw)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		p := reflect.New(t.Elem())
+		p.Elem().Set(v)
+		return p, nil
+	}
+	p := reflect.New(t)
+	switch u := p.Interface().(type) {
+	case encoding.TextUnmarshaler:
+		if err := u.UnmarshalText([]byte(raw)); err != nil {
+			return reflect.Value{}, err
+		}
+		return p.Elem(), nil
+	case sql.Scanner:
+		if err := u.Scan(raw); err != nil {
+			return reflect.Value{}, err
+		}
+		return p.Elem(), nil
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return reflect.ValueOf(raw).Convert(t), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, t.Bits())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		p.Elem().SetInt(n)
+		return p.Elem(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, t.Bits())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		p.Elem().SetUint(n)
+		return p.Elem(), nil
+	}
+	return reflect.Value{}, fmt.Errorf("unsupported key type %s", t)
+}
+
+func isIntegerKind(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Uint64
+}
+
+/* ---------- 主键 ---------- */
+
+// 主键值（字符串形式，与 keySpec 的列一一对应）
+type pk []string
+
+func (k pk) String() string { return strings.Join(k, ",") }
+
+type keySpec struct {
+	names  [][]string // 每列的候选字段名
+	params []string   // 对应的路由参数名
+	model  func() any
+}
+
+func (c *CrudConfig[T]) keys() keySpec {
+	k := keySpec{model: func() any { return c.New() }}
+	if len(c.IDFields) == 0 {
+		k.names = [][]string{c.idFieldCandidates()}
+		k.params = []string{c.idParam()}
+		return k
+	}
+	rt := reflect.TypeOf(c.New()).Elem()
+	for i, name := range c.IDFields {
+		sf, ok := rt.FieldByName(name)
+		if !ok {
+			panic(fmt.Sprintf("ez: id field %q not found on %s", name, rt.Name()))
+		}
+		param := jsonTagName(sf)
+		if i < len(c.IDParams) {
+			param = c.IDParams[i]
+		}
+		k.names = append(k.names, []string{name})
+		k.params = append(k.params, param)
+	}
+	return k
+}
+
+func jsonTagName(sf reflect.StructField) string {
+	if name, _, _ := strings.Cut(sf.Tag.Get("json"), ","); name != "" && name != "-" {
+		return name
+	}
+	return sf.Name
+}
+
+// 实际命中的字段名（每列一个），用于 schema 查找
+func (k keySpec) fieldNames() []string {
+	m := k.model()
+	rv := reflect.ValueOf(m).Elem()
+	var out []string
+	for _, cands := range k.names {
+		for _, n := range cands {
+			if sf, ok := rv.Type().FieldByName(n); ok && sf.IsExported() {
+				out = append(out, n)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func (k keySpec) composite() bool { return len(k.names) > 1 }
+
+// 路由片段：/:id 或 /:tenantId/:code
+func (k keySpec) route() string {
+	var b strings.Builder
+	for _, p := range k.params {
+		b.WriteString("/:" + p)
+	}
+	return b.String()
+}
+
+func (k keySpec) fromParams(c interface{ Param(string) string }) pk {
+	out := make(pk, len(k.params))
+	for i, p := range k.params {
+		out[i] = c.Param(p)
+	}
+	return out
+}
+
+// write 写入主键；nil 时不写，非 nil 时每列都必须有值
+func (k keySpec) write(m any, id pk) error {
+	if id == nil {
+		return nil
+	}
+	if len(id) != len(k.names) {
+		return BadRequest("invalid id")
+	}
+	for i, cands := range k.names {
+		if strings.TrimSpace(id[i]) == "" {
+			return BadRequest("missing id")
+		}
+		found, err := writeField(m, cands, id[i])
+		if !found {
+			return BadRequest("id field not found")
+		}
+		if err != nil {
+			return BadRequest(fmt.Sprintf("invalid %s %q", k.params[i], id[i]))
+		}
+	}
+	return nil
+}
+
+func (k keySpec) read(m any) pk {
+	out := make(pk, len(k.names))
+	for i, cands := range k.names {
+		out[i], _ = readField(m, cands)
+	}
+	return out
+}
+
+// out 主键的响应形式：单列为字段本身的类型（数字 ID 输出数字），复合为 {param: value}
+func (k keySpec) out(id pk) any {
+	m := k.model()
+	if k.write(m, id) != nil {
+		return id.String()
+	}
+	vals := map[string]any{}
+	for i, cands := range k.names {
+		fv, _ := fieldByNames(m, cands)
+		if !k.composite() {
+			return fv.Interface()
+		}
+		vals[k.params[i]] = fv.Interface()
+	}
+	return vals
+}
+
+// outOf 取模型上的主键（响应形式），主键未赋值时为 nil
+func (k keySpec) outOf(m any) any {
+	id := k.read(m)
+	for _, v := range id {
+		if v == "" {
+			return nil
+		}
+	}
+	return k.out(id)
+}
+
+/* ---------- 按主键 / 归属过滤 ---------- */
+
+// rowFilter 逐列等值条件。不用结构体 Where：gorm 会跳过零值字段，
+// id=0、uuid.Nil 时主键条件整个消失，变成只按归属过滤（删除 / 更新会命中一片）
+type rowFilter []clause.Expression
+
+func (f rowFilter) apply(q *gorm.DB) *gorm.DB {
+	for _, e := range f {
+		q = q.Where(e)
+	}
+	return q
+}
+
+// fieldEq 按字段类型解析 raw，生成 列 = 值；found=false 表示模型上没有候选字段
+func fieldEq(sch *schema.Schema, obj any, candidates []string, raw string) (e clause.Expression, found bool, err error) {
+	rt := reflect.TypeOf(obj).Elem()
+	for _, name := range candidates {
+		sf, ok := rt.FieldByName(name)
+		if !ok || !sf.IsExported() {
+			continue
+		}
+		f := sch.LookUpField(name)
+		if f == nil || f.DBName == "" {
+			return nil, true, fmt.Errorf("field %s has no column", name)
+		}
+		v, err := parseFieldValue(sf.Type, raw)
+		if err != nil {
+			return nil, true, err
+		}
+		return clause.Eq{Column: column(f), Value: v.Interface()}, true, nil
+	}
+	return nil, false, nil
+}
+
+// filter 主键条件：每列都必须有值，与字段类型不符 400
+func (k keySpec) filter(sch *schema.Schema, id pk) (rowFilter, error) {
+	if len(id) != len(k.names) {
+		return nil, BadRequest("invalid id")
+	}
+	m := k.model()
+	f := make(rowFilter, 0, len(id))
+	for i, cands := range k.names {
+		if strings.TrimSpace(id[i]) == "" {
+			return nil, BadRequest("missing id")
+		}
+		e, found, err := fieldEq(sch, m, cands, id[i])
+		if !found {
+			return nil, BadRequest("id field not found")
+		}
+		if err != nil {
+			return nil, BadRequest(fmt.Sprintf("invalid %s %q", k.params[i], id[i]))
+		}
+		f = append(f, e)
+	}
+	return f, nil
+}
+
+// fromJSON 批量接口里的 id：字符串/数字；复合主键为 {param: value} 或 [v1, v2]
+func (k keySpec) fromJSON(raw json.RawMessage) (pk, error) {
+	raw = bytes.TrimSpace(raw)
+	scalar := func(b []byte) (string, bool) {
+		var s string
+		if json.Unmarshal(b, &s) == nil {
+			return s, true
+		}
+		var n json.Number
+		if json.Unmarshal(b, &n) == nil {
+			return n.String(), true
+		}
+		return "", false
+	}
+	if !k.composite() {
+		if s, ok := scalar(raw); ok {
+			return pk{s}, nil
+		}
+		return nil, BadRequest("invalid id")
+	}
+	id := make(pk, len(k.params))
+	var arr []json.RawMessage
+	var obj map[string]json.RawMessage
+	switch {
+	case json.Unmarshal(raw, &arr) == nil && len(arr) == len(k.params):
+		for i, v := range arr {
+			s, ok := scalar(v)
+			if !ok {
+				return nil, BadRequest("invalid id")
+			}
+			id[i] = s
+		}
+	case json.Unmarshal(raw, &obj) == nil:
+		for i, p := range k.params {
+			s, ok := scalar(obj[p])
+			if !ok {
+				return nil, BadRequest(fmt.Sprintf("missing %s", p))
+			}
+			id[i] = s
+		}
+	default:
+		return nil, BadRequest("invalid id")
+	}
+	return id, nil
+}
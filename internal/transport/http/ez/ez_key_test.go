@@ -0,0 +1,164 @@
+package ez_test
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/google/uuid"
+
+	httpez "go-gin-gorm-starter/internal/transport/http/ez"
+)
+
+type intItem struct {
+	ID      int64  `gorm:"primaryKey" json:"id"`
+	OwnerID string `gorm:"size:36;index" json:"ownerId"`
+	Name    string `json:"name"`
+}
+
+type uuidItem struct {
+	ID      uuid.UUID `gorm:"type:varchar(36);primaryKey" json:"id"`
+	OwnerID string    `gorm:"size:36;index" json:"ownerId"`
+	Name    string    `json:"name"`
+}
+
+type tenantItem struct {
+	TenantID int64  `gorm:"primaryKey;autoIncrement:false" json:"tenantId"`
+	Code     string `gorm:"primaryKey;size:32" json:"code"`
+	OwnerID  string `gorm:"size:36;index" json:"ownerId"`
+	Name     string `json:"name"`
+}
+
+func TestIntegerKeyZeroIDMatchesNothing(t *testing.T) {
+	db := newDB(t)
+	r, g := newEngine(t)
+	httpez.Crud(httpez.CrudConfig[intItem]{
+		DB: db, Group: g, Path: "/items", New: func() *intItem { return &intItem{} },
+		AllowCreate: true, AllowGet: true, AllowUpdate: true, AllowPatch: true, AllowDelete: true, AllowBatch: true,
+	})
+	alice := as("alice")
+	var ids []int64
+	for _, name := range []string{"a", "b", "c"} {
+		w, res := call(t, r, http.MethodPost, "/api/items", alice, map[string]any{"name": name})
+		if w.Code != http.StatusOK {
+			t.Fatalf("create: %d %s", w.Code, w.Body)
+		}
+		ids = append(ids, decode[intItem](t, res).ID)
+	}
+	if ids[0] == 0 {
+		t.Fatalf("auto-increment id not returned")
+	}
+
+	if w, _ := call(t, r, http.MethodDelete, "/api/items/0", alice, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("DELETE /items/0: %d, want 404", w.Code)
+	}
+	if n := count[intItem](t, db); n != 3 {
+		t.Fatalf("DELETE /items/0 removed rows: %d left, want 3", n)
+	}
+
+	if w, _ := call(t, r, http.MethodPut, "/api/items/0", alice, map[string]any{"name": "hijacked"}); w.Code != http.StatusNotFound {
+		t.Fatalf("PUT /items/0: %d, want 404", w.Code)
+	}
+	if w, _ := call(t, r, http.MethodPatch, "/api/items/0", alice, map[string]any{"name": "hijacked"}); w.Code != http.StatusNotFound {
+		t.Fatalf("PATCH /items/0: %d, want 404", w.Code)
+	}
+	if w, _ := call(t, r, http.MethodGet, "/api/items/0", alice, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("GET /items/0: %d, want 404", w.Code)
+	}
+	var hijacked int64
+	db.Model(&intItem{}).Where("name = ?", "hijacked").Count(&hijacked)
+	if hijacked != 0 {
+		t.Fatalf("PUT/PATCH /items/0 updated %d rows", hijacked)
+	}
+
+	// 批量：id 0（数字或字符串）都只会失败，不会扩大范围
+	w, _ := call(t, r, http.MethodPost, "/api/items:batchDelete", alice, map[string]any{"ids": []any{0, "0"}, "allOrNothing": false})
+	if w.Code != http.StatusOK {
+		t.Fatalf("batchDelete: %d %s", w.Code, w.Body)
+	}
+	if n := count[intItem](t, db); n != 3 {
+		t.Fatalf("batchDelete [0] removed rows: %d left, want 3", n)
+	}
+	call(t, r, http.MethodPost, "/api/items:batchUpdate", alice, map[string]any{"items": []any{map[string]any{"id": 0, "name": "hijacked"}}, "allOrNothing": false})
+	db.Model(&intItem{}).Where("name = ?", "hijacked").Count(&hijacked)
+	if hijacked != 0 {
+		t.Fatalf("batchUpdate id 0 updated %d rows", hijacked)
+	}
+
+	// 正常 id 照常可用，且只影响一行
+	if w, _ := call(t, r, http.MethodDelete, "/api/items/"+strconv.FormatInt(ids[1], 10), alice, nil); w.Code != http.StatusOK {
+		t.Fatalf("DELETE existing: %d", w.Code)
+	}
+	if n := count[intItem](t, db); n != 2 {
+		t.Fatalf("%d rows left, want 2", n)
+	}
+	// 非数字 id 400
+	if w, _ := call(t, r, http.MethodGet, "/api/items/abc", alice, nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("GET /items/abc: %d, want 400", w.Code)
+	}
+	// 别人的记录不可见
+	if w, _ := call(t, r, http.MethodDelete, "/api/items/"+strconv.FormatInt(ids[0], 10), as("bob"), nil); w.Code != http.StatusNotFound {
+		t.Fatalf("DELETE as other user: %d, want 404", w.Code)
+	}
+}
+
+func TestUUIDKeyNilMatchesNothing(t *testing.T) {
+	db := newDB(t)
+	r, g := newEngine(t)
+	httpez.Crud(httpez.CrudConfig[uuidItem]{
+		DB: db, Group: g, Path: "/items", New: func() *uuidItem { return &uuidItem{} },
+		AllowCreate: true, AllowGet: true, AllowDelete: true,
+	})
+	alice := as("alice")
+	w, res := call(t, r, http.MethodPost, "/api/items", alice, map[string]any{"name": "a"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("create: %d %s", w.Code, w.Body)
+	}
+	created := decode[uuidItem](t, res)
+	if created.ID == uuid.Nil {
+		t.Fatalf("uuid not generated")
+	}
+	if w, _ := call(t, r, http.MethodDelete, "/api/items/"+uuid.Nil.String(), alice, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("DELETE nil uuid: %d, want 404", w.Code)
+	}
+	if n := count[uuidItem](t, db); n != 1 {
+		t.Fatalf("DELETE nil uuid removed rows: %d left", n)
+	}
+	if w, _ := call(t, r, http.MethodGet, "/api/items/"+created.ID.String(), alice, nil); w.Code != http.StatusOK {
+		t.Fatalf("GET by uuid: %d", w.Code)
+	}
+}
+
+func TestCompositeKey(t *testing.T) {
+	db := newDB(t)
+	r, g := newEngine(t)
+	httpez.Crud(httpez.CrudConfig[tenantItem]{
+		DB: db, Group: g, Path: "/items", New: func() *tenantItem { return &tenantItem{} },
+		IDFields:    []string{"TenantID", "Code"},
+		AllowCreate: true, AllowGet: true, AllowDelete: true, AllowBatch: true,
+	})
+	alice := as("alice")
+	for _, it := range []map[string]any{{"tenantId": 1, "code": "a"}, {"tenantId": 1, "code": "b"}, {"tenantId": 2, "code": "a"}} {
+		if w, _ := call(t, r, http.MethodPost, "/api/items", alice, it); w.Code != http.StatusOK {
+			t.Fatalf("create %v: %d %s", it, w.Code, w.Body)
+		}
+	}
+	w, res := call(t, r, http.MethodGet, "/api/items/1/b", alice, nil)
+	if w.Code != http.StatusOK || decode[tenantItem](t, res).Code != "b" {
+		t.Fatalf("GET /items/1/b: %d %s", w.Code, w.Body)
+	}
+	// 只给了一列为 0：不能退化成按另一列删一片
+	if w, _ := call(t, r, http.MethodDelete, "/api/items/0/a", alice, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("DELETE /items/0/a: %d, want 404", w.Code)
+	}
+	if n := count[tenantItem](t, db); n != 3 {
+		t.Fatalf("%d rows left, want 3", n)
+	}
+	w, res = call(t, r, http.MethodPost, "/api/items:batchDelete", alice, map[string]any{"ids": []any{map[string]any{"tenantId": 1, "code": "a"}, []any{2, "a"}}})
+	if w.Code != http.StatusOK || decode[struct{ Succeeded int }](t, res).Succeeded != 2 {
+		t.Fatalf("batchDelete composite: %d %s", w.Code, w.Body)
+	}
+	if n := count[tenantItem](t, db); n != 1 {
+		t.Fatalf("%d rows left, want 1", n)
+	}
+}
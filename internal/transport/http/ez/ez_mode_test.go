@@ -0,0 +1,76 @@
+package ez_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	httpez "go-gin-gorm-starter/internal/transport/http/ez"
+	resp "go-gin-gorm-starter/internal/transport/http/response"
+)
+
+type modeNote struct {
+	ID      int64  `gorm:"primaryKey" json:"id"`
+	OwnerID string `gorm:"size:36;index" json:"ownerId"`
+	Title   string `json:"title" binding:"required"`
+}
+
+func TestResponseModes(t *testing.T) {
+	db := newDB(t)
+	alice := as("alice")
+	type want struct {
+		status int
+		code   int
+	}
+	cases := []struct {
+		name         string
+		method, path string
+		hdr          http.Header
+		body         any
+		want         want
+	}{
+		{"ok", http.MethodPost, "/api/notes", alice, map[string]any{"title": "a"}, want{http.StatusOK, resp.CodeOK}},
+		{"not found", http.MethodGet, "/api/notes/999", alice, nil, want{http.StatusNotFound, resp.CodeNotFound}},
+		{"invalid", http.MethodPost, "/api/notes", alice, map[string]any{}, want{http.StatusBadRequest, resp.CodeBadRequest}},
+		{"anonymous", http.MethodGet, "/api/notes", nil, nil, want{http.StatusUnauthorized, resp.CodeUnauthorized}},
+	}
+	for _, mode := range []resp.Mode{resp.ModeLegacy, resp.ModeStatus, resp.ModeProblem} {
+		r, g := newModeEngine(t, mode)
+		httpez.Crud(httpez.CrudConfig[modeNote]{
+			DB: db, Group: g, Path: "/notes", New: func() *modeNote { return &modeNote{} },
+		})
+		for _, tc := range cases {
+			w, res := call(t, r, tc.method, tc.path, tc.hdr, tc.body)
+			status := tc.want.status
+			if mode == resp.ModeLegacy {
+				status = http.StatusOK // 老客户端：HTTP 一律 200，只看 body.code
+			}
+			if w.Code != status {
+				t.Errorf("mode %d %s: HTTP %d, want %d", mode, tc.name, w.Code, status)
+				continue
+			}
+			if mode != resp.ModeProblem || tc.want.code == resp.CodeOK {
+				if res.Code != tc.want.code {
+					t.Errorf("mode %d %s: code %d, want %d", mode, tc.name, res.Code, tc.want.code)
+				}
+				continue
+			}
+			// problem 模式：出错时为 RFC 7807 文档，业务码作为扩展成员
+			if ct := w.Header().Get("Content-Type"); ct != resp.MIMEProblem {
+				t.Errorf("%s: content type %q", tc.name, ct)
+				continue
+			}
+			var p resp.Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+				t.Fatalf("%s: %v", tc.name, err)
+			}
+			if p.Status != tc.want.status || p.Code != tc.want.code || p.Title != http.StatusText(tc.want.status) ||
+				p.Instance != tc.path || p.Detail == "" {
+				t.Errorf("%s: problem %+v", tc.name, p)
+			}
+			if tc.name == "invalid" && p.Data == nil {
+				t.Errorf("validation problem lost field errors: %s", w.Body)
+			}
+		}
+	}
+}
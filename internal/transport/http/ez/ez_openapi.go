@@ -0,0 +1,956 @@
+package ez
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	resp "go-gin-gorm-starter/internal/transport/http/response"
+)
+
+/* ================== OpenAPI 3.1：由注册信息自动生成 ==================
+   RegisterAction / Crud 注册时记录路由与 I/O/T 类型，MountOpenAPI 按引擎实际挂载的路由生成文档：
+     GET /openapi.json   文档
+     GET /docs           Redoc 页面
+   类型反射规则：json 名 / omitempty，binding（required、email、min/max、oneof…）转约束，
+   BindQuery / BindHeader 入参按 form / header tag 生成参数，BindForm / BindMultipart 生成表单请求体；
+   Crud 模型的 ez:"-" 字段不出现在文档里，主键 / 父 ID 路由参数按字段类型（整数、uuid…）生成
+   错误响应跟随引擎的响应模式（resp.UseMode）：legacy 只有 HTTP 200 信封，status 另列错误信封（default），
+   problem 另列 application/problem+json 的 Problem 文档
+*/
+
+type OpenAPIInfo struct {
+	Title       string
+	Version     string
+	Description string
+	// 这些前缀下的路由统一需要 Bearer Token（分组已挂 AuthJWT、Action 上没写 Auth 的情况）
+	SecuredPrefixes []string
+}
+
+// 一条已注册的接口
+type docOp struct {
+	method  string
+	route   string // gin 实际挂载的路由（含分组前缀），用于与引擎的路由表比对
+	path    string // OpenAPI 路径（/users/{id}）
+	secured bool
+	build   func(g *schemaGen) map[string]any // operation object（security 由生成时补）
+}
+
+var (
+	docMu  sync.Mutex
+	docOps []docOp
+)
+
+// recordDoc 登记一条接口；mount 为实际挂载的路由（与 route 不同时传，如 /path:verb 分发的批量接口）
+func recordDoc(g *gin.RouterGroup, method, route, mount string, secured bool, build func(g *schemaGen) map[string]any) {
+	if mount == "" {
+		mount = route
+	}
+	docMu.Lock()
+	defer docMu.Unlock()
+	docOps = append(docOps, docOp{
+		method: strings.ToUpper(method), route: joinRoute(g.BasePath(), mount),
+		path: openAPIPath(joinRoute(g.BasePath(), route)), secured: secured, build: build,
+	})
+}
+
+func joinRoute(base, p string) string {
+	if p == "" {
+		return base
+	}
+	out := path.Join(base, p)
+	if strings.HasSuffix(p, "/") && !strings.HasSuffix(out, "/") {
+		out += "/"
+	}
+	return out
+}
+
+var routeParamRe = regexp.MustCompile(`/[:*]([^/]+)`)
+
+// /users/:id/ban → /users/{id}/ban
+func openAPIPath(route string) string {
+	return routeParamRe.ReplaceAllString(route, "/{$1}")
+}
+
+func routeParams(route string) []string {
+	var out []string
+	for _, m := range routeParamRe.FindAllStringSubmatch(route, -1) {
+		out = append(out, m[1])
+	}
+	return out
+}
+
+// 文档序列化（测试里替换以模拟失败）
+var marshalOpenAPI = json.Marshal
+
+// MountOpenAPI 在引擎上挂 /openapi.json 与 /docs；文档在首次请求时生成（此时路由已全部注册）
+// 错误响应按请求所在引擎的响应模式生成（UseMode 需挂在 MountOpenAPI 之前）
+// 生成失败时返回 500 并把错误记到 c.Errors（访问日志可见），不缓存，下次请求重试
+func MountOpenAPI(r *gin.Engine, info OpenAPIInfo) {
+	var mu sync.Mutex
+	var doc []byte
+	r.GET("/openapi.json", func(c *gin.Context) {
+		mu.Lock()
+		if doc == nil {
+			b, err := marshalOpenAPI(buildOpenAPI(r.Routes(), info, resp.ModeOf(c)))
+			if err != nil {
+				mu.Unlock()
+				_ = c.Error(err)
+				resp.JSON(c, resp.Error(resp.CodeServerError, "build openapi document failed"))
+				return
+			}
+			doc = b
+		}
+		mu.Unlock()
+		c.Data(http.StatusOK, "application/json; charset=utf-8", doc)
+	})
+	r.GET("/docs", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(fmt.Sprintf(redocPage, html.EscapeString(info.Title), redocVersion)))
+	})
+}
+
+// 固定 Redoc 版本，升级时改这里
+const redocVersion = "2.1.5"
+
+const redocPage = `<!doctype html>
+<html>
+<head>
+  <meta charset="utf-8"/>
+  <title>%[1]s</title>
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+</head>
+<body>
+  <redoc spec-url="openapi.json"></redoc>
+  <script src="https://cdn.jsdelivr.net/npm/redoc@%[2]s/bundles/redoc.standalone.js"></script>
+</body>
+</html>`
+
+func buildOpenAPI(routes gin.RoutesInfo, info OpenAPIInfo, mode resp.Mode) map[string]any {
+	mounted := map[string]bool{}
+	for _, rt := range routes {
+		mounted[rt.Method+" "+rt.Path] = true
+	}
+	docMu.Lock()
+	ops := append([]docOp(nil), docOps...)
+	docMu.Unlock()
+
+	g := newSchemaGen(mode)
+	paths := map[string]map[string]any{}
+	opIDs := map[string]int{}
+	for _, op := range ops {
+		if !mounted[op.method+" "+op.route] {
+			continue
+		}
+		item := paths[op.path]
+		if item == nil {
+			item = map[string]any{}
+			paths[op.path] = item
+		}
+		m := strings.ToLower(op.method)
+		if _, dup := item[m]; dup {
+			continue
+		}
+		o := op.build(g)
+		if id, ok := o["operationId"].(string); ok {
+			// 同一文档里 operationId 必须唯一
+			if n := opIDs[id]; n > 0 {
+				o["operationId"] = id + strconv.Itoa(n+1)
+			}
+			opIDs[id]++
+		}
+		if op.secured || hasAnyPrefix(op.route, info.SecuredPrefixes) {
+			o["security"] = []any{map[string]any{"bearerAuth": []any{}}}
+		}
+		item[m] = o
+	}
+
+	title, version := info.Title, info.Version
+	if title == "" {
+		title = "API"
+	}
+	if version == "" {
+		version = "1.0.0"
+	}
+	infoObj := map[string]any{"title": title, "version": version}
+	if info.Description != "" {
+		infoObj["description"] = info.Description
+	}
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info":    infoObj,
+		"paths":   paths,
+		"components": map[string]any{
+			"schemas": g.defs,
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+	}
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+/* ---------- operation 片段 ---------- */
+
+// 统一响应包装：{code, msg, data}
+func envelope(data map[string]any) map[string]any {
+	if data == nil {
+		data = map[string]any{"type": "object"}
+	}
+	return map[string]any{
+		"type":     "object",
+		"required": []string{"code", "msg", "data"},
+		"properties": map[string]any{
+			"code": map[string]any{"type": "integer", "description": "业务码，0 为成功"},
+			"msg":  map[string]any{"type": "string"},
+			"data": data,
+		},
+	}
+}
+
+// 成功响应 + 按响应模式的错误响应
+func (g *schemaGen) okResponse(data map[string]any) map[string]any {
+	if g.mode == resp.ModeLegacy {
+		return map[string]any{"200": map[string]any{
+			"description": "统一响应（HTTP 200，业务结果见 code）",
+			"content":     map[string]any{"application/json": map[string]any{"schema": envelope(data)}},
+		}}
+	}
+	return g.withErrors(map[string]any{"200": map[string]any{
+		"description": "成功（code 为 0）",
+		"content":     map[string]any{"application/json": map[string]any{"schema": envelope(data)}},
+	}})
+}
+
+// withErrors 补上错误响应：status 模式为错误信封，problem 模式为 RFC 7807 文档；legacy 模式错误也走 200，不另列
+func (g *schemaGen) withErrors(responses map[string]any) map[string]any {
+	switch g.mode {
+	case resp.ModeStatus:
+		responses["default"] = map[string]any{
+			"description": "错误（HTTP 状态码跟随业务码，code 为业务码）",
+			"content": map[string]any{"application/json": map[string]any{
+				"schema": g.ref(reflect.TypeOf(resp.Resp{}), func() map[string]any { return envelope(map[string]any{}) }),
+			}},
+		}
+	case resp.ModeProblem:
+		responses["default"] = map[string]any{
+			"description": "错误（RFC 7807，code 为业务码，data 为附加信息如字段错误）",
+			"content":     map[string]any{resp.MIMEProblem: map[string]any{"schema": g.of(reflect.TypeOf(resp.Problem{}))}},
+		}
+	}
+	return responses
+}
+
+func jsonBody(s map[string]any, contentTypes ...string) map[string]any {
+	if len(contentTypes) == 0 {
+		contentTypes = []string{"application/json"}
+	}
+	content := map[string]any{}
+	for _, ct := range contentTypes {
+		content[ct] = map[string]any{"schema": s}
+	}
+	return map[string]any{"required": true, "content": content}
+}
+
+// pathParams 路由参数；types 里有的按字段类型生成 schema（主键、父 ID），其余为字符串
+func (g *schemaGen) pathParams(route string, types map[string]reflect.Type) []any {
+	var out []any
+	for _, p := range routeParams(route) {
+		s := map[string]any{"type": "string"}
+		if t, ok := types[p]; ok {
+			s = g.paramSchema(t)
+		}
+		out = append(out, map[string]any{"name": p, "in": "path", "required": true, "schema": s})
+	}
+	return out
+}
+
+// 路由参数只能是标量：整数 / uuid 等按类型，$ref、对象等（自定义 Scanner）退回字符串
+func (g *schemaGen) paramSchema(t reflect.Type) map[string]any {
+	s := g.plain(indirect(t))
+	switch s["type"] {
+	case "integer", "number", "string", "boolean":
+		return s
+	}
+	return map[string]any{"type": "string"}
+}
+
+func queryParam(name, desc string, s map[string]any) map[string]any {
+	p := map[string]any{"name": name, "in": "query", "schema": s}
+	if desc != "" {
+		p["description"] = desc
+	}
+	return p
+}
+
+// Idempotency-Key 请求头（Idempotent 的写接口）
+func idempotencyParam() map[string]any {
+	return map[string]any{
+		"name": HeaderIdempotencyKey, "in": "header", "schema": map[string]any{"type": "string", "maxLength": idempotencyMaxKeyLen},
+		"description": "幂等键：相同键 + 相同请求在保留期内重放首次响应（带 Idempotent-Replayed: true），键已用于不同请求或仍在处理中时 409",
+	}
+}
+
+// 按 form / header tag 生成 query / header 参数（嵌入结构体展开）；
+// explicit 时只收带该 tag 的字段（多来源组合、header），否则同 gin 以字段名兜底
+func (g *schemaGen) formParams(t reflect.Type, tag, in string, explicit bool) []any {
+	t = indirect(t)
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var out []any
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, opts, _ := strings.Cut(sf.Tag.Get(tag), ",")
+		if name == "-" {
+			continue
+		}
+		if sf.Anonymous && name == "" && indirect(sf.Type).Kind() == reflect.Struct {
+			out = append(out, g.formParams(sf.Type, tag, in, explicit)...)
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			if explicit {
+				continue
+			}
+			name = sf.Name
+		}
+		s := g.of(sf.Type)
+		applyBinding(s, sf.Type, sf.Tag.Get("binding"))
+		for _, o := range strings.Split(opts, ",") {
+			if v, ok := strings.CutPrefix(o, "default="); ok {
+				if dv, err := convertQueryValue(sf.Type, v); err == nil {
+					s["default"] = dv
+				}
+			}
+		}
+		p := map[string]any{"name": name, "in": in, "schema": s}
+		if bindingRequired(sf.Tag.Get("binding")) {
+			p["required"] = true
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// 表单请求体（urlencoded / multipart）：字段同 formParams，文件字段为 binary
+func (g *schemaGen) formBody(t reflect.Type, explicit bool) map[string]any {
+	props := map[string]any{}
+	var required []string
+	for _, p := range g.formParams(t, "form", "", explicit) {
+		p := p.(map[string]any)
+		name := p["name"].(string)
+		props[name] = p["schema"]
+		if p["required"] == true {
+			required = append(required, name)
+		}
+	}
+	s := objectSchema(props)
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+// operationId / tag：取路由里的静态段
+func routeWords(route string) []string {
+	return strings.FieldsFunc(route, func(r rune) bool { return r == '/' || r == ':' || r == '-' || r == '_' || r == '.' })
+}
+
+func pascal(words ...string) string {
+	var b strings.Builder
+	for _, w := range words {
+		for i, r := range w {
+			if i == 0 {
+				r = unicode.ToUpper(r)
+			}
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				b.WriteRune(r)
+			}
+		}
+	}
+	return b.String()
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+// 去掉路由参数后的静态段
+func staticWords(route string) []string {
+	var out []string
+	for _, seg := range strings.Split(route, "/") {
+		if seg == "" || seg[0] == ':' || seg[0] == '*' {
+			continue
+		}
+		out = append(out, routeWords(seg)...)
+	}
+	return out
+}
+
+func routeTag(g *gin.RouterGroup, route string) string {
+	if w := staticWords(route); len(w) > 0 {
+		return w[0]
+	}
+	if w := staticWords(g.BasePath()); len(w) > 0 {
+		return w[len(w)-1]
+	}
+	return "default"
+}
+
+/* ---------- RegisterAction ---------- */
+
+func recordAction[I any, O any](g *gin.RouterGroup, a Action[I, O]) {
+	method := strings.ToUpper(a.Method)
+	switch method {
+	case http.MethodGet, http.MethodPut, http.MethodDelete:
+	default:
+		method = http.MethodPost
+	}
+	in := reflect.TypeOf((*I)(nil)).Elem()
+	out := reflect.TypeOf((*O)(nil)).Elem()
+	route := a.Path
+	recordDoc(g, method, route, "", a.Auth || len(a.Roles) > 0, func(sg *schemaGen) map[string]any {
+		op := map[string]any{
+			"operationId": lowerFirst(pascal(append([]string{strings.ToLower(method)}, staticWords(route)...)...)),
+			"tags":        []string{routeTag(g, route)},
+			"responses":   sg.okResponse(sg.of(out)),
+		}
+		if a.Summary != "" {
+			op["summary"] = a.Summary
+		}
+		params := sg.pathParams(joinRoute(g.BasePath(), route), nil)
+		content := map[string]any{}
+		multi := strings.Contains(string(a.Binder), "+")
+		for _, src := range strings.Split(string(a.Binder), "+") {
+			switch Binder(src) {
+			case BindJSON:
+				content["application/json"] = map[string]any{"schema": sg.of(in)}
+			case BindQuery:
+				params = append(params, sg.formParams(in, "form", "query", multi)...)
+			case BindHeader:
+				params = append(params, sg.formParams(in, "header", "header", true)...)
+			case BindForm:
+				content["application/x-www-form-urlencoded"] = map[string]any{"schema": sg.formBody(in, multi)}
+			case BindMultipart:
+				content["multipart/form-data"] = map[string]any{"schema": sg.formBody(in, multi)}
+			}
+		}
+		if len(content) > 0 {
+			op["requestBody"] = map[string]any{"required": true, "content": content}
+		}
+		if a.Idempotent && method != http.MethodGet {
+			params = append(params, idempotencyParam())
+		}
+		if len(params) > 0 {
+			op["parameters"] = params
+		}
+		return op
+	})
+}
+
+/* ---------- Crud ---------- */
+
+func recordCrud[T any](cfg *CrudConfig[T], keys keySpec, fields *fieldSpec, spec *listSpec, preloads *preloadSpec) {
+	t := reflect.TypeOf(cfg.New()).Elem()
+	name := pascal(staticWords(cfg.Path)...)
+	if name == "" {
+		name = t.Name()
+	}
+	tag := routeTag(cfg.Group, cfg.Path)
+	item := cfg.Path + keys.route()
+	model := func(g *schemaGen) map[string]any { return g.crudModel(t, fields) }
+	// 主键 / 父 ID 路由参数的字段类型
+	paramTypes := map[string]reflect.Type{}
+	for i, n := range keys.fieldNames() {
+		if sf, ok := t.FieldByName(n); ok && i < len(keys.params) {
+			paramTypes[keys.params[i]] = sf.Type
+		}
+	}
+	if cfg.Parent != nil {
+		if sf, ok := t.FieldByName(cfg.Parent.Field); ok {
+			paramTypes[cfg.Parent.Param] = sf.Type
+		}
+	}
+	mount := ""
+	add := func(method, route, opID, summary string, build func(g *schemaGen, op map[string]any)) {
+		recordDoc(cfg.Group, method, route, mount, true, func(g *schemaGen) map[string]any {
+			op := map[string]any{"operationId": opID + name, "tags": []string{tag}, "summary": summary}
+			if params := g.pathParams(joinRoute(cfg.Group.BasePath(), route), paramTypes); len(params) > 0 {
+				op["parameters"] = params
+			}
+			build(g, op)
+			return op
+		})
+	}
+	addParams := func(op map[string]any, ps ...any) {
+		cur, _ := op["parameters"].([]any)
+		op["parameters"] = append(cur, ps...)
+	}
+	str := map[string]any{"type": "string"}
+	fieldsParam := queryParam("fields", "稀疏字段集，逗号分隔", str)
+	var includeParam map[string]any
+	if len(preloads.allowed) > 0 {
+		var rels []string
+		for _, r := range preloads.allowed {
+			rels = append(rels, r)
+		}
+		sort.Strings(rels)
+		includeParam = queryParam("include", "预加载关联，逗号分隔："+strings.Join(rels, ", "), str)
+	}
+	// 列表过滤/排序参数（列表与导出共用）
+	listParams := func() []any {
+		var ps []any
+		for _, n := range sortedJSONNames(spec.filters) {
+			ps = append(ps, queryParam(n, "过滤：op:value（eq/ne/gt/gte/lt/lte/like/in/isnull），省略 op 为 eq", str))
+		}
+		if sorts := sortedJSONNames(spec.sorts); len(sorts) > 0 {
+			ps = append(ps, queryParam("sort", "排序，逗号分隔，- 前缀倒序："+strings.Join(sorts, ", "), str))
+		}
+		if cfg.Scope.Mode != ScopeOwner && cfg.Parent == nil {
+			ps = append(ps, queryParam(cfg.Scope.ownerParam(), "不限归属时按归属用户筛选", str))
+		}
+		return append(ps, fieldsParam)
+	}
+	idOut := objectSchema(map[string]any{"id": map[string]any{}})
+	integer := map[string]any{"type": "integer"}
+
+	if cfg.AllowCreate {
+		add(http.MethodPost, cfg.Path, "create", "创建", func(g *schemaGen, op map[string]any) {
+			op["requestBody"] = jsonBody(model(g))
+			op["responses"] = g.okResponse(model(g))
+			if cfg.Idempotent {
+				addParams(op, idempotencyParam())
+			}
+		})
+	}
+	if cfg.AllowList {
+		add(http.MethodGet, cfg.Path, "list", "列表", func(g *schemaGen, op map[string]any) {
+			ps := []any{queryParam("size", "每页条数（≤100）", integer)}
+			out := map[string]any{
+				"list": map[string]any{"type": "array", "items": model(g)},
+				"size": integer, "total": integer,
+			}
+			if cfg.Pagination == PageCursor {
+				ps = append(ps, queryParam("cursor", "上一页返回的 nextCursor/prevCursor", str))
+				out["nextCursor"], out["prevCursor"] = str, str
+			} else {
+				ps = append(ps, queryParam("page", "页码，从 1 开始", integer))
+				out["page"] = integer
+			}
+			ps = append(ps, listParams()...)
+			if includeParam != nil {
+				ps = append(ps, includeParam)
+			}
+			addParams(op, ps...)
+			op["responses"] = g.okResponse(objectSchema(out))
+		})
+	}
+	if cfg.AllowGet {
+		add(http.MethodGet, item, "get", "详情", func(g *schemaGen, op map[string]any) {
+			addParams(op, fieldsParam)
+			if includeParam != nil {
+				addParams(op, includeParam)
+			}
+			op["responses"] = g.okResponse(model(g))
+		})
+	}
+	if cfg.AllowUpdate {
+		add(http.MethodPut, item, "update", "更新（零值字段不更新）", func(g *schemaGen, op map[string]any) {
+			op["requestBody"] = jsonBody(model(g))
+			op["responses"] = g.okResponse(model(g))
+		})
+	}
+	if cfg.AllowPatch {
+		add(http.MethodPatch, item, "patch", "局部更新（Merge Patch / JSON Patch）", func(g *schemaGen, op map[string]any) {
+			op["requestBody"] = map[string]any{"required": true, "content": map[string]any{
+				"application/merge-patch+json": map[string]any{"schema": model(g)},
+				"application/json":             map[string]any{"schema": model(g)},
+				"application/json-patch+json": map[string]any{"schema": map[string]any{
+					"type": "array", "items": objectSchema(map[string]any{
+						"op":   map[string]any{"type": "string", "enum": []string{"add", "remove", "replace", "move", "copy", "test"}},
+						"path": str, "from": str, "value": map[string]any{},
+					}),
+				}},
+			}}
+			op["responses"] = g.okResponse(model(g))
+		})
+	}
+	if cfg.AllowDelete {
+		add(http.MethodDelete, item, "delete", "删除", func(g *schemaGen, op map[string]any) {
+			op["responses"] = g.okResponse(idOut)
+		})
+	}
+	if cfg.AllowBatch {
+		batchOut := func(g *schemaGen) map[string]any {
+			return objectSchema(map[string]any{
+				"results":   map[string]any{"type": "array", "items": g.of(reflect.TypeOf(BatchResult{}))},
+				"succeeded": integer, "failed": integer,
+			})
+		}
+		atomic := map[string]any{"type": "boolean", "default": true}
+		// 批量接口统一挂在 /path:verb 上分发
+		mount = cfg.Path + ":verb"
+		verb := func(v, summary string, req func(g *schemaGen) map[string]any) {
+			add(http.MethodPost, cfg.Path+":"+v, v, summary, func(g *schemaGen, op map[string]any) {
+				op["requestBody"] = jsonBody(req(g))
+				op["responses"] = g.okResponse(batchOut(g))
+			})
+		}
+		if cfg.AllowCreate {
+			verb("batchCreate", "批量创建", func(g *schemaGen) map[string]any {
+				return objectSchema(map[string]any{"items": map[string]any{"type": "array", "items": model(g)}, "allOrNothing": atomic})
+			})
+		}
+		if cfg.AllowUpdate {
+			verb("batchUpdate", "批量更新（条目需带主键）", func(g *schemaGen) map[string]any {
+				return objectSchema(map[string]any{"items": map[string]any{"type": "array", "items": model(g)}, "allOrNothing": atomic})
+			})
+		}
+		if cfg.AllowDelete {
+			verb("batchDelete", "批量删除", func(g *schemaGen) map[string]any {
+				id := map[string]any{} // 复合主键为对象
+				if t, ok := paramTypes[keys.params[0]]; ok && !keys.composite() {
+					id = g.paramSchema(t)
+				}
+				return objectSchema(map[string]any{"ids": map[string]any{"type": "array", "items": id}, "allOrNothing": atomic})
+			})
+		}
+		mount = ""
+	}
+	if cfg.AllowExport {
+		add(http.MethodGet, cfg.Path+"/export", "export", "导出（流式下载）", func(g *schemaGen, op map[string]any) {
+			addParams(op, queryParam("format", "", map[string]any{"type": "string", "enum": []string{"csv", "ndjson", "xlsx"}, "default": "csv"}))
+			addParams(op, listParams()...)
+			file := map[string]any{"schema": map[string]any{"type": "string", "format": "binary"}}
+			ok := map[string]any{
+				"description": "文件下载",
+				"content": map[string]any{
+					"text/csv": file, "application/x-ndjson": file,
+					"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": file,
+				},
+			}
+			if g.mode == resp.ModeLegacy {
+				ok["description"] = "文件下载；出错时返回统一 JSON 响应（HTTP 200）"
+				ok["content"].(map[string]any)["application/json"] = map[string]any{"schema": envelope(nil)}
+			}
+			op["responses"] = g.withErrors(map[string]any{"200": ok})
+		})
+	}
+	if cfg.AllowImport {
+		add(http.MethodPost, cfg.Path+"/import", "import", "导入 CSV/NDJSON", func(g *schemaGen, op map[string]any) {
+			boolean := map[string]any{"type": "boolean"}
+			addParams(op,
+				queryParam("format", "默认按文件扩展名判断", map[string]any{"type": "string", "enum": []string{"csv", "ndjson"}}),
+				queryParam("dryRun", "只校验不写入", boolean),
+				queryParam("async", "转后台任务", boolean),
+			)
+			op["requestBody"] = map[string]any{"required": true, "content": map[string]any{
+				"multipart/form-data": map[string]any{"schema": objectSchema(map[string]any{
+					"file": map[string]any{"type": "string", "format": "binary"},
+				})},
+			}}
+			op["responses"] = g.okResponse(map[string]any{"oneOf": []any{
+				g.of(reflect.TypeOf(ImportReport{})), g.of(reflect.TypeOf(ImportTask{})),
+			}})
+		})
+		add(http.MethodGet, cfg.Path+"/import/:task", "importStatus", "导入任务进度", func(g *schemaGen, op map[string]any) {
+			op["responses"] = g.okResponse(g.of(reflect.TypeOf(ImportTask{})))
+		})
+	}
+	if cfg.AllowTrash {
+		add(http.MethodGet, cfg.Path+"/trash", "trash", "回收站", func(g *schemaGen, op map[string]any) {
+			addParams(op, queryParam("page", "", integer), queryParam("size", "", integer))
+			addParams(op, listParams()...)
+			op["responses"] = g.okResponse(objectSchema(map[string]any{
+				"list":  map[string]any{"type": "array", "items": model(g)},
+				"total": integer, "page": integer, "size": integer,
+			}))
+		})
+		add(http.MethodPost, item+"/restore", "restore", "从回收站恢复", func(g *schemaGen, op map[string]any) {
+			op["responses"] = g.okResponse(idOut)
+		})
+		add(http.MethodDelete, item+"/purge", "purge", "彻底删除", func(g *schemaGen, op map[string]any) {
+			op["responses"] = g.okResponse(idOut)
+		})
+	}
+}
+
+// 白名单索引里同一字段有多个 key（json 名/字段名/列名），文档只列 json 名
+func sortedJSONNames(m map[string]*schema.Field) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, f := range m {
+		if n := jsonName(f); !seen[n] {
+			seen[n] = true
+			out = append(out, n)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func objectSchema(props map[string]any) map[string]any {
+	return map[string]any{"type": "object", "properties": props}
+}
+
+/* ---------- 类型 → JSON Schema ---------- */
+
+type schemaGen struct {
+	mode  resp.Mode               // 引擎的响应模式，决定错误响应的形状
+	defs  map[string]any          // components.schemas
+	names map[reflect.Type]string // 已登记的具名类型
+	used  map[string]bool
+}
+
+func newSchemaGen(mode resp.Mode) *schemaGen {
+	return &schemaGen{mode: mode, defs: map[string]any{}, names: map[reflect.Type]string{}, used: map[string]bool{}}
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	deletedAtType     = reflect.TypeOf(gorm.DeletedAt{})
+	uuidType          = reflect.TypeOf(uuid.UUID{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	componentNameRe   = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+)
+
+func (g *schemaGen) of(t reflect.Type) map[string]any {
+	switch t {
+	case fileHeaderType:
+		return map[string]any{"type": "string", "format": "binary"}
+	case fileHeadersType:
+		return map[string]any{"type": "array", "items": map[string]any{"type": "string", "format": "binary"}}
+	}
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		t, nullable = t.Elem(), true
+	}
+	s := g.plain(t)
+	if nullable {
+		if typ, ok := s["type"].(string); ok {
+			s["type"] = []string{typ, "null"}
+		}
+	}
+	return s
+}
+
+func (g *schemaGen) plain(t reflect.Type) map[string]any {
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case deletedAtType:
+		return map[string]any{"type": []string{"string", "null"}, "format": "date-time"}
+	case uuidType:
+		return map[string]any{"type": "string", "format": "uuid"}
+	}
+	if t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+		// uuid.UUID 等按文本序列化
+		return map[string]any{"type": "string"}
+	}
+	if t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType) {
+		return map[string]any{} // 自定义 JSON 编码，形状未知
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return map[string]any{"type": "integer", "format": "int32"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32:
+		return map[string]any{"type": "number", "format": "float"}
+	case reflect.Float64:
+		return map[string]any{"type": "number", "format": "double"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": g.of(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.of(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return g.ref(t, func() map[string]any { return g.structSchema(t) })
+	}
+	return map[string]any{} // interface{}：任意值
+}
+
+// ref 具名结构体登记到 components.schemas，返回 $ref
+func (g *schemaGen) ref(t reflect.Type, build func() map[string]any) map[string]any {
+	name, ok := g.names[t]
+	if !ok {
+		base := componentNameRe.ReplaceAllString(t.Name(), "_")
+		name = base
+		for i := 2; g.used[name]; i++ {
+			name = base + strconv.Itoa(i)
+		}
+		g.names[t], g.used[name] = name, true
+		g.defs[name] = map[string]any{} // 先占位，支持自引用
+		g.defs[name] = build()
+	}
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+func (g *schemaGen) structSchema(t reflect.Type) map[string]any {
+	props := map[string]any{}
+	var required []string
+	g.fields(t, props, &required)
+	s := map[string]any{"type": "object", "properties": props}
+	if len(required) > 0 {
+		sort.Strings(required)
+		s["required"] = required
+	}
+	return s
+}
+
+func (g *schemaGen) fields(t reflect.Type, props map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if sf.Anonymous && name == "" && indirect(sf.Type).Kind() == reflect.Struct {
+			g.fields(indirect(sf.Type), props, required)
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		s := g.of(sf.Type)
+		applyBinding(s, sf.Type, sf.Tag.Get("binding"))
+		props[jsonTagName(sf)] = s
+		if bindingRequired(sf.Tag.Get("binding")) {
+			*required = append(*required, jsonTagName(sf))
+		}
+	}
+}
+
+// crudModel Crud 模型：去掉 ez:"-" 字段，writeonly 字段标 writeOnly
+func (g *schemaGen) crudModel(t reflect.Type, fields *fieldSpec) map[string]any {
+	return g.ref(t, func() map[string]any {
+		s := g.structSchema(t)
+		props := s["properties"].(map[string]any)
+		for f, r := range fields.rules {
+			name := jsonName(f)
+			p, ok := props[name].(map[string]any)
+			if !ok {
+				continue
+			}
+			switch {
+			case r.Hidden:
+				delete(props, name)
+			case r.WriteOnly:
+				p["writeOnly"] = true
+			}
+		}
+		return s
+	})
+}
+
+func bindingRequired(tag string) bool {
+	for _, r := range strings.Split(tag, ",") {
+		if r == "dive" {
+			return false
+		}
+		if r == "required" {
+			return true
+		}
+	}
+	return false
+}
+
+// applyBinding 把常见的 validator 规则转成 JSON Schema 约束（$ref 上不加）
+func applyBinding(s map[string]any, t reflect.Type, tag string) {
+	if tag == "" || s["$ref"] != nil {
+		return
+	}
+	t = indirect(t)
+	num := func(v string) any {
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+		return v
+	}
+	for _, rule := range strings.Split(tag, ",") {
+		key, val, _ := strings.Cut(rule, "=")
+		switch key {
+		case "dive":
+			return
+		case "email":
+			s["format"] = "email"
+		case "url", "uri":
+			s["format"] = "uri"
+		case "uuid", "uuid4":
+			s["format"] = "uuid"
+		case "oneof":
+			s["enum"] = strings.Fields(val)
+		case "min", "max", "len", "gt", "gte", "lt", "lte":
+			switch t.Kind() {
+			case reflect.String:
+				if key == "min" || key == "len" || key == "gte" {
+					s["minLength"] = num(val)
+				}
+				if key == "max" || key == "len" || key == "lte" {
+					s["maxLength"] = num(val)
+				}
+			case reflect.Slice, reflect.Array, reflect.Map:
+				if key == "min" || key == "len" || key == "gte" {
+					s["minItems"] = num(val)
+				}
+				if key == "max" || key == "len" || key == "lte" {
+					s["maxItems"] = num(val)
+				}
+			default:
+				switch key {
+				case "min", "gte":
+					s["minimum"] = num(val)
+				case "max", "lte":
+					s["maximum"] = num(val)
+				case "gt":
+					s["exclusiveMinimum"] = num(val)
+				case "lt":
+					s["exclusiveMaximum"] = num(val)
+				}
+			}
+		}
+	}
+}
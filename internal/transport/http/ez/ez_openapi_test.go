@@ -0,0 +1,170 @@
+package ez_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	httpez "go-gin-gorm-starter/internal/transport/http/ez"
+	resp "go-gin-gorm-starter/internal/transport/http/response"
+)
+
+type oaNote struct {
+	gorm.Model
+	OwnerID string `json:"ownerId"`
+	Title   string `json:"title" binding:"required,max=64"`
+	Secret  string `json:"secret" ez:"-"`
+	Pw      string `json:"pw" ez:"writeonly"`
+}
+
+func openAPIEngine(t *testing.T) *gin.Engine {
+	t.Helper()
+	r, g := newEngine(t)
+	httpez.Crud(httpez.CrudConfig[oaNote]{
+		DB: newDB(t), Group: g, Path: "/oa-notes", New: func() *oaNote { return &oaNote{} },
+		AllowCreate: true, AllowList: true, AllowGet: true, AllowBatch: true, AllowImport: true,
+	})
+	httpez.MountOpenAPI(r, httpez.OpenAPIInfo{Title: "<Notes>", Version: "1", SecuredPrefixes: []string{"/api"}})
+	return r
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	r := openAPIEngine(t)
+	w, _ := call(t, r, http.MethodGet, "/openapi.json", nil, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("openapi.json: %d", w.Code)
+	}
+	var doc struct {
+		Paths map[string]map[string]map[string]any
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{"/api/oa-notes", "/api/oa-notes/{id}", "/api/oa-notes:batchCreate", "/api/oa-notes/import"} {
+		if doc.Paths[p] == nil {
+			t.Fatalf("missing path %s", p)
+		}
+	}
+	if doc.Paths["/api/oa-notes/{id}"]["get"]["security"] == nil {
+		t.Fatal("secured prefix not applied")
+	}
+	raw := w.Body.String()
+	if strings.Contains(raw, `"secret"`) {
+		t.Fatal(`ez:"-" field documented`)
+	}
+
+	w, _ = call(t, r, http.MethodGet, "/docs", nil, nil)
+	page := w.Body.String()
+	if !strings.Contains(page, "&lt;Notes&gt;") || strings.Contains(page, "/latest/") || !strings.Contains(page, "redoc@2") {
+		t.Fatalf("docs page %s", page)
+	}
+}
+
+func TestOpenAPIMarshalFailure(t *testing.T) {
+	r := openAPIEngine(t)
+	restore := httpez.SetMarshalOpenAPI(func(any) ([]byte, error) { return nil, errors.New("boom") })
+	w, _ := call(t, r, http.MethodGet, "/openapi.json", nil, nil)
+	restore()
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("marshal failure: %d %s, want 500", w.Code, w.Body)
+	}
+	// 失败不缓存：恢复后能正常生成
+	if w, _ := call(t, r, http.MethodGet, "/openapi.json", nil, nil); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "/api/oa-notes") {
+		t.Fatalf("after failure: %d %.80s", w.Code, w.Body)
+	}
+}
+
+type oaLine struct {
+	ID     int64     `gorm:"primaryKey" json:"id"`
+	ItemID uuid.UUID `gorm:"type:varchar(36);index" json:"itemId"`
+	Qty    int       `json:"qty"`
+}
+
+// 错误响应跟随响应模式；主键 / 父 ID 路由参数按字段类型
+func TestOpenAPIModes(t *testing.T) {
+	db := newDB(t)
+	for _, mode := range []resp.Mode{resp.ModeLegacy, resp.ModeStatus, resp.ModeProblem} {
+		r, g := newModeEngine(t, mode)
+		items := httpez.CrudConfig[uuidItem]{
+			DB: db, Group: g, Path: "/oa-items", New: func() *uuidItem { return &uuidItem{} },
+			AllowGet: true, AllowExport: true,
+		}
+		httpez.Crud(items)
+		httpez.Crud(httpez.CrudConfig[oaLine]{
+			DB: db, Group: g, Path: "/oa-items/:id/lines", IDParam: "lineId", New: func() *oaLine { return &oaLine{} },
+			AllowGet: true, Parent: httpez.ParentOf(items, "ItemID"),
+		})
+		httpez.Crud(httpez.CrudConfig[tenantItem]{
+			DB: db, Group: g, Path: "/oa-tenants", New: func() *tenantItem { return &tenantItem{} },
+			IDFields: []string{"TenantID", "Code"}, AllowGet: true,
+		})
+		httpez.MountOpenAPI(r, httpez.OpenAPIInfo{Title: "modes"})
+
+		w, _ := call(t, r, http.MethodGet, "/openapi.json", nil, nil)
+		var doc struct {
+			Paths map[string]map[string]struct {
+				Parameters []struct {
+					Name   string
+					In     string
+					Schema map[string]any
+				}
+				Responses map[string]struct {
+					Content map[string]struct{ Schema map[string]any }
+				}
+			}
+			Components struct{ Schemas map[string]map[string]any }
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+			t.Fatal(err)
+		}
+
+		get := doc.Paths["/api/oa-items/{id}"]["get"]
+		switch errRes, ok := get.Responses["default"]; mode {
+		case resp.ModeLegacy:
+			if ok || len(get.Responses) != 1 {
+				t.Errorf("legacy: responses %v, want only 200", get.Responses)
+			}
+			if _, ok := doc.Paths["/api/oa-items/export"]["get"].Responses["200"].Content["application/json"]; !ok {
+				t.Error("legacy export: error envelope missing from 200")
+			}
+		case resp.ModeStatus:
+			if s := errRes.Content["application/json"].Schema; s["$ref"] == nil {
+				t.Errorf("status: error response %v", errRes)
+			}
+		case resp.ModeProblem:
+			s := errRes.Content[resp.MIMEProblem].Schema
+			if s["$ref"] != "#/components/schemas/Problem" || doc.Components.Schemas["Problem"] == nil {
+				t.Errorf("problem: error response %v", errRes)
+			}
+			if _, ok := doc.Paths["/api/oa-items/export"]["get"].Responses["default"].Content[resp.MIMEProblem]; !ok {
+				t.Error("problem export: problem response missing")
+			}
+		}
+
+		types := func(path string) map[string]string {
+			out := map[string]string{}
+			for _, p := range doc.Paths[path]["get"].Parameters {
+				if p.In == "path" {
+					out[p.Name] = fmt.Sprint(p.Schema["type"], "/", p.Schema["format"])
+				}
+			}
+			return out
+		}
+		for path, want := range map[string]map[string]string{
+			"/api/oa-items/{id}":                {"id": "string/uuid"},
+			"/api/oa-items/{id}/lines/{lineId}": {"id": "string/uuid", "lineId": "integer/int64"},
+			"/api/oa-tenants/{tenantId}/{code}": {"tenantId": "integer/int64", "code": "string/<nil>"},
+		} {
+			if got := types(path); fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("mode %d %s: path params %v, want %v", mode, path, got, want)
+			}
+		}
+	}
+}
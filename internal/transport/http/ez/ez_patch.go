@@ -0,0 +1,416 @@
+package ez
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm/schema"
+)
+
+/* ================== PATCH：部分更新 ==================
+   - application/merge-patch+json / application/json：JSON Merge Patch（RFC 7396）
+   - application/json-patch+json：JSON Patch（RFC 6902）
+   只更新请求里真正出现的字段（零值也会写入），只读字段直接 400
+*/
+
+const mimeJSONPatch = "application/json-patch+json"
+
+type patchSpec struct {
+	sch      *schema.Schema
+	readOnly map[*schema.Field]struct{}
+}
+
+// 只读字段：主键/ID/Owner/自动时间戳/软删字段 + 额外配置
+func newPatchSpec(sch *schema.Schema, extra, idNames, ownerNames []string) *patchSpec {
+	p := &patchSpec{sch: sch, readOnly: map[*schema.Field]struct{}{}}
+	for _, f := range sch.Fields {
+		if f.PrimaryKey || f.AutoCreateTime > 0 || f.AutoUpdateTime > 0 || isDeletedAt(f) {
+			p.readOnly[f] = struct{}{}
+		}
+	}
+	// ID/Owner 是候选名列表，模型上不一定都存在，找不到跳过
+	for _, n := range append(append([]string{}, idNames...), ownerNames...) {
+		if f := lookupField(sch, n); f != nil {
+			p.readOnly[f] = struct{}{}
+		}
+	}
+	for _, n := range extra {
+		f := lookupField(sch, n)
+		if f == nil {
+			panic(fmt.Sprintf("ez: read-only field %q not found on %s", n, sch.Name))
+		}
+		p.readOnly[f] = struct{}{}
+	}
+	return p
+}
+
+func isDeletedAt(f *schema.Field) bool {
+	return indirect(f.FieldType).Name() == "DeletedAt"
+}
+
+// apply 把补丁写进 m（已从库里读出的当前记录），返回需要 UPDATE 的列
+// writable 为字段级权限判断，不可写的字段同只读字段一样只允许原样回传
+func (p *patchSpec) apply(m any, body []byte, contentType string, writable func(*schema.Field) bool) ([]string, error) {
+	var changes map[string]json.RawMessage
+	var err error
+	if contentType == mimeJSONPatch {
+		changes, err = jsonPatchChanges(m, body)
+	} else {
+		changes, err = mergePatchChanges(m, body)
+	}
+	if err != nil {
+		return nil, BadRequest(err.Error())
+	}
+
+	keys := make([]string, 0, len(changes))
+	for k := range changes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	rv := reflect.ValueOf(m).Elem()
+	var cols []string
+	for _, key := range keys {
+		raw := changes[key]
+		f := lookupField(p.sch, key)
+		if f == nil {
+			return nil, BadRequest(fmt.Sprintf("unknown field %q", key))
+		}
+		fv := f.ReflectValueOf(context.Background(), rv)
+		if _, ro := p.readOnly[f]; ro {
+			// 原样回传当前值视为未修改
+			if cur, _ := json.Marshal(fv.Interface()); jsonEqual(cur, raw) {
+				continue
+			}
+			return nil, BadRequest(fmt.Sprintf("field %q is read-only", key))
+		}
+		if !writable(f) {
+			if cur, _ := json.Marshal(fv.Interface()); jsonEqual(cur, raw) {
+				continue
+			}
+			return nil, Forbidden(fmt.Sprintf("field %q is not writable", key))
+		}
+		if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+			fv.Set(reflect.Zero(fv.Type()))
+		} else {
+			nv := reflect.New(fv.Type())
+			if err := json.Unmarshal(raw, nv.Interface()); err != nil {
+				return nil, BadRequest(fmt.Sprintf("invalid value for %q: %v", key, err))
+			}
+			fv.Set(nv.Elem())
+		}
+		cols = append(cols, f.DBName)
+	}
+	if len(cols) == 0 {
+		return nil, nil
+	}
+	// 自动更新时间戳需显式 Select 才会写入
+	for _, f := range p.sch.Fields {
+		if f.AutoUpdateTime > 0 {
+			cols = append(cols, f.DBName)
+		}
+	}
+	return cols, nil
+}
+
+// RFC 7396：顶层每个 key 对应一列；对象值与当前值递归合并
+func mergePatchChanges(m any, body []byte) (map[string]json.RawMessage, error) {
+	var patch map[string]json.RawMessage
+	if err := json.Unmarshal(body, &patch); err != nil {
+		return nil, fmt.Errorf("merge patch must be a JSON object: %v", err)
+	}
+	cur, err := toDoc(m)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]json.RawMessage, len(patch))
+	for k, raw := range patch {
+		var pv any
+		if err := json.Unmarshal(raw, &pv); err != nil {
+			return nil, err
+		}
+		if obj, ok := pv.(map[string]any); ok {
+			merged, err := json.Marshal(mergePatch(cur[k], obj))
+			if err != nil {
+				return nil, err
+			}
+			raw = merged
+		}
+		out[k] = raw
+	}
+	return out, nil
+}
+
+func mergePatch(target any, patch map[string]any) any {
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+	for k, v := range patch {
+		switch pv := v.(type) {
+		case nil:
+			delete(t, k)
+		case map[string]any:
+			t[k] = mergePatch(t[k], pv)
+		default:
+			t[k] = v
+		}
+	}
+	return t
+}
+
+type jsonPatchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// RFC 6902：在资源的 JSON 文档上依次执行操作，再按顶层 key 求出变更
+func jsonPatchChanges(m any, body []byte) (map[string]json.RawMessage, error) {
+	var ops []jsonPatchOp
+	if err := json.Unmarshal(body, &ops); err != nil {
+		return nil, fmt.Errorf("json patch must be an array of operations: %v", err)
+	}
+	before, err := toDoc(m)
+	if err != nil {
+		return nil, err
+	}
+	after, err := toDoc(m)
+	if err != nil {
+		return nil, err
+	}
+	var doc any = after
+	for i, op := range ops {
+		if doc, err = applyOp(doc, op); err != nil {
+			return nil, fmt.Errorf("op %d (%s %s): %v", i, op.Op, op.Path, err)
+		}
+	}
+	root, ok := doc.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("patched document must remain an object")
+	}
+
+	out := map[string]json.RawMessage{}
+	for k, v := range root {
+		nb, _ := json.Marshal(v)
+		ob, _ := json.Marshal(before[k])
+		if _, existed := before[k]; !existed || !bytes.Equal(nb, ob) {
+			out[k] = nb
+		}
+	}
+	for k := range before {
+		if _, ok := root[k]; !ok {
+			out[k] = json.RawMessage("null")
+		}
+	}
+	return out, nil
+}
+
+func jsonEqual(a, b []byte) bool {
+	var x, y any
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil {
+		return false
+	}
+	return reflect.DeepEqual(x, y)
+}
+
+func toDoc(m any) (map[string]any, error) {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func applyOp(doc any, op jsonPatchOp) (any, error) {
+	switch op.Op {
+	case "add", "replace", "test":
+		var v any
+		if err := json.Unmarshal(op.Value, &v); err != nil {
+			return nil, fmt.Errorf("invalid value")
+		}
+		if op.Op == "test" {
+			cur, err := getPointer(doc, op.Path)
+			if err != nil {
+				return nil, err
+			}
+			a, _ := json.Marshal(cur)
+			b, _ := json.Marshal(v)
+			if !bytes.Equal(a, b) {
+				return nil, fmt.Errorf("test failed")
+			}
+			return doc, nil
+		}
+		return setPointer(doc, op.Path, v, op.Op == "replace")
+	case "remove":
+		return removePointer(doc, op.Path)
+	case "move", "copy":
+		v, err := getPointer(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if doc, err = removePointer(doc, op.From); err != nil {
+				return nil, err
+			}
+		} else {
+			// copy 需深拷贝，避免两处共享同一个 map/slice
+			b, _ := json.Marshal(v)
+			_ = json.Unmarshal(b, &v)
+		}
+		return setPointer(doc, op.Path, v, false)
+	default:
+		return nil, fmt.Errorf("unsupported op")
+	}
+}
+
+// JSON Pointer（RFC 6901）
+func splitPointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(p, "/") {
+		return nil, fmt.Errorf("invalid pointer %q", p)
+	}
+	parts := strings.Split(p[1:], "/")
+	for i, s := range parts {
+		parts[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(s)
+	}
+	return parts, nil
+}
+
+func getPointer(doc any, p string) (any, error) {
+	parts, err := splitPointer(p)
+	if err != nil {
+		return nil, err
+	}
+	cur := doc
+	for _, key := range parts {
+		switch c := cur.(type) {
+		case map[string]any:
+			v, ok := c[key]
+			if !ok {
+				return nil, fmt.Errorf("path not found")
+			}
+			cur = v
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(c) {
+				return nil, fmt.Errorf("index out of range")
+			}
+			cur = c[i]
+		default:
+			return nil, fmt.Errorf("path not found")
+		}
+	}
+	return cur, nil
+}
+
+// 在 parent 容器上执行 fn，返回替换后的新容器（数组增删会换底层切片）
+func updateParent(doc any, p string, fn func(parent any, key string) (any, error)) (any, error) {
+	parts, err := splitPointer(p)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) == 0 {
+		return fn(nil, "")
+	}
+	var walk func(cur any, rest []string) (any, error)
+	walk = func(cur any, rest []string) (any, error) {
+		if len(rest) == 1 {
+			return fn(cur, rest[0])
+		}
+		switch c := cur.(type) {
+		case map[string]any:
+			child, ok := c[rest[0]]
+			if !ok {
+				return nil, fmt.Errorf("path not found")
+			}
+			nc, err := walk(child, rest[1:])
+			if err != nil {
+				return nil, err
+			}
+			c[rest[0]] = nc
+			return c, nil
+		case []any:
+			i, err := strconv.Atoi(rest[0])
+			if err != nil || i < 0 || i >= len(c) {
+				return nil, fmt.Errorf("index out of range")
+			}
+			nc, err := walk(c[i], rest[1:])
+			if err != nil {
+				return nil, err
+			}
+			c[i] = nc
+			return c, nil
+		default:
+			return nil, fmt.Errorf("path not found")
+		}
+	}
+	return walk(doc, parts)
+}
+
+func setPointer(doc any, p string, v any, mustExist bool) (any, error) {
+	return updateParent(doc, p, func(parent any, key string) (any, error) {
+		switch c := parent.(type) {
+		case nil:
+			return v, nil // 整个文档替换
+		case map[string]any:
+			if _, ok := c[key]; mustExist && !ok {
+				return nil, fmt.Errorf("path not found")
+			}
+			c[key] = v
+			return c, nil
+		case []any:
+			if key == "-" && !mustExist {
+				return append(c, v), nil
+			}
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i > len(c) || (mustExist && i == len(c)) {
+				return nil, fmt.Errorf("index out of range")
+			}
+			if mustExist {
+				c[i] = v
+				return c, nil
+			}
+			c = append(c, nil)
+			copy(c[i+1:], c[i:])
+			c[i] = v
+			return c, nil
+		default:
+			return nil, fmt.Errorf("path not found")
+		}
+	})
+}
+
+func removePointer(doc any, p string) (any, error) {
+	return updateParent(doc, p, func(parent any, key string) (any, error) {
+		switch c := parent.(type) {
+		case map[string]any:
+			if _, ok := c[key]; !ok {
+				return nil, fmt.Errorf("path not found")
+			}
+			delete(c, key)
+			return c, nil
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(c) {
+				return nil, fmt.Errorf("index out of range")
+			}
+			return append(c[:i], c[i+1:]...), nil
+		default:
+			return nil, fmt.Errorf("path not found")
+		}
+	})
+}
@@ -0,0 +1,130 @@
+package ez_test
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	httpez "go-gin-gorm-starter/internal/transport/http/ez"
+)
+
+type noteMeta struct {
+	Color  string `json:"color"`
+	Pinned bool   `json:"pinned"`
+}
+
+type patchNote struct {
+	ID        int64     `gorm:"primaryKey" json:"id"`
+	OwnerID   string    `gorm:"size:36;index" json:"ownerId"`
+	Title     string    `json:"title" binding:"required"`
+	Body      string    `json:"body"`
+	Done      bool      `json:"done"`
+	Meta      noteMeta  `gorm:"serializer:json" json:"meta"`
+	Slug      string    `json:"slug"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func TestPatch(t *testing.T) {
+	db := newDB(t)
+	r, g := newEngine(t)
+	httpez.Crud(httpez.CrudConfig[patchNote]{
+		DB: db, Group: g, Path: "/notes", New: func() *patchNote { return &patchNote{} },
+		AllowCreate: true, AllowGet: true, AllowPatch: true,
+		ReadOnlyFields: []string{"Slug"},
+	})
+	alice := as("alice")
+	merge := with(alice, "Content-Type", "application/merge-patch+json")
+	jsonPatch := with(alice, "Content-Type", "application/json-patch+json")
+
+	_, res := call(t, r, http.MethodPost, "/api/notes", alice, map[string]any{
+		"title": "draft", "body": "text", "done": true, "slug": "draft",
+		"meta": map[string]any{"color": "blue", "pinned": true},
+	})
+	created := decode[patchNote](t, res)
+	path := "/api/notes/" + strconv.FormatInt(created.ID, 10)
+	stored := func() patchNote {
+		t.Helper()
+		var n patchNote
+		if err := db.First(&n, created.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	// Merge Patch：只改出现的字段，零值也写入，嵌套对象递归合并
+	w, res := call(t, r, http.MethodPatch, path, merge, `{"title":"final","done":false,"meta":{"color":"red"}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("merge patch: %d %s", w.Code, w.Body)
+	}
+	got := stored()
+	if got.Title != "final" || got.Done || got.Body != "text" {
+		t.Fatalf("after merge patch: %+v", got)
+	}
+	if got.Meta != (noteMeta{Color: "red", Pinned: true}) {
+		t.Fatalf("nested merge: %+v, want color replaced and pinned kept", got.Meta)
+	}
+	if decode[patchNote](t, res).Title != "final" {
+		t.Fatalf("response not updated: %s", res.Data)
+	}
+	// null 清空字段；普通 application/json 也按 Merge Patch 处理
+	if w, _ := call(t, r, http.MethodPatch, path, alice, `{"body":null}`); w.Code != http.StatusOK {
+		t.Fatalf("null patch: %d %s", w.Code, w.Body)
+	}
+	if got := stored(); got.Body != "" || got.Title != "final" {
+		t.Fatalf("after null patch: %+v", got)
+	}
+
+	// JSON Patch：按操作序列修改，test 失败整体不生效
+	ops := `[{"op":"test","path":"/title","value":"final"},{"op":"replace","path":"/title","value":"v2"},{"op":"replace","path":"/meta/pinned","value":false}]`
+	if w, _ := call(t, r, http.MethodPatch, path, jsonPatch, ops); w.Code != http.StatusOK {
+		t.Fatalf("json patch: %d %s", w.Code, w.Body)
+	}
+	if got := stored(); got.Title != "v2" || got.Meta != (noteMeta{Color: "red"}) {
+		t.Fatalf("after json patch: %+v", got)
+	}
+	ops = `[{"op":"replace","path":"/body","value":"lost"},{"op":"test","path":"/title","value":"stale"}]`
+	if w, _ := call(t, r, http.MethodPatch, path, jsonPatch, ops); w.Code != http.StatusBadRequest {
+		t.Fatalf("failed test op: %d, want 400", w.Code)
+	}
+	if got := stored(); got.Body != "" {
+		t.Fatalf("failed json patch applied partially: %+v", got)
+	}
+
+	// 只读字段：原样回传放行，修改则 400
+	cases := []struct {
+		name string
+		hdr  http.Header
+		body string
+		want int
+	}{
+		{"echo id", merge, `{"id":` + strconv.FormatInt(created.ID, 10) + `,"title":"v3"}`, http.StatusOK},
+		{"change id", merge, `{"id":999}`, http.StatusBadRequest},
+		{"change owner", merge, `{"ownerId":"bob"}`, http.StatusBadRequest},
+		{"change configured read-only", merge, `{"slug":"other"}`, http.StatusBadRequest},
+		{"change createdAt", merge, `{"createdAt":"2020-01-01T00:00:00Z"}`, http.StatusBadRequest},
+		{"remove owner", jsonPatch, `[{"op":"remove","path":"/ownerId"}]`, http.StatusBadRequest},
+		{"unknown field", merge, `{"nope":1}`, http.StatusBadRequest},
+		{"wrong type", merge, `{"done":"yes"}`, http.StatusBadRequest},
+		{"not an object", merge, `[1]`, http.StatusBadRequest},
+		{"not an op list", jsonPatch, `{"title":"x"}`, http.StatusBadRequest},
+		{"unsupported op", jsonPatch, `[{"op":"jump","path":"/title"}]`, http.StatusBadRequest},
+		{"fails validation", merge, `{"title":""}`, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		if w, _ := call(t, r, http.MethodPatch, path, tc.hdr, tc.body); w.Code != tc.want {
+			t.Errorf("%s: %d %s, want %d", tc.name, w.Code, w.Body, tc.want)
+		}
+	}
+	if got := stored(); got.ID != created.ID || got.OwnerID != "alice" || got.Slug != "draft" || got.Title != "v3" {
+		t.Fatalf("read-only fields changed: %+v", got)
+	}
+
+	// 别人的记录、id 0 一律 404
+	if w, _ := call(t, r, http.MethodPatch, path, with(as("bob"), "Content-Type", "application/merge-patch+json"), `{"title":"mine"}`); w.Code != http.StatusNotFound {
+		t.Fatalf("patch other's note: %d, want 404", w.Code)
+	}
+	if w, _ := call(t, r, http.MethodPatch, "/api/notes/0", merge, `{"title":"zero"}`); w.Code != http.StatusNotFound {
+		t.Fatalf("patch id 0: %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,256 @@
+package ez
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+/* ================== 列表查询语言：过滤 + 排序 ==================
+   ?status=eq:active&createdAt=gte:2026-01-01&name=like:foo&sort=-createdAt,name
+   - 字段必须在 CrudConfig.Filterable / Sortable 白名单内；其它未声明的参数一律 400
+     （ScopeList 自己读的参数在 CrudConfig.ExtraQueryKeys 里声明，归属参数 Scope.OwnerParam 自动放行）
+   - 值只有以已知操作符开头时才拆成 op:value，否则整体按 eq 比较（?url=http://x、?name=foo:bar）
+   - 列名一律取自模型 schema，永不拼接原始 SQL
+*/
+
+// 支持的过滤操作符
+var filterOps = map[string]struct{}{
+	"eq": {}, "ne": {}, "gt": {}, "gte": {}, "lt": {}, "lte": {},
+	"like": {}, "in": {}, "isnull": {},
+}
+
+// 保留参数：不参与过滤解析（分页/排序/导出/导入等）
+var reservedQueryKeys = map[string]struct{}{
+	"page": {}, "size": {}, "sort": {}, "cursor": {}, "fields": {}, "include": {},
+	"format": {}, "owner": {}, "dryRun": {}, "async": {},
+}
+
+// 时间值可接受的格式
+var timeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"}
+
+// 解析模型 schema（复用 gorm 的缓存与命名策略）
+func parseSchema(db *gorm.DB, model any) (*schema.Schema, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, err
+	}
+	return stmt.Schema, nil
+}
+
+// 字段对外名称：json tag 优先，否则字段名
+func jsonName(f *schema.Field) string {
+	if tag := f.Tag.Get("json"); tag != "" {
+		if name := strings.Split(tag, ",")[0]; name != "" && name != "-" {
+			return name
+		}
+	}
+	return f.Name
+}
+
+// 按 json 名 / 字段名 / 列名（不区分大小写）查找字段
+func lookupField(s *schema.Schema, name string) *schema.Field {
+	key := strings.ToLower(strings.TrimSpace(name))
+	for _, f := range s.Fields {
+		if f.DBName == "" {
+			continue
+		}
+		if strings.ToLower(jsonName(f)) == key || strings.ToLower(f.Name) == key || strings.ToLower(f.DBName) == key {
+			return f
+		}
+	}
+	return nil
+}
+
+type listSpec struct {
+	filters map[string]*schema.Field // 小写查询 key -> 字段
+	sorts   map[string]*schema.Field
+	extra   map[string]struct{} // 放行但不参与过滤的参数（归属参数、ExtraQueryKeys）
+}
+
+// 构建白名单索引（配置错误属于编程错误，注册时直接 panic）
+func newListSpec(s *schema.Schema, filterable, sortable, extra []string) *listSpec {
+	index := func(names []string, kind string) map[string]*schema.Field {
+		m := map[string]*schema.Field{}
+		for _, n := range names {
+			f := lookupField(s, n)
+			if f == nil {
+				panic(fmt.Sprintf("ez: %s field %q not found on %s", kind, n, s.Name))
+			}
+			for _, k := range []string{jsonName(f), f.Name, f.DBName} {
+				m[strings.ToLower(k)] = f
+			}
+		}
+		return m
+	}
+	spec := &listSpec{filters: index(filterable, "filterable"), sorts: index(sortable, "sortable"), extra: map[string]struct{}{}}
+	for _, k := range extra {
+		spec.extra[k] = struct{}{}
+	}
+	return spec
+}
+
+func column(f *schema.Field) clause.Column {
+	return clause.Column{Table: clause.CurrentTable, Name: f.DBName}
+}
+
+// 把 "op:value" 拆开；前缀不是已知操作符时整个值按 eq 处理
+func splitOp(raw string) (op, val string) {
+	if i := strings.IndexByte(raw, ':'); i > 0 {
+		p := strings.ToLower(raw[:i])
+		if _, ok := filterOps[p]; ok {
+			return p, raw[i+1:]
+		}
+	}
+	return "eq", raw
+}
+
+// applyFilters 把查询参数翻译为 gorm 条件
+func (s *listSpec) applyFilters(values url.Values, q *gorm.DB) (*gorm.DB, error) {
+	// 按 key 排序，保证生成的 SQL 稳定（利于预编译缓存）
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if _, ok := reservedQueryKeys[key]; ok {
+			continue
+		}
+		if _, ok := s.extra[key]; ok {
+			continue
+		}
+		f, ok := s.filters[strings.ToLower(key)]
+		if !ok {
+			// 拼错的字段名、不在白名单的字段：明确拒绝，不静默返回未过滤的结果
+			return nil, fmt.Errorf("field %q is not filterable", key)
+		}
+		for _, raw := range values[key] {
+			op, val := splitOp(raw)
+			expr, err := filterExpr(f, op, val)
+			if err != nil {
+				return nil, fmt.Errorf("invalid filter on %q: %w", key, err)
+			}
+			q = q.Where(expr)
+		}
+	}
+	return q, nil
+}
+
+func filterExpr(f *schema.Field, op, val string) (clause.Expression, error) {
+	col := column(f)
+	switch op {
+	case "like":
+		if indirect(f.FieldType).Kind() != reflect.String {
+			return nil, fmt.Errorf("like only applies to string fields")
+		}
+		return clause.Expr{SQL: "? LIKE ? ESCAPE '!'", Vars: []any{col, "%" + escapeLike(val) + "%"}}, nil
+	case "in":
+		var vs []any
+		for _, part := range strings.Split(val, ",") {
+			v, err := convertQueryValue(f.FieldType, strings.TrimSpace(part))
+			if err != nil {
+				return nil, err
+			}
+			vs = append(vs, v)
+		}
+		return clause.IN{Column: col, Values: vs}, nil
+	case "isnull":
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("isnull expects true/false")
+		}
+		if b {
+			return clause.Eq{Column: col, Value: nil}, nil
+		}
+		return clause.Neq{Column: col, Value: nil}, nil
+	}
+
+	v, err := convertQueryValue(f.FieldType, val)
+	if err != nil {
+		return nil, err
+	}
+	switch op {
+	case "ne":
+		return clause.Neq{Column: col, Value: v}, nil
+	case "gt":
+		return clause.Gt{Column: col, Value: v}, nil
+	case "gte":
+		return clause.Gte{Column: col, Value: v}, nil
+	case "lt":
+		return clause.Lt{Column: col, Value: v}, nil
+	case "lte":
+		return clause.Lte{Column: col, Value: v}, nil
+	default:
+		return clause.Eq{Column: col, Value: v}, nil
+	}
+}
+
+// orderBy 解析 sort=-createdAt,name（- 为降序）
+func (s *listSpec) orderBy(raw string) ([]keyCol, error) {
+	var out []keyCol
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		desc := false
+		switch part[0] {
+		case '-':
+			desc, part = true, part[1:]
+		case '+':
+			part = part[1:]
+		}
+		f, ok := s.sorts[strings.ToLower(part)]
+		if !ok {
+			return nil, fmt.Errorf("field %q is not sortable", part)
+		}
+		out = append(out, keyCol{f: f, desc: desc})
+	}
+	return out, nil
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// 把字符串转换为字段对应的 Go 类型，非法值返回错误（→ 400）
+func convertQueryValue(ft reflect.Type, raw string) (any, error) {
+	t := indirect(ft)
+	if t == reflect.TypeOf(time.Time{}) {
+		for _, layout := range timeLayouts {
+			if v, err := time.Parse(layout, raw); err == nil {
+				return v, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid time %q", raw)
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return strconv.ParseBool(raw)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.ParseInt(raw, 10, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.ParseUint(raw, 10, 64)
+	case reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(raw, 64)
+	default:
+		return raw, nil
+	}
+}
+
+// 转义 LIKE 通配符；用 ! 作转义符并显式写 ESCAPE（SQLite 没有默认转义符，MySQL 字面量里的 \ 又要再转义）
+func escapeLike(s string) string {
+	return strings.NewReplacer(`!`, `!!`, `%`, `!%`, `_`, `!_`).Replace(s)
+}
@@ -0,0 +1,104 @@
+package ez_test
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	httpez "go-gin-gorm-starter/internal/transport/http/ez"
+)
+
+type queryTask struct {
+	ID       int64      `gorm:"primaryKey" json:"id"`
+	OwnerID  string     `gorm:"size:36;index" json:"ownerId"`
+	Title    string     `json:"title"`
+	Status   string     `json:"status"`
+	Priority int        `json:"priority"`
+	DueAt    *time.Time `json:"dueAt"`
+}
+
+func TestListQueryLanguage(t *testing.T) {
+	db := newDB(t)
+	r, g := newEngine(t)
+	httpez.Crud(httpez.CrudConfig[queryTask]{
+		DB: db, Group: g, Path: "/q-tasks", New: func() *queryTask { return &queryTask{} },
+		AllowList:  true,
+		Filterable: []string{"title", "status", "priority", "dueAt"},
+		Sortable:   []string{"priority", "title"},
+		// ScopeList 自己读的参数须声明
+		ExtraQueryKeys: []string{"minPriority"},
+		Hooks: httpez.CrudHooks[queryTask]{
+			ScopeList: func(c *gin.Context, q *gorm.DB) *gorm.DB {
+				if v := c.Query("minPriority"); v != "" {
+					q = q.Where("priority >= ?", v)
+				}
+				return q
+			},
+		},
+	})
+	due := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	db.Create(&[]queryTask{
+		{OwnerID: "alice", Title: "write 100% docs", Status: "open", Priority: 3, DueAt: &due},
+		{OwnerID: "alice", Title: "fix bug", Status: "open", Priority: 1},
+		{OwnerID: "alice", Title: "release:v2", Status: "done", Priority: 2},
+		{OwnerID: "bob", Title: "bob's", Status: "open", Priority: 9},
+	})
+	alice := as("alice")
+	list := func(query string) []string {
+		t.Helper()
+		w, res := call(t, r, http.MethodGet, "/api/q-tasks?"+query, alice, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("?%s: %d %s", query, w.Code, w.Body)
+		}
+		var titles []string
+		for _, it := range decode[struct{ List []queryTask }](t, res).List {
+			titles = append(titles, it.Title)
+		}
+		return titles
+	}
+	cases := map[string]string{
+		"status=open&sort=priority":             "fix bug|write 100% docs",
+		"status=eq:open&sort=-priority":         "write 100% docs|fix bug",
+		"status=ne:open":                        "release:v2",
+		"priority=gte:2&sort=priority":          "release:v2|write 100% docs",
+		"priority=in:1,3&sort=priority":         "fix bug|write 100% docs",
+		"priority=gt:1&priority=lt:3":           "release:v2",
+		"title=like:" + url.QueryEscape("100%"): "write 100% docs", // % 按字面匹配
+		"title=like:_":                          "",
+		"dueAt=isnull:false":                    "write 100% docs",
+		"dueAt=lt:2026-04-01":                   "write 100% docs",
+		"sort=title":                            "fix bug|release:v2|write 100% docs",
+		// 前缀不是操作符：整个值按 eq 比较
+		"title=release:v2":                     "release:v2",
+		"title=eq:release:v2":                  "release:v2",
+		"title=" + url.QueryEscape("http://x"): "",
+		// 声明过的 ScopeList 参数、归属参数（非管理员忽略）放行
+		"minPriority=2&sort=priority": "release:v2|write 100% docs",
+		"owner=bob&sort=title":        "fix bug|release:v2|write 100% docs",
+	}
+	for q, want := range cases {
+		if got := strings.Join(list(q), "|"); got != want {
+			t.Errorf("?%s = %q, want %q", q, got, want)
+		}
+	}
+
+	for _, q := range []string{
+		"ownerId=eq:bob",     // 不在白名单
+		"ownerId=bob",        // 无操作符同样拒绝
+		"unrelated=1",        // 未声明的参数（如拼错的字段名）不静默忽略
+		"priority=between:1", // 不是操作符：整个值按 eq 比较，类型不符
+		"priority=gt:x",      // 类型不符
+		"priority=like:1",    // like 只用于字符串
+		"dueAt=isnull:maybe",
+		"sort=ownerId",
+	} {
+		if w, _ := call(t, r, http.MethodGet, "/api/q-tasks?"+q, alice, nil); w.Code != http.StatusBadRequest {
+			t.Errorf("?%s: %d, want 400", q, w.Code)
+		}
+	}
+}
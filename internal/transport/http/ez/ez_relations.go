@@ -0,0 +1,150 @@
+package ez
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+/* ================== 关联预加载 & 嵌套子资源 ==================
+   Preloads: []string{"Items", "Customer"}   →  GET /orders/:id?include=items,customer
+   嵌套：
+     orders := ez.CrudConfig[Order]{Group: g, Path: "/orders", ...}
+     ez.Crud(orders)
+     ez.Crud(ez.CrudConfig[OrderItem]{
+         Group: g, Path: "/orders/:id/items", IDParam: "itemId",
+         Parent: ez.ParentOf(orders, "OrderID"), ...
+     })
+   子资源不看自己的 Owner：每次请求先按父资源的可见范围校验父记录，查不到直接 404，
+   子表按外键 = 父 ID 过滤、创建时自动写外键
+*/
+
+type Parent struct {
+	Param  string                                      // 父 ID 的路由参数名
+	Field  string                                      // 子模型上的外键字段，如 "OrderID"
+	Verify func(c *gin.Context, parentID string) error // 父记录对当前请求不可见时返回 NotFound
+}
+
+// ParentOf 以父资源的 CrudConfig 构造 Parent（父资源自身也可以是嵌套的）
+func ParentOf[P any](parent CrudConfig[P], field string) *Parent {
+	keys := parent.keys()
+	if keys.composite() {
+		panic("ez: ParentOf does not support composite parent keys")
+	}
+	return &Parent{
+		Param: keys.params[0],
+		Field: field,
+		Verify: func(c *gin.Context, parentID string) error {
+			a, err := parent.access(c)
+			if err != nil {
+				return err
+			}
+			filter, err := parent.owned(a.owner, pk{parentID})
+			if err != nil {
+				return err
+			}
+			if err := filter.apply(parent.DB.WithContext(c)).First(parent.New()).Error; err != nil {
+				return NotFound("not found")
+			}
+			return nil
+		},
+	}
+}
+
+func (c *CrudConfig[T]) idParam() string {
+	if c.IDParam != "" {
+		return c.IDParam
+	}
+	return "id"
+}
+
+// 归属过滤：主键 + 归属列的显式等值条件（见 rowFilter）
+// id 为 nil 时只按归属过滤，owner 为空时不按归属过滤；id/owner 与字段类型不符时返回 400
+func (c *CrudConfig[T]) owned(owner string, id pk) (rowFilter, error) {
+	sch, err := parseSchema(c.DB, c.New())
+	if err != nil {
+		return nil, Internal("parse schema failed", err)
+	}
+	var f rowFilter
+	if id != nil {
+		if f, err = c.keys().filter(sch, id); err != nil {
+			return nil, err
+		}
+	}
+	if owner != "" {
+		e, found, err := fieldEq(sch, c.New(), c.ownerFieldCandidates(), owner)
+		if !found {
+			return nil, BadRequest("owner field not found")
+		}
+		if err != nil {
+			return nil, BadRequest("invalid owner")
+		}
+		f = append(f, e)
+	}
+	return f, nil
+}
+
+// access 解析本次请求的可见范围；嵌套资源的“归属”即父 ID
+func (c *CrudConfig[T]) access(ctx *gin.Context) (access, error) {
+	a, err := c.Scope.resolve(ctx)
+	if err != nil || c.Parent == nil {
+		return a, err
+	}
+	pid := ctx.Param(c.Parent.Param)
+	if err := c.Parent.Verify(ctx, pid); err != nil {
+		return access{}, err
+	}
+	return access{uid: a.uid, owner: pid}, nil
+}
+
+/* ---------- ?include= ---------- */
+
+type preloadSpec struct {
+	allowed map[string]string // 小写名 -> 配置里的关联路径
+}
+
+func newPreloadSpec(sch *schema.Schema, names []string) *preloadSpec {
+	p := &preloadSpec{allowed: map[string]string{}}
+	for _, n := range names {
+		// 逐段校验：Items.Product 要求 Items 是本模型的关联、Product 是 Items 模型的关联
+		cur := sch
+		for _, seg := range strings.Split(n, ".") {
+			rel, ok := cur.Relationships.Relations[seg]
+			if !ok || rel.FieldSchema == nil {
+				panic(fmt.Sprintf("ez: preload %q: %q is not a relation of %s", n, seg, cur.Name))
+			}
+			cur = rel.FieldSchema
+		}
+		p.allowed[strings.ToLower(n)] = n
+	}
+	return p
+}
+
+// parse 解析 ?include=；未声明的关联 400
+func (p *preloadSpec) parse(c *gin.Context) ([]string, error) {
+	raw := strings.TrimSpace(c.Query("include"))
+	if raw == "" {
+		return nil, nil
+	}
+	var rels []string
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		rel, ok := p.allowed[strings.ToLower(name)]
+		if !ok {
+			return nil, BadRequest(fmt.Sprintf("relation %q is not includable", name))
+		}
+		rels = append(rels, rel)
+	}
+	return rels, nil
+}
+
+// 预加载只加在取数据的查询上（COUNT 不能带 Preload）
+func preload(q *gorm.DB, rels []string) *gorm.DB {
+	for _, rel := range rels {
+		q = q.Preload(rel)
+	}
+	return q
+}
@@ -0,0 +1,157 @@
+package ez_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	httpez "go-gin-gorm-starter/internal/transport/http/ez"
+)
+
+type relCustomer struct {
+	ID     int64  `gorm:"primaryKey" json:"id"`
+	Name   string `json:"name"`
+	Secret string `json:"secret" ez:"-"`
+}
+
+type relProduct struct {
+	ID   int64  `gorm:"primaryKey" json:"id"`
+	Name string `json:"name"`
+	Cost int    `json:"cost" ez:"read=admin"`
+}
+
+type relOrder struct {
+	ID         int64        `gorm:"primaryKey" json:"id"`
+	OwnerID    string       `gorm:"size:36;index" json:"ownerId"`
+	Title      string       `json:"title"`
+	CustomerID int64        `json:"customerId"`
+	Customer   *relCustomer `json:"customer,omitempty"`
+	Lines      []relLine    `gorm:"foreignKey:OrderID" json:"lines,omitempty"`
+}
+
+type relLine struct {
+	ID        int64       `gorm:"primaryKey" json:"id"`
+	OrderID   int64       `gorm:"index" json:"orderId"`
+	ProductID int64       `json:"productId"`
+	Product   *relProduct `json:"product,omitempty"`
+	Qty       int         `json:"qty"`
+}
+
+func TestRelations(t *testing.T) {
+	db := newDB(t)
+	if err := db.AutoMigrate(&relCustomer{}, &relProduct{}); err != nil {
+		t.Fatal(err)
+	}
+	db.Create(&relCustomer{ID: 1, Name: "acme", Secret: "TOPSECRET"})
+	db.Create(&relProduct{ID: 7, Name: "widget", Cost: 42})
+	r, g := newEngine(t)
+	orders := httpez.CrudConfig[relOrder]{
+		DB: db, Group: g, Path: "/orders", New: func() *relOrder { return &relOrder{} },
+		Preloads: []string{"Customer", "Lines", "Lines.Product"},
+		Scope:    httpez.Scope{Mode: httpez.ScopeRoleBypass},
+	}
+	httpez.Crud(orders)
+	httpez.Crud(httpez.CrudConfig[relLine]{
+		DB: db, Group: g, Path: "/orders/:id/lines", IDParam: "lineId", New: func() *relLine { return &relLine{} },
+		Parent: httpez.ParentOf(orders, "OrderID"),
+	})
+	alice, bob := as("alice"), as("bob")
+
+	var orderIDs []int64
+	for _, title := range []string{"first", "second"} {
+		_, res := call(t, r, http.MethodPost, "/api/orders", alice, map[string]any{"title": title, "customerId": 1})
+		orderIDs = append(orderIDs, decode[relOrder](t, res).ID)
+	}
+	lines := func(order int64) string { return fmt.Sprintf("/api/orders/%d/lines", order) }
+
+	// 子资源：外键取自路由，请求体里的 orderId 被忽略
+	w, res := call(t, r, http.MethodPost, lines(orderIDs[0]), alice, map[string]any{"productId": 7, "qty": 2, "orderId": orderIDs[1]})
+	if w.Code != http.StatusOK {
+		t.Fatalf("create line: %d %s", w.Code, w.Body)
+	}
+	line := decode[relLine](t, res)
+	if line.OrderID != orderIDs[0] {
+		t.Fatalf("line order %d, want %d", line.OrderID, orderIDs[0])
+	}
+	call(t, r, http.MethodPost, lines(orderIDs[1]), alice, map[string]any{"productId": 7, "qty": 5})
+
+	_, res = call(t, r, http.MethodGet, lines(orderIDs[0]), alice, nil)
+	if page := decode[struct{ List []relLine }](t, res); len(page.List) != 1 || page.List[0].ID != line.ID {
+		t.Fatalf("lines of first order: %+v", page)
+	}
+	// 子资源只能经由所属父资源访问
+	if w, _ := call(t, r, http.MethodGet, fmt.Sprintf("%s/%d", lines(orderIDs[1]), line.ID), alice, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("line via other order: %d, want 404", w.Code)
+	}
+	if w, _ := call(t, r, http.MethodGet, fmt.Sprintf("%s/%d", lines(orderIDs[0]), line.ID), alice, nil); w.Code != http.StatusOK {
+		t.Fatalf("get line: %d %s", w.Code, w.Body)
+	}
+	// 父资源不可见时子资源一律 404
+	for _, tc := range []struct{ method, path string }{
+		{http.MethodGet, lines(orderIDs[0])},
+		{http.MethodPost, lines(orderIDs[0])},
+		{http.MethodGet, fmt.Sprintf("%s/%d", lines(orderIDs[0]), line.ID)},
+		{http.MethodDelete, fmt.Sprintf("%s/%d", lines(orderIDs[0]), line.ID)},
+		{http.MethodGet, lines(999)},
+	} {
+		if w, _ := call(t, r, tc.method, tc.path, bob, map[string]any{"productId": 7}); w.Code != http.StatusNotFound {
+			t.Errorf("bob %s %s: %d, want 404", tc.method, tc.path, w.Code)
+		}
+	}
+	if n := count[relLine](t, db); n != 2 {
+		t.Fatalf("%d lines, want 2", n)
+	}
+
+	// ?include=：按声明预加载，嵌套路径可用
+	path := fmt.Sprintf("/api/orders/%d", orderIDs[0])
+	_, res = call(t, r, http.MethodGet, path, alice, nil)
+	if o := decode[relOrder](t, res); o.Customer != nil || o.Lines != nil {
+		t.Fatalf("relations loaded without include: %+v", o)
+	}
+	_, res = call(t, r, http.MethodGet, path+"?include=customer,lines.product", alice, nil)
+	o := decode[relOrder](t, res)
+	if o.Customer == nil || o.Customer.Name != "acme" {
+		t.Fatalf("customer not included: %+v", o)
+	}
+	if len(o.Lines) != 1 || o.Lines[0].Product == nil || o.Lines[0].Product.Name != "widget" {
+		t.Fatalf("lines.product not included: %+v", o.Lines)
+	}
+	// 关联模型上的字段规则同样生效（单条、列表、多级）
+	w, _ = call(t, r, http.MethodGet, path+"?include=customer,lines.product", alice, nil)
+	if body := w.Body.String(); strings.Contains(body, "TOPSECRET") || strings.Contains(body, `"secret"`) || strings.Contains(body, `"cost"`) {
+		t.Fatalf("included relation leaks hidden fields: %s", body)
+	}
+	w, _ = call(t, r, http.MethodGet, "/api/orders?include=customer,lines.product", alice, nil)
+	if body := w.Body.String(); strings.Contains(body, "TOPSECRET") || strings.Contains(body, `"cost"`) {
+		t.Fatalf("included relation in list leaks hidden fields: %s", body)
+	}
+	_, res = call(t, r, http.MethodGet, path+"?include=customer,lines.product", as("root", "admin"), nil)
+	if o := decode[relOrder](t, res); o.Customer.Secret != "" || o.Lines[0].Product.Cost != 42 {
+		t.Fatalf("admin include: customer %+v product %+v", o.Customer, o.Lines[0].Product)
+	}
+
+	_, res = call(t, r, http.MethodGet, "/api/orders?include=lines", alice, nil)
+	page := decode[struct{ List []relOrder }](t, res)
+	if len(page.List) != 2 || len(page.List[0].Lines) != 1 || len(page.List[1].Lines) != 1 {
+		t.Fatalf("list include lines: %+v", page.List)
+	}
+	if w, _ := call(t, r, http.MethodGet, path+"?include=owner", alice, nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("undeclared include: %d, want 400", w.Code)
+	}
+
+	// 多级 include 的每一段都要是关联
+	for i, bad := range []string{"Title", "Lines.Qty", "Lines.Bogus", "Customer.Lines"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("preload %q did not panic", bad)
+				}
+			}()
+			httpez.Crud(httpez.CrudConfig[relOrder]{
+				DB: db, Group: g, Path: fmt.Sprintf("/broken%d", i), New: func() *relOrder { return &relOrder{} },
+				Preloads: []string{bad},
+			})
+		}()
+	}
+}
@@ -0,0 +1,87 @@
+package ez
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"go-gin-gorm-starter/internal/core/auth"
+)
+
+/* ================== 可见范围：归属过滤策略 ==================
+   ScopeOwner      只能读写自己的记录（默认，按 OwnerID/UserID 过滤）
+   ScopeRoleBypass 角色命中 BypassRoles（默认 admin）时不限归属，其余人仍按归属过滤
+   ScopeCustom     由 Predicate 判断本次请求是否不限归属
+   不限归属时可用 ?owner=<uid> 只看某个用户的记录
+*/
+
+type ScopeMode int
+
+const (
+	ScopeOwner ScopeMode = iota
+	ScopeRoleBypass
+	ScopeCustom
+)
+
+type Scope struct {
+	Mode        ScopeMode
+	BypassRoles []string                  // ScopeRoleBypass：默认 admin
+	Predicate   func(c *gin.Context) bool // ScopeCustom：返回 true 表示不限归属
+	OwnerParam  string                    // 按归属筛选的 query 参数，默认 "owner"
+}
+
+// 单次请求的访问范围
+type access struct {
+	uid   string // 当前登录用户
+	owner string // 归属过滤值；all 且未指定 ?owner= 时为空（不过滤）
+	all   bool   // 是否不限归属
+}
+
+func (s *Scope) check(model string) {
+	if s.Mode == ScopeCustom && s.Predicate == nil {
+		panic("ez: ScopeCustom requires Scope.Predicate on " + model)
+	}
+}
+
+func (s *Scope) ownerParam() string {
+	if s.OwnerParam != "" {
+		return s.OwnerParam
+	}
+	return "owner"
+}
+
+func (s *Scope) resolve(c *gin.Context) (access, error) {
+	uid := auth.UserID(c)
+	if uid == "" {
+		return access{}, Unauthorized("unauthorized")
+	}
+	var all bool
+	switch s.Mode {
+	case ScopeRoleBypass:
+		roles := s.BypassRoles
+		if len(roles) == 0 {
+			roles = []string{"admin"}
+		}
+		all = hasRole(auth.Roles(c), roles)
+	case ScopeCustom:
+		all = s.Predicate(c)
+	}
+	if !all {
+		return access{uid: uid, owner: uid}, nil
+	}
+	return access{uid: uid, owner: strings.TrimSpace(c.Query(s.ownerParam())), all: true}, nil
+}
+
+// 新建记录的归属：受限时强制为 owner（自己，嵌套资源为父 ID）；不限归属时优先请求体，其次 ?owner=，最后自己
+func (a access) createOwner(fromBody string) string {
+	if !a.all {
+		return a.owner
+	}
+	if strings.TrimSpace(fromBody) != "" {
+		return fromBody
+	}
+	if a.owner != "" {
+		return a.owner
+	}
+	return a.uid
+}
@@ -0,0 +1,137 @@
+package ez_test
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	httpez "go-gin-gorm-starter/internal/transport/http/ez"
+)
+
+type scopedTicket struct {
+	ID      int64  `gorm:"primaryKey" json:"id"`
+	OwnerID string `gorm:"size:36;index" json:"ownerId"`
+	Title   string `json:"title"`
+}
+
+type ticketPage struct {
+	List  []scopedTicket `json:"list"`
+	Total int            `json:"total"`
+}
+
+func TestScopeRoleBypass(t *testing.T) {
+	db := newDB(t)
+	r, g := newEngine(t)
+	httpez.Crud(httpez.CrudConfig[scopedTicket]{
+		DB: db, Group: g, Path: "/tickets", New: func() *scopedTicket { return &scopedTicket{} },
+		Scope: httpez.Scope{Mode: httpez.ScopeRoleBypass, BypassRoles: []string{"support"}},
+	})
+	alice, bob, agent := as("alice"), as("bob"), as("sam", "support")
+	post := func(h http.Header, body map[string]any) scopedTicket {
+		t.Helper()
+		_, res := call(t, r, http.MethodPost, "/api/tickets", h, body)
+		return decode[scopedTicket](t, res)
+	}
+	list := func(h http.Header, query string) ticketPage {
+		t.Helper()
+		_, res := call(t, r, http.MethodGet, "/api/tickets"+query, h, nil)
+		return decode[ticketPage](t, res)
+	}
+
+	a := post(alice, map[string]any{"title": "a", "ownerId": "bob"})
+	if a.OwnerID != "alice" {
+		t.Fatalf("user chose owner %q", a.OwnerID)
+	}
+	post(bob, map[string]any{"title": "b"})
+	if c := post(agent, map[string]any{"title": "c", "ownerId": "bob"}); c.OwnerID != "bob" {
+		t.Fatalf("bypass role create owner %q, want bob", c.OwnerID)
+	}
+	if d := post(agent, map[string]any{"title": "d"}); d.OwnerID != "sam" {
+		t.Fatalf("bypass role default owner %q, want sam", d.OwnerID)
+	}
+
+	// 列表：普通用户只看自己（?owner= 无效），绕过角色看全部或按 ?owner= 筛选
+	if p := list(alice, "?owner=bob"); p.Total != 1 || len(p.List) != 1 || p.List[0].OwnerID != "alice" {
+		t.Fatalf("alice list: %+v", p)
+	}
+	if p := list(agent, ""); p.Total != 4 {
+		t.Fatalf("agent list total %d, want 4", p.Total)
+	}
+	if p := list(agent, "?owner=bob"); p.Total != 2 {
+		t.Fatalf("agent list ?owner=bob total %d, want 2", p.Total)
+	}
+	if p := list(as("root", "admin"), ""); p.Total != 0 {
+		t.Fatalf("role outside BypassRoles sees %d tickets", p.Total)
+	}
+
+	// 单条：绕过角色可读写别人的记录，归属保持不变
+	path := "/api/tickets/" + strconv.FormatInt(a.ID, 10)
+	if w, _ := call(t, r, http.MethodGet, path, bob, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("bob reads alice's ticket: %d, want 404", w.Code)
+	}
+	if w, _ := call(t, r, http.MethodPut, path, agent, map[string]any{"title": "edited", "ownerId": "sam"}); w.Code != http.StatusOK {
+		t.Fatalf("agent update: %d %s", w.Code, w.Body)
+	}
+	var got scopedTicket
+	db.First(&got, a.ID)
+	if got.Title != "edited" || got.OwnerID != "alice" {
+		t.Fatalf("after agent update: %+v", got)
+	}
+	if w, _ := call(t, r, http.MethodDelete, path, bob, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("bob deletes alice's ticket: %d, want 404", w.Code)
+	}
+	if w, _ := call(t, r, http.MethodDelete, path, agent, nil); w.Code != http.StatusOK {
+		t.Fatalf("agent delete: %d %s", w.Code, w.Body)
+	}
+
+	if w, _ := call(t, r, http.MethodGet, "/api/tickets", nil, nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("anonymous list: %d, want 401", w.Code)
+	}
+}
+
+func TestScopeCustom(t *testing.T) {
+	db := newDB(t)
+	r, g := newEngine(t)
+	httpez.Crud(httpez.CrudConfig[scopedTicket]{
+		DB: db, Group: g, Path: "/tickets", New: func() *scopedTicket { return &scopedTicket{} },
+		AllowCreate: true, AllowList: true,
+		Scope: httpez.Scope{
+			Mode:       httpez.ScopeCustom,
+			Predicate:  func(c *gin.Context) bool { return c.GetHeader("X-Audit") == "on" },
+			OwnerParam: "user",
+		},
+	})
+	for _, uid := range []string{"alice", "bob", "bob"} {
+		call(t, r, http.MethodPost, "/api/tickets", as(uid), map[string]any{"title": uid})
+	}
+	audit := with(as("carol"), "X-Audit", "on")
+	cases := []struct {
+		hdr   http.Header
+		query string
+		want  int
+	}{
+		{as("bob"), "", 2},
+		{as("carol"), "?user=bob", 0},
+		{audit, "", 3},
+		{audit, "?user=bob", 2},
+		{audit, "?owner=bob", 3},
+	}
+	for _, tc := range cases {
+		_, res := call(t, r, http.MethodGet, "/api/tickets"+tc.query, tc.hdr, nil)
+		if got := decode[ticketPage](t, res).Total; got != tc.want {
+			t.Errorf("%s %s%s: total %d, want %d", tc.hdr.Get("X-User"), tc.hdr.Get("X-Audit"), tc.query, got, tc.want)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("ScopeCustom without Predicate did not panic")
+		}
+	}()
+	httpez.Crud(httpez.CrudConfig[scopedTicket]{
+		DB: db, Group: g, Path: "/broken", New: func() *scopedTicket { return &scopedTicket{} },
+		Scope: httpez.Scope{Mode: httpez.ScopeCustom},
+	})
+}
@@ -0,0 +1,132 @@
+package ez_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"go-gin-gorm-starter/internal/core/auth"
+	mdw "go-gin-gorm-starter/internal/transport/http/middleware"
+	resp "go-gin-gorm-starter/internal/transport/http/response"
+)
+
+// 公用：SQLite 文件库 + 用请求头模拟登录主体（X-User / X-Roles，代替 AuthJWT）
+
+func newDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// newEngine 返回 status 模式的引擎和挂了模拟鉴权的 /api 分组
+func newEngine(t *testing.T) (*gin.Engine, *gin.RouterGroup) {
+	t.Helper()
+	return newModeEngine(t, resp.ModeStatus)
+}
+
+func newModeEngine(t *testing.T, mode resp.Mode) (*gin.Engine, *gin.RouterGroup) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(resp.UseMode(mode))
+	g := r.Group("/api")
+	g.Use(func(c *gin.Context) {
+		if uid := c.GetHeader("X-User"); uid != "" {
+			var roles []string
+			if rs := c.GetHeader("X-Roles"); rs != "" {
+				roles = strings.Split(rs, ",")
+			}
+			mdw.SetPrincipal(c, &auth.Principal{UserID: uid, Roles: roles, Method: "test"})
+		}
+		c.Next()
+	})
+	return r, g
+}
+
+// as 登录主体请求头
+func as(uid string, roles ...string) http.Header {
+	h := http.Header{}
+	h.Set("X-User", uid)
+	if len(roles) > 0 {
+		h.Set("X-Roles", strings.Join(roles, ","))
+	}
+	return h
+}
+
+type envelope struct {
+	Code int             `json:"code"`
+	Msg  string          `json:"msg"`
+	Data json.RawMessage `json:"data"`
+}
+
+// call 发请求；body 为 string 时原样发送，否则按 JSON 编码
+func call(t *testing.T, h http.Handler, method, path string, hdr http.Header, body any) (*httptest.ResponseRecorder, envelope) {
+	t.Helper()
+	var rd io.Reader = http.NoBody
+	switch b := body.(type) {
+	case nil:
+	case string:
+		rd = strings.NewReader(b)
+	default:
+		raw, _ := json.Marshal(b)
+		rd = bytes.NewReader(raw)
+	}
+	req := httptest.NewRequest(method, path, rd)
+	req.Header.Set("Content-Type", "application/json")
+	for k, vs := range hdr {
+		req.Header[k] = vs
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	var e envelope
+	if strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		if err := json.Unmarshal(w.Body.Bytes(), &e); err != nil {
+			t.Fatalf("%s %s: bad body %q", method, path, w.Body.String())
+		}
+	}
+	return w, e
+}
+
+func decode[T any](t *testing.T, e envelope) T {
+	t.Helper()
+	var v T
+	if err := json.Unmarshal(e.Data, &v); err != nil {
+		t.Fatalf("decode %s: %v", e.Data, err)
+	}
+	return v
+}
+
+// with 在 h 上追加请求头
+func with(h http.Header, kv ...string) http.Header {
+	out := h.Clone()
+	if out == nil {
+		out = http.Header{}
+	}
+	for i := 0; i+1 < len(kv); i += 2 {
+		out.Set(kv[i], kv[i+1])
+	}
+	return out
+}
+
+func count[T any](t *testing.T, db *gorm.DB) int64 {
+	t.Helper()
+	var n int64
+	if err := db.Unscoped().Model(new(T)).Count(&n).Error; err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
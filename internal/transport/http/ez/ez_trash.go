@@ -0,0 +1,250 @@
+package ez
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"go-gin-gorm-starter/internal/core/auth"
+	resp "go-gin-gorm-starter/internal/transport/http/response"
+)
+
+/* ================== 软删生命周期：回收站 / 恢复 / 彻底删除 ==================
+   GET    /path/trash         回收站（按 Scope 过滤归属，同样经过 Hooks.ScopeList，分页）
+   POST   /path/:id/restore   恢复
+   DELETE /path/:id/purge     彻底删除（仅 PurgeRoles，默认 admin）
+   TrashRetentionDays > 0 时由 StartTrashSweeper 定期清理过期条目：按表去重，
+   哪个进程注册了该资源就由哪个进程清理（cmd/api 与 cmd/admin 都会启动清理器，删除是幂等的）
+*/
+
+type trashSpec[T any] struct {
+	cfg       *CrudConfig[T]
+	table     string
+	deletedAt *schema.Field
+	owned     func(owner string, id pk) (rowFilter, error)
+}
+
+func newTrashSpec[T any](cfg *CrudConfig[T], sch *schema.Schema, owned func(owner string, id pk) (rowFilter, error)) *trashSpec[T] {
+	for _, f := range sch.Fields {
+		if isDeletedAt(f) && f.DBName != "" {
+			return &trashSpec[T]{cfg: cfg, table: sch.Table, deletedAt: f, owned: owned}
+		}
+	}
+	panic("ez: AllowTrash requires a gorm.DeletedAt field on " + sch.Name)
+}
+
+func (t *trashSpec[T]) trashed() clause.Expression {
+	return clause.Neq{Column: column(t.deletedAt), Value: nil}
+}
+
+func (t *trashSpec[T]) mount(spec *listSpec, fields *fieldSpec) {
+	cfg := t.cfg
+	keys := cfg.keys()
+	purgeRoles := cfg.PurgeRoles
+	if len(purgeRoles) == 0 {
+		purgeRoles = []string{"admin"}
+	}
+
+	cfg.Group.GET(cfg.Path+"/trash", func(c *gin.Context) {
+		a, err := cfg.access(c)
+		if err != nil {
+			resp.JSON(c, errResp(err))
+			return
+		}
+		page := atoiDefault(c.Query("page"), 1)
+		size := atoiDefault(c.Query("size"), 20)
+		if size <= 0 || size > 100 {
+			size = 20
+		}
+		proj, err := fields.projection(c)
+		if err != nil {
+			resp.JSON(c, errResp(err))
+			return
+		}
+
+		filter, err := t.owned(a.owner, nil)
+		if err != nil {
+			resp.JSON(c, errResp(err))
+			return
+		}
+		q := filter.apply(cfg.DB.WithContext(c).Unscoped().Model(cfg.New())).Where(t.trashed())
+		if cfg.Hooks.ScopeList != nil {
+			q = cfg.Hooks.ScopeList(c, q)
+		}
+		values := c.Request.URL.Query()
+		if a.all {
+			values.Del(cfg.Scope.ownerParam())
+		}
+		q, err = spec.applyFilters(values, q)
+		if err != nil {
+			resp.JSON(c, resp.Error(resp.CodeBadRequest, err.Error()))
+			return
+		}
+		var total int64
+		if err := q.Count(&total).Error; err != nil {
+			resp.JSON(c, resp.Error(resp.CodeServerError, err.Error()))
+			return
+		}
+		var items []T
+		order := clause.OrderByColumn{Column: column(t.deletedAt), Desc: true}
+		if err := q.Order(order).Limit(size).Offset((page - 1) * size).Find(&items).Error; err != nil {
+			resp.JSON(c, resp.Error(resp.CodeServerError, err.Error()))
+			return
+		}
+		list, err := projectList(proj, items)
+		if err != nil {
+			resp.JSON(c, errResp(err))
+			return
+		}
+		resp.JSON(c, resp.OK(gin.H{
+			"list": list, "total": total, "page": page, "size": size,
+		}))
+	})
+
+	cfg.Group.POST(cfg.Path+keys.route()+"/restore", func(c *gin.Context) {
+		a, err := cfg.access(c)
+		if err != nil {
+			resp.JSON(c, errResp(err))
+			return
+		}
+		id := keys.fromParams(c)
+		filter, err := t.owned(a.owner, id)
+		if err != nil {
+			resp.JSON(c, errResp(err))
+			return
+		}
+		res := filter.apply(cfg.DB.WithContext(c).Unscoped().Model(cfg.New())).
+			Where(t.trashed()).
+			UpdateColumn(t.deletedAt.DBName, nil)
+		if res.Error != nil {
+			resp.JSON(c, resp.Error(resp.CodeServerError, res.Error.Error()))
+			return
+		}
+		if res.RowsAffected == 0 {
+			resp.JSON(c, resp.Error(resp.CodeNotFound, "not found in trash"))
+			return
+		}
+		resp.JSON(c, resp.OK(gin.H{"id": keys.out(id)}))
+	})
+
+	cfg.Group.DELETE(cfg.Path+keys.route()+"/purge", func(c *gin.Context) {
+		if auth.UserID(c) == "" {
+			resp.JSON(c, resp.Error(resp.CodeUnauthorized, "unauthorized"))
+			return
+		}
+		if !hasRole(auth.Roles(c), purgeRoles) {
+			resp.JSON(c, resp.Error(resp.CodeForbidden, "forbidden"))
+			return
+		}
+		// 管理员彻底删除不限归属，但只能删回收站里的；必须按完整主键命中恰好一行
+		id := keys.fromParams(c)
+		filter, err := t.owned("", id)
+		if err != nil {
+			resp.JSON(c, errResp(err))
+			return
+		}
+		if len(filter) != len(keys.names) {
+			resp.JSON(c, resp.Error(resp.CodeBadRequest, "missing id"))
+			return
+		}
+		err = cfg.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+			res := filter.apply(tx.Unscoped()).Where(t.trashed()).Delete(cfg.New())
+			switch {
+			case res.Error != nil:
+				return Internal(res.Error.Error(), res.Error)
+			case res.RowsAffected == 0:
+				return NotFound("not found in trash")
+			case res.RowsAffected > 1:
+				// 主键条件没生效（如列映射异常）：回滚，宁可不删
+				return Internal(fmt.Sprintf("purge matched %d rows, rolled back", res.RowsAffected), nil)
+			}
+			return nil
+		})
+		if err != nil {
+			resp.JSON(c, errResp(err))
+			return
+		}
+		resp.JSON(c, resp.OK(gin.H{"id": keys.out(id)}))
+	})
+
+	if cfg.TrashRetentionDays > 0 {
+		registerSweep(sweepTask{
+			table: t.table,
+			days:  cfg.TrashRetentionDays,
+			run: func(ctx context.Context, cutoff time.Time) (int64, error) {
+				res := cfg.DB.WithContext(ctx).Unscoped().
+					Where(clause.Lt{Column: column(t.deletedAt), Value: cutoff}).
+					Delete(cfg.New())
+				return res.RowsAffected, res.Error
+			},
+		})
+	}
+}
+
+// hasRole have 与 roles 有交集
+func hasRole(have, roles []string) bool {
+	for _, r := range roles {
+		if slices.Contains(have, r) {
+			return true
+		}
+	}
+	return false
+}
+
+/* ---------- 过期清理 ---------- */
+
+type sweepTask struct {
+	table string
+	days  int // 保留天数
+	run   func(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+var (
+	sweepMu    sync.Mutex
+	sweepTasks []sweepTask
+)
+
+// 同一张表挂了多个资源（如用户端、管理端各一条路径）时只留一个任务：
+// 保留期取最长的（不比任何一方约定的更早删除），清理用后注册的那个
+func registerSweep(t sweepTask) {
+	sweepMu.Lock()
+	defer sweepMu.Unlock()
+	for i, cur := range sweepTasks {
+		if cur.table == t.table {
+			t.days = max(t.days, cur.days)
+			sweepTasks[i] = t
+			return
+		}
+	}
+	sweepTasks = append(sweepTasks, t)
+}
+
+// StartTrashSweeper 每隔 every 清理一次超过保留期的回收站条目，直到 ctx 取消
+// report 可选：每张表每轮的清理结果（用于打日志）
+func StartTrashSweeper(ctx context.Context, every time.Duration, report func(table string, purged int64, err error)) {
+	tick := time.NewTicker(every)
+	defer tick.Stop()
+	for {
+		sweepMu.Lock()
+		tasks := append([]sweepTask(nil), sweepTasks...)
+		sweepMu.Unlock()
+		for _, t := range tasks {
+			n, err := t.run(ctx, time.Now().AddDate(0, 0, -t.days))
+			if report != nil && (n > 0 || err != nil) {
+				report(t.table, n, err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+		}
+	}
+}
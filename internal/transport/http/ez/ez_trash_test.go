@@ -0,0 +1,138 @@
+package ez_test
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	httpez "go-gin-gorm-starter/internal/transport/http/ez"
+)
+
+type trashItem struct {
+	ID        int64          `gorm:"primaryKey" json:"id"`
+	OwnerID   string         `gorm:"size:36;index" json:"ownerId"`
+	Name      string         `json:"name"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deletedAt"`
+}
+
+func TestTrashRestoreAndPurge(t *testing.T) {
+	db := newDB(t)
+	r, g := newEngine(t)
+	httpez.Crud(httpez.CrudConfig[trashItem]{
+		DB: db, Group: g, Path: "/items", New: func() *trashItem { return &trashItem{} },
+		AllowCreate: true, AllowList: true, AllowDelete: true, AllowTrash: true,
+	})
+	alice, admin := as("alice"), as("root", "admin")
+	path := func(id int64, suffix string) string { return "/api/items/" + strconv.FormatInt(id, 10) + suffix }
+
+	var ids []int64
+	for _, name := range []string{"a", "b", "c"} {
+		_, res := call(t, r, http.MethodPost, "/api/items", alice, map[string]any{"name": name})
+		ids = append(ids, decode[trashItem](t, res).ID)
+	}
+	for _, id := range ids {
+		if w, _ := call(t, r, http.MethodDelete, path(id, ""), alice, nil); w.Code != http.StatusOK {
+			t.Fatalf("soft delete: %d", w.Code)
+		}
+	}
+	_, res := call(t, r, http.MethodGet, "/api/items/trash", alice, nil)
+	if n := decode[struct{ Total int }](t, res).Total; n != 3 {
+		t.Fatalf("trash total %d, want 3", n)
+	}
+	if _, res := call(t, r, http.MethodGet, "/api/items/trash", as("bob"), nil); decode[struct{ Total int }](t, res).Total != 0 {
+		t.Fatalf("other user sees alice's trash")
+	}
+
+	// 恢复
+	if w, _ := call(t, r, http.MethodPost, path(ids[0], "/restore"), alice, nil); w.Code != http.StatusOK {
+		t.Fatalf("restore: %d %s", w.Code, w.Body)
+	}
+	if w, _ := call(t, r, http.MethodPost, path(ids[0], "/restore"), alice, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("restore twice: %d, want 404", w.Code)
+	}
+	if w, _ := call(t, r, http.MethodPost, path(0, "/restore"), alice, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("restore id 0: %d, want 404", w.Code)
+	}
+	if _, res := call(t, r, http.MethodGet, "/api/items", alice, nil); decode[struct{ Total int }](t, res).Total != 1 {
+		t.Fatalf("restored item not listed")
+	}
+
+	// 彻底删除：仅管理员、仅回收站里的、id 0 不会清空回收站
+	if w, _ := call(t, r, http.MethodDelete, path(ids[1], "/purge"), alice, nil); w.Code != http.StatusForbidden {
+		t.Fatalf("purge as user: %d, want 403", w.Code)
+	}
+	if w, _ := call(t, r, http.MethodDelete, path(0, "/purge"), admin, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("purge id 0: %d, want 404", w.Code)
+	}
+	if n := count[trashItem](t, db); n != 3 {
+		t.Fatalf("purge id 0 removed rows: %d left, want 3", n)
+	}
+	if w, _ := call(t, r, http.MethodDelete, path(ids[0], "/purge"), admin, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("purge live item: %d, want 404", w.Code)
+	}
+	if w, _ := call(t, r, http.MethodDelete, path(ids[1], "/purge"), admin, nil); w.Code != http.StatusOK {
+		t.Fatalf("purge: %d %s", w.Code, w.Body)
+	}
+	if n := count[trashItem](t, db); n != 2 {
+		t.Fatalf("%d rows left after purge, want 2", n)
+	}
+
+	// 回收站同样经过 ScopeList
+	httpez.Crud(httpez.CrudConfig[trashItem]{
+		DB: db, Group: g, Path: "/scoped-items", New: func() *trashItem { return &trashItem{} },
+		AllowTrash: true,
+		Hooks: httpez.CrudHooks[trashItem]{
+			ScopeList: func(c *gin.Context, q *gorm.DB) *gorm.DB { return q.Where("name <> ?", "c") },
+		},
+	})
+	for p, want := range map[string]int{"/api/items/trash": 1, "/api/scoped-items/trash": 0} {
+		if _, res := call(t, r, http.MethodGet, p, alice, nil); decode[struct{ Total int }](t, res).Total != want {
+			t.Errorf("%s total, want %d", p, want)
+		}
+	}
+}
+
+func TestTrashSweeper(t *testing.T) {
+	db := newDB(t)
+	_, g := newEngine(t)
+	// 同一张表挂两条路径：只清理一次，保留期取较长的 7 天
+	for path, days := range map[string]int{"/sweep-items": 7, "/admin/sweep-items": 2} {
+		httpez.Crud(httpez.CrudConfig[trashItem]{
+			DB: db, Group: g, Path: path, New: func() *trashItem { return &trashItem{} },
+			AllowTrash: true, TrashRetentionDays: days,
+		})
+	}
+	deleted := func(days int) gorm.DeletedAt {
+		return gorm.DeletedAt{Time: time.Now().AddDate(0, 0, -days), Valid: true}
+	}
+	db.Create(&[]trashItem{
+		{OwnerID: "alice", Name: "old", DeletedAt: deleted(8)},
+		{OwnerID: "alice", Name: "within 7 days", DeletedAt: deleted(4)},
+		{OwnerID: "alice", Name: "recent", DeletedAt: deleted(1)},
+		{OwnerID: "alice", Name: "live"},
+	})
+
+	// 启动即跑一轮，随后等下一个周期时 ctx 到期退出
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	purged := map[string]int64{}
+	reports := 0
+	httpez.StartTrashSweeper(ctx, time.Hour, func(table string, n int64, err error) {
+		if err != nil {
+			t.Errorf("sweep %s: %v", table, err)
+		}
+		purged[table] += n
+		reports++
+	})
+	if purged["trash_items"] != 1 || reports != 1 {
+		t.Fatalf("purged %v in %d reports, want 1 from trash_items once", purged, reports)
+	}
+	if n := count[trashItem](t, db); n != 3 {
+		t.Fatalf("%d rows left, want 3", n)
+	}
+}
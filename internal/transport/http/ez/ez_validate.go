@@ -0,0 +1,238 @@
+package ez
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	resp "go-gin-gorm-starter/internal/transport/http/response"
+)
+
+/* ================== 校验错误：结构化 + 本地化 ==================
+   绑定 / 校验失败统一返回 400，data.errors 为字段级错误列表：
+     {"code":400,"msg":"validation failed","data":{"errors":[{"field":"email","rule":"email","message":"email must be a valid email address"}]}}
+   - field 用 json 名（无 json tag 时依次取 form / uri / header tag），嵌套为 a.b / items[0].name
+   - message 按 Accept-Language 选语言（内置 en / zh），自定义规则用 RegisterValidationMessage 补模板
+*/
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+type validationData struct {
+	Errors []FieldError `json:"errors"`
+}
+
+// 模板占位：{field} {param}；min/max 等按字段类型细分：rule.string / rule.number / rule.slice
+var (
+	validationMu       sync.RWMutex
+	validationMessages = map[string]map[string]string{
+		"en": {
+			"_summary":      "validation failed",
+			"_default":      "{field} failed on the '{rule}' rule",
+			"type":          "{field} must be of type {param}",
+			"required":      "{field} is required",
+			"required_if":   "{field} is required",
+			"required_with": "{field} is required",
+			"email":         "{field} must be a valid email address",
+			"url":           "{field} must be a valid URL",
+			"uri":           "{field} must be a valid URI",
+			"uuid":          "{field} must be a valid UUID",
+			"numeric":       "{field} must be numeric",
+			"number":        "{field} must be a number",
+			"alpha":         "{field} may only contain letters",
+			"alphanum":      "{field} may only contain letters and digits",
+			"oneof":         "{field} must be one of [{param}]",
+			"eq":            "{field} must be equal to {param}",
+			"ne":            "{field} must not be equal to {param}",
+			"eqfield":       "{field} must be equal to {param}",
+			"datetime":      "{field} must match the format {param}",
+			"len.string":    "{field} must be {param} characters long",
+			"len.slice":     "{field} must contain {param} items",
+			"len":           "{field} must be equal to {param}",
+			"min.string":    "{field} must be at least {param} characters long",
+			"min.slice":     "{field} must contain at least {param} items",
+			"min":           "{field} must be {param} or greater",
+			"max.string":    "{field} must be at most {param} characters long",
+			"max.slice":     "{field} must contain at most {param} items",
+			"max":           "{field} must be {param} or less",
+			"gte.string":    "{field} must be at least {param} characters long",
+			"gte":           "{field} must be {param} or greater",
+			"lte.string":    "{field} must be at most {param} characters long",
+			"lte":           "{field} must be {param} or less",
+			"gt":            "{field} must be greater than {param}",
+			"lt":            "{field} must be less than {param}",
+		},
+		"zh": {
+			"_summary":      "参数校验失败",
+			"_default":      "{field}未通过'{rule}'校验",
+			"type":          "{field}的类型应为{param}",
+			"required":      "{field}为必填字段",
+			"required_if":   "{field}为必填字段",
+			"required_with": "{field}为必填字段",
+			"email":         "{field}必须是有效的邮箱地址",
+			"url":           "{field}必须是有效的URL",
+			"uri":           "{field}必须是有效的URI",
+			"uuid":          "{field}必须是有效的UUID",
+			"numeric":       "{field}必须是数字",
+			"number":        "{field}必须是数字",
+			"alpha":         "{field}只能包含字母",
+			"alphanum":      "{field}只能包含字母和数字",
+			"oneof":         "{field}必须是[{param}]中的一个",
+			"eq":            "{field}必须等于{param}",
+			"ne":            "{field}不能等于{param}",
+			"eqfield":       "{field}必须与{param}相同",
+			"datetime":      "{field}的格式必须是{param}",
+			"len.string":    "{field}长度必须是{param}个字符",
+			"len.slice":     "{field}必须包含{param}项",
+			"len":           "{field}必须等于{param}",
+			"min.string":    "{field}长度不能少于{param}个字符",
+			"min.slice":     "{field}至少包含{param}项",
+			"min":           "{field}不能小于{param}",
+			"max.string":    "{field}长度不能超过{param}个字符",
+			"max.slice":     "{field}最多包含{param}项",
+			"max":           "{field}不能大于{param}",
+			"gte.string":    "{field}长度不能少于{param}个字符",
+			"gte":           "{field}不能小于{param}",
+			"lte.string":    "{field}长度不能超过{param}个字符",
+			"lte":           "{field}不能大于{param}",
+			"gt":            "{field}必须大于{param}",
+			"lt":            "{field}必须小于{param}",
+		},
+	}
+)
+
+// RegisterValidationMessage 补充 / 覆盖某语言下某规则的消息模板（启动时调用）
+func RegisterValidationMessage(lang, rule, tmpl string) {
+	validationMu.Lock()
+	defer validationMu.Unlock()
+	lang = strings.ToLower(lang)
+	if validationMessages[lang] == nil {
+		validationMessages[lang] = map[string]string{}
+	}
+	validationMessages[lang][rule] = tmpl
+}
+
+func init() {
+	// 校验错误里的字段名用 json / form / uri / header 名，而不是 Go 字段名
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(validationFieldName)
+	}
+}
+
+func validationFieldName(sf reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "uri", "header"} {
+		if name, _, _ := strings.Cut(sf.Tag.Get(tag), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return sf.Name
+}
+
+// invalid 把绑定 / 校验错误转成带 data.errors 的 400；其它错误原样按 400 透出
+func invalid(c *gin.Context, err error) error {
+	lang := requestLang(c)
+	var ves validator.ValidationErrors
+	var te *json.UnmarshalTypeError
+	var list []FieldError
+	switch {
+	case errors.As(err, &ves):
+		for _, fe := range ves {
+			field := fe.Namespace()
+			if _, rest, ok := strings.Cut(field, "."); ok {
+				field = rest // 去掉根类型名
+			}
+			list = append(list, FieldError{
+				Field: field, Rule: fe.Tag(), Param: fe.Param(),
+				Message: validationMessage(lang, fe.Tag(), kindClass(fe.Kind()), field, fe.Param()),
+			})
+		}
+	case errors.As(err, &te) && te.Field != "":
+		param := jsonKind(te.Type)
+		list = []FieldError{{
+			Field: te.Field, Rule: "type", Param: param,
+			Message: validationMessage(lang, "type", "", te.Field, param),
+		}}
+	default:
+		return BadRequest(err.Error())
+	}
+	return &AErr{Code: 400, Msg: validationMessage(lang, "_summary", "", "", ""), Err: err, Data: validationData{Errors: list}}
+}
+
+// fieldErrors 取出 invalid 生成的字段错误（批量 / 导入逐条结果用）
+func fieldErrors(err error) []FieldError {
+	var ae *AErr
+	if errors.As(err, &ae) {
+		if d, ok := ae.Data.(validationData); ok {
+			return d.Errors
+		}
+	}
+	return nil
+}
+
+func validationMessage(lang, rule, class, field, param string) string {
+	validationMu.RLock()
+	defer validationMu.RUnlock()
+	tmpl := ""
+	for _, l := range []string{lang, resp.DefaultLang} {
+		m := validationMessages[l]
+		if class != "" && m[rule+"."+class] != "" {
+			tmpl = m[rule+"."+class]
+		} else if m[rule] != "" {
+			tmpl = m[rule]
+		}
+		if tmpl == "" {
+			tmpl = m["_default"]
+		}
+		if tmpl != "" {
+			break
+		}
+	}
+	return strings.NewReplacer("{field}", field, "{param}", param, "{rule}", rule).Replace(tmpl)
+}
+
+// requestLang 按 Accept-Language 挑一个已有消息的语言
+func requestLang(c *gin.Context) string {
+	validationMu.RLock()
+	defer validationMu.RUnlock()
+	return resp.NegotiateLang(c.GetHeader("Accept-Language"), func(lang string) bool {
+		_, ok := validationMessages[lang]
+		return ok
+	}, resp.DefaultLang)
+}
+
+func kindClass(k reflect.Kind) string {
+	switch k {
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return "slice"
+	}
+	return "number"
+}
+
+func jsonKind(t reflect.Type) string {
+	switch indirect(t).Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	}
+	return "object"
+}
@@ -0,0 +1,53 @@
+package ez_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	httpez "go-gin-gorm-starter/internal/transport/http/ez"
+)
+
+type signupIn struct {
+	Email string   `json:"email" binding:"required,email"`
+	Pass  string   `json:"password" binding:"min=6"`
+	Tags  []string `json:"tags" binding:"max=1"`
+}
+
+func TestValidationErrors(t *testing.T) {
+	r, g := newEngine(t)
+	httpez.RegisterAction(httpez.New(g), newDB(t), httpez.Action[signupIn, struct{}]{
+		Method: http.MethodPost, Path: "/signup", Binder: httpez.BindJSON,
+		Handler: func(*gin.Context, *gorm.DB, *signupIn) (struct{}, error) { return struct{}{}, nil },
+	})
+	body := map[string]any{"email": "x", "password": "1", "tags": []string{"a", "b"}}
+	type fieldErrs struct {
+		Errors []httpez.FieldError
+	}
+	messages := func(lang string) map[string]string {
+		t.Helper()
+		w, res := call(t, r, http.MethodPost, "/api/signup", http.Header{"Accept-Language": {lang}}, body)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: %d, want 400", lang, w.Code)
+		}
+		out := map[string]string{}
+		for _, e := range decode[fieldErrs](t, res).Errors {
+			out[e.Field+":"+e.Rule] = e.Message
+		}
+		return out
+	}
+
+	en := messages("")
+	if len(en) != 3 || en["email:email"] == "" || en["password:min"] == "" || en["tags:max"] == "" {
+		t.Fatalf("field errors %v", en)
+	}
+	// 按 Accept-Language 本地化；没有的语言回落到默认语言
+	if zh := messages("zh-CN,zh;q=0.9"); zh["email:email"] == en["email:email"] {
+		t.Fatalf("zh not localized: %v", zh)
+	}
+	if fr := messages("fr"); fr["password:min"] != en["password:min"] {
+		t.Fatalf("fr fallback %v, want %v", fr, en)
+	}
+}
@@ -52,8 +52,8 @@ func AccessLog(l *zap.Logger) gin.HandlerFunc {
 		c.Next()
 
 		q := mask(c.Request.URL.Query())
-		// 打印摘要：method/path/status/latency/ip/ua/query/size
-		l.Info("HTTP",
+		// 打印摘要：method/path/status/latency/ip/ua/query/size；handler 用 c.Error 记下的错误一并输出
+		fields := []zap.Field{
 			zap.String("rid", c.GetString("rid")),
 			zap.String("method", c.Request.Method),
 			zap.String("path", c.FullPath()),
@@ -63,6 +63,11 @@ func AccessLog(l *zap.Logger) gin.HandlerFunc {
 			zap.String("ua", c.Request.UserAgent()),
 			zap.Any("query", q),
 			zap.Int("size", w.size),
-		)
+		}
+		if len(c.Errors) > 0 {
+			l.Warn("HTTP", append(fields, zap.Strings("errors", c.Errors.Errors()))...)
+			return
+		}
+		l.Info("HTTP", fields...)
 	}
 }
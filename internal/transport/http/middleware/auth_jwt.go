@@ -1,7 +1,7 @@
 package middleware
 
 import (
-	"net/http"
+	"errors"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -13,19 +13,38 @@ func AuthJWT(j *auth.JWTer, requireRole string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ah := c.GetHeader("Authorization")
 		if !strings.HasPrefix(ah, "Bearer ") {
-			c.AbortWithStatusJSON(http.StatusOK, resp.Error(resp.CodeUnauthorized, "missing token"))
+			resp.Abort(c, resp.Error(resp.CodeUnauthorized, "missing token"))
 			return
 		}
 		claims, err := j.Parse(strings.TrimPrefix(ah, "Bearer "))
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusOK, resp.Error(resp.CodeUnauthorized, "invalid token"))
+			resp.Abort(c, resp.Error(resp.CodeUnauthorized, "invalid token"))
 			return
 		}
-		if requireRole != "" && claims.Role != requireRole {
-			c.AbortWithStatusJSON(http.StatusOK, resp.Error(resp.CodeForbidden, "forbidden"))
+		if err := j.Revoked(c, claims); err != nil {
+			if errors.Is(err, auth.ErrTokenRevoked) {
+				resp.Abort(c, resp.Error(resp.CodeUnauthorized, "token revoked"))
+			} else {
+				resp.Abort(c, resp.Error(resp.CodeServiceUnavailable, "token check failed"))
+			}
 			return
 		}
+		p := auth.PrincipalFromClaims(claims)
+		if requireRole != "" && !p.HasRole(requireRole) {
+			resp.Abort(c, resp.Error(resp.CodeForbidden, "forbidden"))
+			return
+		}
+		SetPrincipal(c, p)
 		c.Set("claims", claims)
 		c.Next()
 	}
 }
+
+// SetPrincipal 登记当前主体：gin 上下文 + 请求 context（auth.PrincipalFrom 两处都能取到）；
+// 同时写 userId / role 键，兼容直接 c.GetString 的旧代码
+func SetPrincipal(c *gin.Context, p *auth.Principal) {
+	c.Set(auth.ContextKey, p)
+	c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), p))
+	c.Set("userId", p.UserID)
+	c.Set("role", p.Role())
+}
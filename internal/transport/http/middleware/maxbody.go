@@ -12,7 +12,7 @@ func MaxBodyBytes(n int64) gin.HandlerFunc {
 		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, n)
 		c.Next()
 		if c.Err() != nil && !c.Writer.Written() {
-			c.AbortWithStatusJSON(http.StatusOK, resp.Error(resp.CodeBadRequest, "request body too large"))
+			resp.Abort(c, resp.Error(resp.CodeBadRequest, "request body too large"))
 		}
 	}
 }
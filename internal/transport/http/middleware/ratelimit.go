@@ -4,7 +4,6 @@ import (
 	"github.com/gin-gonic/gin"
 	resp "go-gin-gorm-starter/internal/transport/http/response"
 	"golang.org/x/time/rate"
-	"net/http"
 )
 
 // RateLimit 全局令牌桶限速
@@ -15,7 +14,7 @@ func RateLimit(rps rate.Limit, burst int) gin.HandlerFunc {
 			c.Next()
 			return
 		}
-		c.AbortWithStatusJSON(http.StatusOK, resp.Error(resp.CodeServerError, "too many requests"))
+		abortBusy(c, resp.CodeTooManyRequests, "too many requests")
 	}
 }
 
@@ -33,6 +32,14 @@ func RateLimitPerIP(rps rate.Limit, burst int) gin.HandlerFunc {
 			c.Next()
 			return
 		}
-		c.AbortWithStatusJSON(http.StatusOK, resp.Error(resp.CodeServerError, "too many requests"))
+		abortBusy(c, resp.CodeTooManyRequests, "too many requests")
 	}
 }
+
+// abortBusy 限流 / 过载拒绝：legacy 模式沿用老客户端认的业务码 500，status / problem 模式用 code（429 / 503）
+func abortBusy(c *gin.Context, code int, msg string) {
+	if resp.ModeOf(c) == resp.ModeLegacy {
+		code = resp.CodeServerError
+	}
+	resp.Abort(c, resp.Error(code, msg))
+}
@@ -0,0 +1,74 @@
+package middleware_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	mdw "go-gin-gorm-starter/internal/transport/http/middleware"
+	resp "go-gin-gorm-starter/internal/transport/http/response"
+)
+
+// 限流拒绝：legacy 保持 HTTP 200 + 业务码 500，status / problem 用真实的 429
+func TestRateLimitByMode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cases := []struct {
+		mode         resp.Mode
+		status, code int
+		ctype        string
+	}{
+		{resp.ModeLegacy, http.StatusOK, resp.CodeServerError, "application/json"},
+		{resp.ModeStatus, http.StatusTooManyRequests, resp.CodeTooManyRequests, "application/json"},
+		{resp.ModeProblem, http.StatusTooManyRequests, resp.CodeTooManyRequests, resp.MIMEProblem},
+	}
+	for _, tc := range cases {
+		r := gin.New()
+		r.Use(resp.UseMode(tc.mode), mdw.RateLimit(0, 1))
+		r.GET("/", func(c *gin.Context) { resp.JSON(c, resp.OK(nil)) })
+
+		if w := get(r); w.Code != http.StatusOK {
+			t.Fatalf("mode %d: first request %d", tc.mode, w.Code)
+		}
+		w := get(r)
+		var body struct{ Code int }
+		_ = json.Unmarshal(w.Body.Bytes(), &body)
+		if w.Code != tc.status || body.Code != tc.code || !strings.HasPrefix(w.Header().Get("Content-Type"), tc.ctype) {
+			t.Fatalf("mode %d: %d %s %s, want %d code %d", tc.mode, w.Code, w.Header().Get("Content-Type"), w.Body, tc.status, tc.code)
+		}
+	}
+}
+
+func TestConcurrencyLimitByMode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for mode, want := range map[resp.Mode][2]int{
+		resp.ModeLegacy: {http.StatusOK, resp.CodeServerError},
+		resp.ModeStatus: {http.StatusServiceUnavailable, resp.CodeServiceUnavailable},
+	} {
+		r := gin.New()
+		r.ContextWithFallback = true                       // c.Done() 跟随请求 ctx
+		r.Use(resp.UseMode(mode), mdw.ConcurrencyLimit(0)) // 0 个名额：等到请求 ctx 取消即拒绝
+		r.GET("/", func(c *gin.Context) { resp.JSON(c, resp.OK(nil)) })
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		ctx, cancel := context.WithCancel(req.Context())
+		cancel()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req.WithContext(ctx))
+		var body struct{ Code int }
+		_ = json.Unmarshal(w.Body.Bytes(), &body)
+		if w.Code != want[0] || body.Code != want[1] {
+			t.Fatalf("mode %d: %d %s, want %d code %d", mode, w.Code, w.Body, want[0], want[1])
+		}
+	}
+}
+
+func get(h http.Handler) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	return w
+}
@@ -1,8 +1,6 @@
 package middleware
 
 import (
-	"net/http"
-
 	"github.com/gin-gonic/gin"
 	resp "go-gin-gorm-starter/internal/transport/http/response"
 )
@@ -11,7 +9,7 @@ func SimpleRecovery() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
 			if rec := recover(); rec != nil {
-				c.AbortWithStatusJSON(http.StatusOK, resp.Error(resp.CodeServerError, "internal error"))
+				resp.Abort(c, resp.Error(resp.CodeServerError, "internal error"))
 			}
 		}()
 		c.Next()
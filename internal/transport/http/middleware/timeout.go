@@ -2,7 +2,6 @@ package middleware
 
 import (
 	"context"
-	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -16,7 +15,7 @@ func Timeout(d time.Duration) gin.HandlerFunc {
 		c.Request = c.Request.WithContext(ctx)
 		c.Next()
 		if ctx.Err() == context.DeadlineExceeded && !c.Writer.Written() {
-			c.AbortWithStatusJSON(http.StatusOK, resp.Error(504, "timeout"))
+			resp.Abort(c, resp.Error(resp.CodeGatewayTimeout, "timeout"))
 		}
 	}
 }
@@ -7,7 +7,14 @@ const (
 	CodeUnauthorized = 401
 	CodeForbidden    = 403
 	CodeNotFound     = 404
+	CodeConflict     = 409
 	CodeServerError  = 500
+
+	CodePreconditionFailed   = 412
+	CodePreconditionRequired = 428
+	CodeTooManyRequests      = 429
+	CodeServiceUnavailable   = 503
+	CodeGatewayTimeout       = 504
 )
 
 // CodeMsgMap 用于集中管理 code - msg
@@ -17,5 +24,12 @@ var CodeMsgMap = map[int]string{
 	CodeUnauthorized: "Unauthorized",
 	CodeForbidden:    "Forbidden",
 	CodeNotFound:     "Not Found",
+	CodeConflict:     "Conflict",
 	CodeServerError:  "Internal Server Error",
+
+	CodePreconditionFailed:   "Precondition Failed",
+	CodePreconditionRequired: "Precondition Required",
+	CodeTooManyRequests:      "Too Many Requests",
+	CodeServiceUnavailable:   "Service Unavailable",
+	CodeGatewayTimeout:       "Gateway Timeout",
 }
@@ -0,0 +1,119 @@
+package response
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Mode 响应写法（按引擎选择，老客户端保持 ModeLegacy）
+type Mode int
+
+const (
+	ModeLegacy  Mode = iota // 一律 HTTP 200，业务码只在 body.code
+	ModeStatus              // 信封不变，HTTP 状态码跟随业务码（404 → 404）
+	ModeProblem             // 出错时返回 RFC 7807 application/problem+json，成功仍是信封
+)
+
+const modeKey = "resp.mode"
+
+// ParseMode 配置值 → Mode：""/legacy、status、problem
+func ParseMode(s string) (Mode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "legacy":
+		return ModeLegacy, nil
+	case "status":
+		return ModeStatus, nil
+	case "problem":
+		return ModeProblem, nil
+	}
+	return ModeLegacy, fmt.Errorf("unknown response mode %q (want legacy|status|problem)", s)
+}
+
+// UseMode 中间件：为本引擎设定响应写法，需放在其它中间件之前
+func UseMode(m Mode) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(modeKey, m)
+		c.Next()
+	}
+}
+
+func ModeOf(c *gin.Context) Mode {
+	if v, ok := c.Get(modeKey); ok {
+		if m, ok := v.(Mode); ok {
+			return m
+		}
+	}
+	return ModeLegacy
+}
+
+// HTTPStatus 业务码 → HTTP 状态码：优先取注册表里的 Status，未注册的按 fallbackStatus 推断
+func HTTPStatus(code int) int {
+	if d, ok := LookupCode(code); ok {
+		return d.Status
+	}
+	return fallbackStatus(code)
+}
+
+// Problem RFC 7807 错误体；code / data 为扩展成员
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     int    `json:"code"`
+	Data     any    `json:"data,omitempty"`
+}
+
+const MIMEProblem = "application/problem+json"
+
+// JSON 按当前引擎的模式写出 r
+func JSON(c *gin.Context, r Resp) {
+	if r.localize {
+		r.Msg = Message(r.Code, Lang(c), r.params)
+	}
+	mode := ModeOf(c)
+	if mode == ModeLegacy {
+		c.JSON(http.StatusOK, r)
+		return
+	}
+	status := HTTPStatus(r.Code)
+	if mode == ModeStatus || r.Code == CodeOK {
+		c.JSON(status, r)
+		return
+	}
+	p := Problem{
+		Type: "about:blank", Title: http.StatusText(status), Status: status,
+		Detail: r.Msg, Instance: c.Request.URL.Path, Code: r.Code,
+	}
+	if r.Data != (struct{}{}) {
+		p.Data = r.Data
+	}
+	c.Render(status, problemRender{p})
+}
+
+// Abort 写出并终止后续 handler（中间件用）
+func Abort(c *gin.Context, r Resp) {
+	c.Abort()
+	JSON(c, r)
+}
+
+type problemRender struct{ p Problem }
+
+func (r problemRender) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	b, err := json.Marshal(r.p)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+func (problemRender) WriteContentType(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", MIMEProblem)
+}
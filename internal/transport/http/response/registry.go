@@ -0,0 +1,216 @@
+package response
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+/* ================== 业务错误码注册表 ==================
+   系统码（0、400…504）内置；业务模块用包级变量声明自己的码：
+     var CodeEmailTaken = resp.Register(resp.CodeDef{Code: 10001, Key: "email_taken", Status: 409,
+         Messages: map[string]string{"en": "email {email} is already registered", "zh": "邮箱 {email} 已被注册"}})
+   - 码段按模块分配（1xxxx 用户…）；重复的 code / key 不在注册时 panic，由启动时 CheckCodes 统一报出
+   - 消息模板用 {name} 占位，按 Accept-Language 选语言，缺省 en
+   - Status 为 status / problem 模式下的 HTTP 状态码，不填时按码推断（见 fallbackStatus）
+*/
+
+// CodeDef 一个业务错误码
+type CodeDef struct {
+	Code     int               `json:"code"`
+	Key      string            `json:"key"`
+	Status   int               `json:"status"`
+	Messages map[string]string `json:"messages"` // 语言 → 模板，至少含 en
+	source   string            // 注册位置（冲突报告用）
+}
+
+const DefaultLang = "en"
+
+var (
+	codesMu   sync.RWMutex
+	codes     = map[int]*CodeDef{}
+	codeKeys  = map[string]int{}
+	codeLangs = map[string]bool{}
+	conflicts []string
+)
+
+// Register 登记业务码并返回 code 本身，便于写成包级常量式变量
+func Register(def CodeDef) int {
+	src := ""
+	if _, file, line, ok := runtime.Caller(1); ok {
+		src = file + ":" + strconv.Itoa(line)
+	}
+	def.source = src
+	if def.Status == 0 {
+		def.Status = fallbackStatus(def.Code)
+	}
+
+	codesMu.Lock()
+	defer codesMu.Unlock()
+	if old, ok := codes[def.Code]; ok {
+		conflicts = append(conflicts, fmt.Sprintf("code %d registered twice: %q (%s) and %q (%s)", def.Code, old.Key, old.source, def.Key, src))
+		return def.Code
+	}
+	if def.Key != "" {
+		if other, ok := codeKeys[def.Key]; ok {
+			conflicts = append(conflicts, fmt.Sprintf("key %q used by codes %d (%s) and %d (%s)", def.Key, other, codes[other].source, def.Code, src))
+			return def.Code
+		}
+		codeKeys[def.Key] = def.Code
+	}
+	if def.Messages[DefaultLang] == "" {
+		conflicts = append(conflicts, fmt.Sprintf("code %d (%s) has no %q message", def.Code, src, DefaultLang))
+	}
+	for lang := range def.Messages {
+		codeLangs[lang] = true
+	}
+	codes[def.Code] = &def
+	return def.Code
+}
+
+// CheckCodes 启动时调用：有重复 code / key 或缺默认语言消息时返回错误
+func CheckCodes() error {
+	codesMu.RLock()
+	defer codesMu.RUnlock()
+	if len(conflicts) == 0 {
+		return nil
+	}
+	return fmt.Errorf("error code registry: %s", strings.Join(conflicts, "; "))
+}
+
+func LookupCode(code int) (CodeDef, bool) {
+	codesMu.RLock()
+	defer codesMu.RUnlock()
+	if d, ok := codes[code]; ok {
+		return *d, true
+	}
+	return CodeDef{}, false
+}
+
+// Codes 全部已注册的码（按 code 排序）
+func Codes() []CodeDef {
+	codesMu.RLock()
+	defer codesMu.RUnlock()
+	out := make([]CodeDef, 0, len(codes))
+	for _, d := range codes {
+		out = append(out, *d)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Code < out[j].Code })
+	return out
+}
+
+// Message 渲染某码在某语言下的消息；未注册时退回 CodeMsgMap
+func Message(code int, lang string, params map[string]any) string {
+	codesMu.RLock()
+	d := codes[code]
+	codesMu.RUnlock()
+	if d == nil {
+		return CodeMsgMap[code]
+	}
+	tmpl := d.Messages[lang]
+	if tmpl == "" {
+		tmpl = d.Messages[DefaultLang]
+	}
+	if len(params) == 0 {
+		return tmpl
+	}
+	pairs := make([]string, 0, 2*len(params))
+	for k, v := range params {
+		pairs = append(pairs, "{"+k+"}", fmt.Sprint(v))
+	}
+	return strings.NewReplacer(pairs...).Replace(tmpl)
+}
+
+// Coded 用注册表里的消息模板构造失败响应；写出时（JSON）再按请求语言渲染
+func Coded(code int, params map[string]any) Resp {
+	r := New(code, Message(code, DefaultLang, params), nil)
+	r.params, r.localize = params, true
+	return r
+}
+
+// NegotiateLang 按 Accept-Language（含 q 值）在 has 支持的语言里挑一个，都不支持时返回 fallback
+func NegotiateLang(accept string, has func(lang string) bool, fallback string) string {
+	best, bestQ := fallback, -1.0
+	for _, part := range strings.Split(accept, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			q, _ = strconv.ParseFloat(v, 64)
+		}
+		base, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if base != "" && has(base) && q > bestQ {
+			best, bestQ = base, q
+		}
+	}
+	return best
+}
+
+// Lang 当前请求在注册表消息里可用的语言
+func Lang(c *gin.Context) string {
+	codesMu.RLock()
+	defer codesMu.RUnlock()
+	return NegotiateLang(c.GetHeader("Accept-Language"), func(lang string) bool { return codeLangs[lang] }, DefaultLang)
+}
+
+// ListCodes GET /error-codes：全部错误码（message 为当前语言，messages 为全部翻译）
+func ListCodes(c *gin.Context) {
+	lang := Lang(c)
+	type item struct {
+		CodeDef
+		Message string `json:"message"`
+	}
+	defs := Codes()
+	out := make([]item, len(defs))
+	for i, d := range defs {
+		out[i] = item{CodeDef: d, Message: Message(d.Code, lang, nil)}
+	}
+	JSON(c, OK(gin.H{"items": out}))
+}
+
+// 内置系统码
+func init() {
+	builtin := []struct {
+		code    int
+		key, zh string
+	}{
+		{CodeOK, "ok", "成功"},
+		{CodeBadRequest, "bad_request", "请求参数错误"},
+		{CodeUnauthorized, "unauthorized", "未登录或登录已失效"},
+		{CodeForbidden, "forbidden", "没有权限"},
+		{CodeNotFound, "not_found", "资源不存在"},
+		{CodeConflict, "conflict", "资源冲突"},
+		{CodePreconditionFailed, "precondition_failed", "资源已被修改，请刷新后重试"},
+		{CodePreconditionRequired, "precondition_required", "缺少 If-Match 请求头"},
+		{CodeTooManyRequests, "too_many_requests", "请求过于频繁"},
+		{CodeServerError, "internal_error", "服务器内部错误"},
+		{CodeServiceUnavailable, "service_unavailable", "服务繁忙，请稍后重试"},
+		{CodeGatewayTimeout, "gateway_timeout", "请求超时"},
+	}
+	for _, b := range builtin {
+		Register(CodeDef{Code: b.code, Key: b.key, Messages: map[string]string{DefaultLang: CodeMsgMap[b.code], "zh": b.zh}})
+	}
+}
+
+// 未声明 Status 时：HTTP 状态码原样；子码（40401、4001）逐位截到三位；其它业务码按 400
+func fallbackStatus(code int) int {
+	if code == CodeOK {
+		return http.StatusOK
+	}
+	business := code >= 1000
+	for code >= 600 {
+		code /= 10
+	}
+	switch {
+	case code >= 400 && code < 600:
+		return code
+	case business:
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
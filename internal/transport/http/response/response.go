@@ -4,6 +4,9 @@ type Resp struct {
 	Code int         `json:"code"`
 	Msg  string      `json:"msg"`
 	Data interface{} `json:"data"`
+
+	params   map[string]any // 消息模板参数（Coded）
+	localize bool           // 写出时按请求语言重新渲染 Msg
 }
 
 // New 构造函数（保证 data 不为 null）
@@ -21,9 +24,8 @@ func OK(data interface{}) Resp {
 
 // Error 失败响应（可以传自定义 msg 覆盖默认）
 func Error(code int, customMsg string) Resp {
-	msg := CodeMsgMap[code]
 	if customMsg != "" {
-		msg = customMsg
+		return New(code, customMsg, struct{}{})
 	}
-	return New(code, msg, struct{}{})
+	return Coded(code, nil)
 }
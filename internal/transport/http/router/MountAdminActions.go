@@ -1,21 +1,26 @@
 package router
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
+	"go-gin-gorm-starter/internal/core/auth"
 	"go-gin-gorm-starter/internal/feature/user"
 	httpez "go-gin-gorm-starter/internal/transport/http/ez"
 )
 
 // 把管理端接口集中在这里注册
-func MountAdminActions(admin *gin.RouterGroup, db *gorm.DB) {
+func MountAdminActions(admin *gin.RouterGroup, db *gorm.DB, jwter *auth.JWTer) {
 	_ = db.AutoMigrate(&user.UserModel{})
+	refresh := &auth.RefreshStore{DB: db, TTL: jwter.RefreshTTL}
+	_ = refresh.Migrate()
 
 	ez := httpez.New(admin)
+	usersKeyset := httpez.NewKeyset(db, &user.UserModel{}, "CreatedAt DESC", "ID")
 
 	// --- GET /admin/v1/users  用户列表 ---
 	type listQ struct {
@@ -23,6 +28,9 @@ func MountAdminActions(admin *gin.RouterGroup, db *gorm.DB) {
 		Limit       int    `form:"limit,default=20"`
 		Q           string `form:"q"`            // 按 email/name 模糊搜
 		WithDeleted bool   `form:"with_deleted"` // 是否包含软删
+		Paging      string `form:"paging"`       // "cursor" 走游标分页（大表推荐）
+		Cursor      string `form:"cursor"`       // 上一页返回的 nextCursor/prevCursor
+		WithTotal   bool   `form:"with_total"`   // 游标模式下是否仍统计总数
 	}
 	type row struct {
 		ID    string `json:"id"`
@@ -31,8 +39,29 @@ func MountAdminActions(admin *gin.RouterGroup, db *gorm.DB) {
 		Role  string `json:"role"`
 	}
 	type listOut struct {
-		Total int64 `json:"total"`
-		Items []row `json:"items"`
+		Total      *int64 `json:"total,omitempty"`
+		Items      []row  `json:"items"`
+		NextCursor string `json:"nextCursor,omitempty"`
+		PrevCursor string `json:"prevCursor,omitempty"`
+	}
+	toRows := func(us []user.UserModel) []row {
+		rows := make([]row, 0, len(us))
+		for _, u := range us {
+			rows = append(rows, row{ID: u.ID, Email: u.Email, Name: u.Name, Role: u.Role})
+		}
+		return rows
+	}
+
+	filterUsers := func(db *gorm.DB, kw string, withDeleted bool) *gorm.DB {
+		q := db.Model(&user.UserModel{})
+		if withDeleted {
+			q = q.Unscoped()
+		}
+		if s := strings.TrimSpace(kw); s != "" {
+			like := "%" + s + "%"
+			q = q.Where("email LIKE ? OR name LIKE ?", like, like)
+		}
+		return q
 	}
 
 	httpez.RegisterAction[listQ, listOut](ez, db, httpez.Action[listQ, listOut]{
@@ -45,54 +74,117 @@ func MountAdminActions(admin *gin.RouterGroup, db *gorm.DB) {
 			if in.Limit <= 0 || in.Limit > 100 {
 				in.Limit = 20
 			}
-			q := tx.WithContext(c).Model(&user.UserModel{})
-			if in.WithDeleted {
-				q = q.Unscoped()
-			}
-			if s := strings.TrimSpace(in.Q); s != "" {
-				like := "%" + s + "%"
-				q = q.Where("email LIKE ? OR name LIKE ?", like, like)
+			q := filterUsers(tx.WithContext(c), in.Q, in.WithDeleted)
+
+			cursorMode := in.Paging == "cursor" || in.Cursor != ""
+
+			var out listOut
+			if !cursorMode || in.WithTotal {
+				var total int64
+				if err := q.Count(&total).Error; err != nil {
+					return listOut{}, httpez.Internal("count users failed", err)
+				}
+				out.Total = &total
 			}
 
-			var total int64
-			if err := q.Count(&total).Error; err != nil {
-				return listOut{}, httpez.Internal("count users failed", err)
+			if cursorMode {
+				p, err := httpez.CursorPaginate[user.UserModel](q, usersKeyset, in.Cursor, in.Limit)
+				if err != nil {
+					return listOut{}, err
+				}
+				out.Items, out.NextCursor, out.PrevCursor = toRows(p.List), p.NextCursor, p.PrevCursor
+				return out, nil
 			}
 
 			var us []user.UserModel
 			if err := q.Order("created_at DESC").Limit(in.Limit).Offset(in.Offset).Find(&us).Error; err != nil {
 				return listOut{}, httpez.Internal("list users failed", err)
 			}
-
-			out := listOut{Total: total, Items: make([]row, 0, len(us))}
-			for _, u := range us {
-				out.Items = append(out.Items, row{
-					ID: u.ID, Email: u.Email, Name: u.Name, Role: u.Role,
-				})
-			}
+			out.Items = toRows(us)
 			return out, nil
 		},
 	})
 
-	// --- POST /admin/v1/users/:id/ban  封禁（软删） ---
-	httpez.RegisterAction[struct{}, gin.H](ez, db, httpez.Action[struct{}, gin.H]{
+	// --- GET /admin/v1/users/export?format=csv|ndjson|xlsx  导出（筛选条件同列表） ---
+	type exportQ struct {
+		Q           string `form:"q"`
+		WithDeleted bool   `form:"with_deleted"`
+		Format      string `form:"format"`
+	}
+	httpez.RegisterAction[exportQ, struct{}](ez, db, httpez.Action[exportQ, struct{}]{
+		Method: http.MethodGet,
+		Path:   "/users/export",
+		Binder: httpez.BindQuery,
+		Handler: func(c *gin.Context, tx *gorm.DB, in *exportQ) (struct{}, error) {
+			q := filterUsers(tx.WithContext(c), in.Q, in.WithDeleted).Order("created_at DESC")
+			return struct{}{}, httpez.Export(c, q, in.Format, httpez.ExportOptions[user.UserModel]{
+				Filename: "users",
+				Row: func(u *user.UserModel) any {
+					return row{ID: u.ID, Email: u.Email, Name: u.Name, Role: u.Role}
+				},
+			})
+		},
+	})
+
+	// --- POST /admin/v1/users/:id/ban  封禁（软删），其已签发的令牌立即失效 ---
+	type banIn struct {
+		ID string `uri:"id" binding:"required"`
+	}
+	httpez.RegisterAction[banIn, gin.H](ez, db, httpez.Action[banIn, gin.H]{
 		Method: http.MethodPost,
 		Path:   "/users/:id/ban",
-		Binder: httpez.BindNone,
+		Binder: httpez.BindURI,
 		Auth:   false, // 分组已校验 admin
-		Handler: func(c *gin.Context, tx *gorm.DB, _ *struct{}) (gin.H, error) {
-			id := c.Param("id")
-			if id == "" {
-				return nil, httpez.BadRequest("missing id")
+		UseTx:  true,  // 封禁与吊销令牌一起提交
+		Handler: func(c *gin.Context, tx *gorm.DB, in *banIn) (gin.H, error) {
+			if in.ID == auth.UserID(c) {
+				return nil, httpez.BadRequest("cannot ban yourself")
 			}
-			res := tx.WithContext(c).Where("id = ?", id).Delete(&user.UserModel{})
+			res := tx.WithContext(c).Where("id = ?", in.ID).Delete(&user.UserModel{})
 			if res.Error != nil {
 				return nil, httpez.Internal("ban user failed", res.Error)
 			}
 			if res.RowsAffected == 0 {
 				return nil, httpez.NotFound("user not found")
 			}
-			return gin.H{"id": id}, nil
+			if err := revokeUserTokens(c, tx, jwter, refresh, in.ID); err != nil {
+				return nil, httpez.Internal("revoke tokens failed", err)
+			}
+			return gin.H{"id": in.ID}, nil
+		},
+	})
+
+	// --- PUT /admin/v1/users/:id/role  改角色，旧令牌失效（需重新登录拿新角色） ---
+	type roleIn struct {
+		ID   string `uri:"id" json:"-" binding:"required"`
+		Role string `json:"role" binding:"required,oneof=user admin"`
+	}
+	httpez.RegisterAction[roleIn, gin.H](ez, db, httpez.Action[roleIn, gin.H]{
+		Method: http.MethodPut,
+		Path:   "/users/:id/role",
+		Binder: httpez.Bind(httpez.BindURI, httpez.BindJSON),
+		UseTx:  true, // 改角色与吊销令牌一起提交
+		Handler: func(c *gin.Context, tx *gorm.DB, in *roleIn) (gin.H, error) {
+			if in.ID == auth.UserID(c) {
+				return nil, httpez.BadRequest("cannot change your own role")
+			}
+			var u user.UserModel
+			if err := tx.WithContext(c).Where("id = ?", in.ID).First(&u).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil, httpez.NotFound("user not found")
+				}
+				return nil, httpez.Internal("db error", err)
+			}
+			if u.Role == in.Role {
+				return gin.H{"id": in.ID, "role": in.Role}, nil
+			}
+			if err := tx.WithContext(c).Model(&u).Update("role", in.Role).Error; err != nil {
+				return nil, httpez.Internal("update role failed", err)
+			}
+			if err := revokeUserTokens(c, tx, jwter, refresh, in.ID); err != nil {
+				return nil, httpez.Internal("revoke tokens failed", err)
+			}
+			return gin.H{"id": in.ID, "role": in.Role}, nil
 		},
 	})
 }
@@ -0,0 +1,258 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"go-gin-gorm-starter/internal/core/auth"
+	"go-gin-gorm-starter/internal/core/mailer"
+	"go-gin-gorm-starter/internal/feature/user"
+	httpez "go-gin-gorm-starter/internal/transport/http/ez"
+	resp "go-gin-gorm-starter/internal/transport/http/response"
+	"go-gin-gorm-starter/pkg/utils"
+)
+
+/* ================== 账号邮件：邮箱验证 / 找回密码 ==================
+   POST /auth/verify-email          {token}                用邮件里的令牌验证邮箱
+   POST /auth/verify-email/resend   （需登录）             重发验证邮件
+   POST /auth/forgot-password       {email}                发重置邮件（邮箱是否存在都返回成功）
+   POST /auth/reset-password        {token, newPassword}   重置密码，旧令牌全部失效，同时视为邮箱已验证
+   令牌见 auth.OneTimeTokens：签名 + 单次使用，同用途重发后旧链接作废；签名密钥 Mail.TokenSecret 独立配置，不与 JWT 共用
+*/
+
+const (
+	verifyEmailTTL   = 24 * time.Hour
+	resetPasswordTTL = time.Hour
+	asyncMailTimeout = time.Minute // 后台发信（sendAsync）的超时
+)
+
+// Mail 账号邮件配置；Mailer 为空时写日志
+type Mail struct {
+	Mailer      mailer.Mailer
+	From        string
+	LinkBaseURL string // 前端地址：链接为 <LinkBaseURL>/verify-email?token=… 与 /reset-password?token=…
+	TokenSecret []byte // 邮件里一次性令牌的签名密钥（必填）
+}
+
+type accountMail struct {
+	Mail
+	tokens *auth.OneTimeTokens
+	l      *zap.Logger
+}
+
+func newAccountMail(m Mail, db *gorm.DB, l *zap.Logger) *accountMail {
+	if len(m.TokenSecret) == 0 {
+		panic("router: Mail.TokenSecret is required")
+	}
+	if m.Mailer == nil {
+		m.Mailer = mailer.LogMailer{L: l}
+	}
+	tokens := &auth.OneTimeTokens{DB: db, Secret: m.TokenSecret}
+	_ = tokens.Migrate()
+	return &accountMail{Mail: m, tokens: tokens, l: l}
+}
+
+// 邮件模板：按请求语言（Accept-Language）选，缺省 en；%[1]s 链接或令牌，%[2]s 有效期
+var accountTemplates = map[string]map[string][2]string{
+	auth.PurposeVerifyEmail: {
+		"en": {"Verify your email", "Open the link below to verify your email address:\n\n%[1]s\n\nThe link expires in %[2]s."},
+		"zh": {"验证你的邮箱", "请打开下面的链接完成邮箱验证：\n\n%[1]s\n\n链接 %[2]s 内有效。"},
+	},
+	auth.PurposeResetPassword: {
+		"en": {"Reset your password", "Open the link below to set a new password:\n\n%[1]s\n\nThe link expires in %[2]s. If you did not request this, you can ignore this email."},
+		"zh": {"重置密码", "请打开下面的链接设置新密码：\n\n%[1]s\n\n链接 %[2]s 内有效。如果不是你本人操作，请忽略本邮件。"},
+	},
+}
+
+// send 签发 purpose 令牌并发邮件
+func (m *accountMail) send(c *gin.Context, u *user.UserModel, purpose string) error {
+	return m.deliver(c, resp.Lang(c), u, purpose)
+}
+
+func (m *accountMail) deliver(ctx context.Context, lang string, u *user.UserModel, purpose string) error {
+	ttl, page := verifyEmailTTL, "/verify-email"
+	if purpose == auth.PurposeResetPassword {
+		ttl, page = resetPasswordTTL, "/reset-password"
+	}
+	tok, err := m.tokens.Issue(ctx, u.ID, purpose, ttl)
+	if err != nil {
+		return err
+	}
+	link := tok
+	if m.LinkBaseURL != "" {
+		link = strings.TrimRight(m.LinkBaseURL, "/") + page + "?token=" + url.QueryEscape(tok)
+	}
+	tmpl, ok := accountTemplates[purpose][lang]
+	if !ok {
+		tmpl = accountTemplates[purpose][resp.DefaultLang]
+	}
+	return m.Mailer.Send(ctx, mailer.Message{
+		From: m.From, To: u.Email, Subject: tmpl[0],
+		Text: fmt.Sprintf(tmpl[1], link, ttl),
+	})
+}
+
+// sendQuietly 发信失败不影响主流程（用户可重发），只记日志
+func (m *accountMail) sendQuietly(c *gin.Context, u *user.UserModel, purpose string) {
+	if err := m.send(c, u, purpose); err != nil {
+		m.l.Warn("send account mail failed", zap.String("purpose", purpose), zap.String("uid", u.ID), zap.Error(err))
+	}
+}
+
+// sendAsync 后台签发令牌并发信，响应不等 SMTP：耗时与邮箱不存在时一致，无法按耗时探测注册邮箱
+// gin.Context 会被复用，不能带进 goroutine：先取出语言，context 去掉请求取消
+func (m *accountMail) sendAsync(c *gin.Context, u *user.UserModel, purpose string) {
+	ctx, lang, to := context.WithoutCancel(c.Request.Context()), resp.Lang(c), *u
+	go func() {
+		ctx, cancel := context.WithTimeout(ctx, asyncMailTimeout)
+		defer cancel()
+		if err := m.deliver(ctx, lang, &to, purpose); err != nil {
+			m.l.Warn("send account mail failed", zap.String("purpose", purpose), zap.String("uid", to.ID), zap.Error(err))
+		}
+	}()
+}
+
+func mountAccountActions(ezPublic, ezAuth httpez.EZ, db *gorm.DB, jwter *auth.JWTer, refresh *auth.RefreshStore, mail *accountMail) {
+	// /auth/verify-email
+	type tokenIn struct {
+		Token string `json:"token" binding:"required"`
+	}
+	httpez.RegisterAction[tokenIn, struct{}](ezPublic, db, httpez.Action[tokenIn, struct{}]{
+		Method:  http.MethodPost,
+		Path:    "/auth/verify-email",
+		Binder:  httpez.BindJSON,
+		Summary: "验证邮箱",
+		Handler: func(c *gin.Context, tx *gorm.DB, in *tokenIn) (struct{}, error) {
+			uid, err := mail.tokens.Consume(c, in.Token, auth.PurposeVerifyEmail)
+			if errors.Is(err, auth.ErrOneTimeTokenInvalid) {
+				return struct{}{}, httpez.Fail(user.CodeTokenInvalid, nil)
+			}
+			if err != nil {
+				return struct{}{}, httpez.Internal("verify email failed", err)
+			}
+			if err := markEmailVerified(tx, uid); err != nil {
+				return struct{}{}, httpez.Internal("verify email failed", err)
+			}
+			return struct{}{}, nil
+		},
+	})
+
+	// /auth/verify-email/resend（需登录）
+	httpez.RegisterAction[struct{}, struct{}](ezAuth, db, httpez.Action[struct{}, struct{}]{
+		Method:  http.MethodPost,
+		Path:    "/auth/verify-email/resend",
+		Binder:  httpez.BindNone,
+		Auth:    true,
+		Summary: "重发验证邮件",
+		Handler: func(c *gin.Context, tx *gorm.DB, _ *struct{}) (struct{}, error) {
+			var u user.UserModel
+			if err := tx.Where("id = ?", auth.UserID(c)).First(&u).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return struct{}{}, httpez.NotFound("user not found")
+				}
+				return struct{}{}, httpez.Internal("db error", err)
+			}
+			if u.EmailVerifiedAt != nil {
+				return struct{}{}, httpez.Fail(user.CodeEmailAlreadyVerified, nil)
+			}
+			if err := mail.send(c, &u, auth.PurposeVerifyEmail); err != nil {
+				return struct{}{}, httpez.Internal("send mail failed", err)
+			}
+			return struct{}{}, nil
+		},
+	})
+
+	// /auth/forgot-password：不论邮箱是否存在都返回成功，邮件后台发送（耗时也一致），避免探测注册邮箱
+	type forgotIn struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	httpez.RegisterAction[forgotIn, struct{}](ezPublic, db, httpez.Action[forgotIn, struct{}]{
+		Method:  http.MethodPost,
+		Path:    "/auth/forgot-password",
+		Binder:  httpez.BindJSON,
+		Summary: "找回密码（发送重置邮件）",
+		Handler: func(c *gin.Context, tx *gorm.DB, in *forgotIn) (struct{}, error) {
+			var u user.UserModel
+			err := tx.Where("email = ?", strings.TrimSpace(in.Email)).First(&u).Error
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				return struct{}{}, nil
+			case err != nil:
+				return struct{}{}, httpez.Internal("db error", err)
+			}
+			mail.sendAsync(c, &u, auth.PurposeResetPassword)
+			return struct{}{}, nil
+		},
+	})
+
+	// /auth/reset-password
+	type resetIn struct {
+		Token       string `json:"token"       binding:"required"`
+		NewPassword string `json:"newPassword" binding:"required,min=8,max=72"`
+	}
+	httpez.RegisterAction[resetIn, struct{}](ezPublic, db, httpez.Action[resetIn, struct{}]{
+		Method:  http.MethodPost,
+		Path:    "/auth/reset-password",
+		Binder:  httpez.BindJSON,
+		Summary: "重置密码",
+		Handler: func(c *gin.Context, _ *gorm.DB, in *resetIn) (struct{}, error) {
+			// 用掉令牌、改密、吊销旧令牌在同一事务：任一步失败全部回滚，令牌仍可重试
+			var uid string
+			err := db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+				var err error
+				uid, err = mail.tokens.WithDB(tx).Consume(c, in.Token, auth.PurposeResetPassword)
+				if errors.Is(err, auth.ErrOneTimeTokenInvalid) {
+					return httpez.Fail(user.CodeTokenInvalid, nil)
+				}
+				if err != nil {
+					return httpez.Internal("reset password failed", err)
+				}
+				res := tx.Model(&user.UserModel{}).Where("id = ?", uid).Update("password_hash", utils.HashPassword(in.NewPassword))
+				if res.Error != nil {
+					return httpez.Internal("reset password failed", res.Error)
+				}
+				if res.RowsAffected == 0 {
+					return httpez.Fail(user.CodeTokenInvalid, nil) // 用户已删除
+				}
+				// 能收到重置邮件即证明邮箱归属
+				if err := markEmailVerified(tx, uid); err != nil {
+					return httpez.Internal("reset password failed", err)
+				}
+				if jwter.Revocations != nil {
+					if err := jwter.Revocations.BumpTx(tx, uid); err != nil {
+						return httpez.Internal("revoke tokens failed", err)
+					}
+				}
+				if err := refresh.WithDB(tx).RevokeUser(c, uid); err != nil {
+					return httpez.Internal("revoke tokens failed", err)
+				}
+				return nil
+			})
+			if err != nil {
+				return struct{}{}, err
+			}
+			// 版本缓存提交后再刷新
+			if jwter.Revocations != nil {
+				if err := jwter.Revocations.SyncVersion(c, uid); err != nil {
+					return struct{}{}, httpez.Internal("revoke tokens failed", err)
+				}
+			}
+			return struct{}{}, nil
+		},
+	})
+}
+
+func markEmailVerified(db *gorm.DB, uid string) error {
+	return db.Model(&user.UserModel{}).
+		Where("id = ? AND email_verified_at IS NULL", uid).
+		Update("email_verified_at", time.Now()).Error
+}
@@ -10,13 +10,17 @@ import (
 	"gorm.io/gorm"
 
 	"go-gin-gorm-starter/internal/core/auth"
+	httpez "go-gin-gorm-starter/internal/transport/http/ez"
 	mdw "go-gin-gorm-starter/internal/transport/http/middleware"
+	resp "go-gin-gorm-starter/internal/transport/http/response"
 )
 
-func NewAdminEngine(l *zap.Logger, db *gorm.DB, jwter *auth.JWTer) *gin.Engine {
+// mode 决定错误响应的写法（legacy 一律 200 / status 真实状态码 / problem RFC 7807）
+func NewAdminEngine(l *zap.Logger, db *gorm.DB, jwter *auth.JWTer, mode resp.Mode) *gin.Engine {
 	r := gin.New()
 
 	r.Use(
+		resp.UseMode(mode), // 须在最前：限流 / 超时等中间件的错误也按此写出
 		mdw.RequestID(),
 		mdw.RateLimit(200, 400),
 		mdw.ConcurrencyLimit(300),
@@ -30,6 +34,9 @@ func NewAdminEngine(l *zap.Logger, db *gorm.DB, jwter *auth.JWTer) *gin.Engine {
 	// 健康检查
 	r.GET("/health", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"ok": 1}) })
 
+	// 错误码清单（给前端对照）
+	r.GET("/error-codes", resp.ListCodes)
+
 	// 管理端 v1（统一要求 admin 角色）
 	admin := r.Group("/admin/v1")
 	admin.Use(mdw.AuthJWT(jwter, "admin"))
@@ -38,7 +45,10 @@ func NewAdminEngine(l *zap.Logger, db *gorm.DB, jwter *auth.JWTer) *gin.Engine {
 	MountAllAdmin(admin)
 
 	// ② 用 Action 挂载管理端接口（用户列表/封禁等）
-	MountAdminActions(admin, db)
+	MountAdminActions(admin, db, jwter)
+
+	// 接口文档：/openapi.json + /docs（/admin/v1 下统一需要 admin Token）
+	httpez.MountOpenAPI(r, httpez.OpenAPIInfo{Title: "Admin API", Version: "v1", SecuredPrefixes: []string{"/admin/v1"}})
 
 	return r
 }
@@ -14,14 +14,17 @@ import (
 	"go-gin-gorm-starter/internal/feature/user"
 	httpez "go-gin-gorm-starter/internal/transport/http/ez"
 	mdw "go-gin-gorm-starter/internal/transport/http/middleware"
+	resp "go-gin-gorm-starter/internal/transport/http/response"
 	"go-gin-gorm-starter/pkg/utils"
 )
 
-func NewAPIEngine(l *zap.Logger, db *gorm.DB, jwter *auth.JWTer) *gin.Engine {
+// mail 为账号邮件（验证邮箱 / 重置密码）配置；mode 决定错误响应的写法（legacy 一律 200 / status 真实状态码 / problem RFC 7807）
+func NewAPIEngine(l *zap.Logger, db *gorm.DB, jwter *auth.JWTer, mail Mail, mode resp.Mode) *gin.Engine {
 	r := gin.New()
 
 	// 中间件
 	r.Use(
+		resp.UseMode(mode), // 须在最前：限流 / 超时等中间件的错误也按此写出
 		mdw.RequestID(),
 		mdw.RateLimit(200, 400),
 		mdw.ConcurrencyLimit(300),
@@ -35,139 +38,299 @@ func NewAPIEngine(l *zap.Logger, db *gorm.DB, jwter *auth.JWTer) *gin.Engine {
 	// 健康检查
 	r.GET("/health", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"ok": 1}) })
 
+	// 错误码清单（给前端对照）
+	r.GET("/error-codes", resp.ListCodes)
+
+	// JWT 验证公钥（非对称签名时供下游服务验签；标准格式，不走统一信封）
+	r.GET("/.well-known/jwks.json", func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, jwter.JWKS())
+	})
+
 	// 前缀
 	api := r.Group("/api/v1")
 
 	// 统一注册器（保留你原来的）
 	MountAllAPI(api)
 
-	// 鉴权分组（⚠️ /me 必须挂这里，才能拿到 userId）
+	// 鉴权分组（⚠️ /me 必须挂这里，才能拿到 auth.Principal）
 	authUser := api.Group("")
 	authUser.Use(mdw.AuthJWT(jwter, ""))
 
-	// 用 Action 方式挂载：/auth/login（公共） 和 /me（鉴权）
-	mountAuthActions(api, authUser, db, jwter)
+	// 用 Action 方式挂载：/auth/register、/auth/login 等（公共） 和 /me（鉴权）
+	mountAuthActions(api, authUser, db, jwter, newAccountMail(mail, db, l))
+
+	// 接口文档：/openapi.json + /docs
+	httpez.MountOpenAPI(r, httpez.OpenAPIInfo{Title: "API", Version: "v1"})
 
 	return r
 }
 
-// ---------- 动作注册：/auth/login + /me ----------
+// ---------- 动作注册：/auth/register、/auth/login、/auth/refresh、/auth/logout + /me（邮箱验证 / 找回密码见 account.go） ----------
 
-func mountAuthActions(api, authUser *gin.RouterGroup, db *gorm.DB, jwter *auth.JWTer) {
-	// 确保用户表
+func mountAuthActions(api, authUser *gin.RouterGroup, db *gorm.DB, jwter *auth.JWTer, mail *accountMail) {
+	// 确保用户表 / 刷新令牌表
 	_ = db.AutoMigrate(&user.UserModel{})
+	refresh := &auth.RefreshStore{DB: db, TTL: jwter.RefreshTTL}
+	_ = refresh.Migrate()
 
 	// 公共分组（无需登录）
 	ezPublic := httpez.New(api)
 
-	// /auth/login：查不到就自动注册 + 发 JWT
-	type loginIn struct {
-		Email    string `json:"email"    binding:"required,email"`
-		Password string `json:"password" binding:"required"`
-		Name     string `json:"name"     binding:"omitempty,max=64"` // 首次注册可用
+	// 访问令牌 + 刷新令牌
+	type tokenOut struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refreshToken"`
+		ExpiresIn    int    `json:"expiresIn"` // 访问令牌有效秒数
 	}
-	type loginOut struct {
-		Token string      `json:"token"`
-		IsNew bool        `json:"isNew"`
-		User  interface{} `json:"user"`
+	issueTokens := func(c *gin.Context, u *user.UserModel, rt string) (tokenOut, error) {
+		tok, err := jwter.IssueContext(c, u.ID, u.Role)
+		if err != nil || tok == "" {
+			return tokenOut{}, httpez.Internal("issue token failed", err)
+		}
+		if rt == "" {
+			if rt, err = refresh.Issue(c, u.ID); err != nil {
+				return tokenOut{}, httpez.Internal("issue refresh token failed", err)
+			}
+		}
+		return tokenOut{Token: tok, RefreshToken: rt, ExpiresIn: int(jwter.TTL.Seconds())}, nil
+	}
+
+	// 登录 / 注册返回的用户信息
+	type userOut struct {
+		ID            string `json:"id"`
+		Email         string `json:"email"`
+		Name          string `json:"name"`
+		Role          string `json:"role"`
+		EmailVerified bool   `json:"emailVerified"`
 	}
-	httpez.RegisterAction[loginIn, loginOut](ezPublic, db, httpez.Action[loginIn, loginOut]{
-		Method: http.MethodPost,
-		Path:   "/auth/login",
-		Binder: httpez.BindJSON,
-		Auth:   false,
-		Handler: func(c *gin.Context, tx *gorm.DB, in *loginIn) (loginOut, error) {
+	toUserOut := func(u *user.UserModel) userOut {
+		return userOut{ID: u.ID, Email: u.Email, Name: u.Name, Role: u.Role, EmailVerified: u.EmailVerifiedAt != nil}
+	}
+	type authOut struct {
+		tokenOut
+		User userOut `json:"user"`
+	}
+
+	// /auth/register：注册 + 发验证邮件 + 发 JWT（未验证邮箱也可登录，业务按 emailVerified 自行限制）
+	type registerIn struct {
+		Email    string `json:"email"    binding:"required,email"`
+		Password string `json:"password" binding:"required,min=8,max=72"`
+		Name     string `json:"name"     binding:"omitempty,max=64"` // 缺省取邮箱 @ 前部分
+	}
+	httpez.RegisterAction[registerIn, authOut](ezPublic, db, httpez.Action[registerIn, authOut]{
+		Method:  http.MethodPost,
+		Path:    "/auth/register",
+		Binder:  httpez.BindJSON,
+		Summary: "注册",
+		Handler: func(c *gin.Context, tx *gorm.DB, in *registerIn) (authOut, error) {
 			email := strings.TrimSpace(in.Email)
 			name := strings.TrimSpace(in.Name)
+			if name == "" {
+				if at := strings.IndexByte(email, '@'); at > 0 {
+					name = email[:at]
+				} else {
+					name = "user"
+				}
+			}
 
-			var u user.UserModel
-			err := tx.Where("email = ?", email).First(&u).Error
+			var n int64
+			if err := tx.Model(&user.UserModel{}).Where("email = ?", email).Count(&n).Error; err != nil {
+				return authOut{}, httpez.Internal("db error", err)
+			}
+			if n > 0 {
+				return authOut{}, httpez.Fail(user.CodeEmailTaken, map[string]any{"email": email})
+			}
+			u := user.UserModel{
+				ID:           utils.NewID(),
+				Email:        email,
+				Name:         name,
+				PasswordHash: utils.HashPassword(in.Password),
+				Role:         "user",
+			}
+			if err := tx.Create(&u).Error; err != nil {
+				// 并发兜底：唯一冲突
+				if isDupKey(err) {
+					return authOut{}, httpez.Fail(user.CodeEmailTaken, map[string]any{"email": email})
+				}
+				return authOut{}, httpez.Internal("register failed", err)
+			}
+			mail.sendQuietly(c, &u, auth.PurposeVerifyEmail)
 
+			toks, err := issueTokens(c, &u, "")
+			if err != nil {
+				return authOut{}, err
+			}
+			return authOut{tokenOut: toks, User: toUserOut(&u)}, nil
+		},
+	})
+
+	// /auth/login：邮箱 + 密码换 JWT；邮箱不存在与密码错误返回同一个码，耗时也一致
+	type loginIn struct {
+		Email    string `json:"email"    binding:"required,email"`
+		Password string `json:"password" binding:"required"`
+	}
+	httpez.RegisterAction[loginIn, authOut](ezPublic, db, httpez.Action[loginIn, authOut]{
+		Method:  http.MethodPost,
+		Path:    "/auth/login",
+		Binder:  httpez.BindJSON,
+		Summary: "登录",
+		Handler: func(c *gin.Context, tx *gorm.DB, in *loginIn) (authOut, error) {
+			var u user.UserModel
+			err := tx.Where("email = ?", strings.TrimSpace(in.Email)).First(&u).Error
 			switch {
 			case errors.Is(err, gorm.ErrRecordNotFound):
-				// 自动注册
-				if name == "" {
-					if at := strings.IndexByte(email, '@'); at > 0 {
-						name = email[:at]
-					} else {
-						name = "user"
-					}
-				}
-				u = user.UserModel{
-					ID:           utils.NewID(),
-					Email:        email,
-					Name:         name,
-					PasswordHash: utils.HashPassword(in.Password),
-					Role:         "user",
-				}
-				if e := tx.Create(&u).Error; e != nil {
-					// 并发兜底：唯一冲突 → 再查一次
-					if isDupKey(e) {
-						if e2 := tx.Where("email = ?", email).First(&u).Error; e2 != nil {
-							return loginOut{}, httpez.Internal("login failed", e2)
-						}
-					} else {
-						return loginOut{}, httpez.BadRequest(e.Error())
-					}
-				}
-				tok, e := jwter.Issue(u.ID, u.Role)
-				if e != nil || tok == "" {
-					return loginOut{}, httpez.Internal("issue token failed", e)
-				}
-				return loginOut{
-					Token: tok, IsNew: true,
-					User: gin.H{"id": u.ID, "email": u.Email, "name": u.Name, "role": u.Role},
-				}, nil
-
+				utils.CheckPasswordTiming(in.Password) // 照样跑一次 bcrypt，免得按耗时探测注册邮箱
+				return authOut{}, httpez.Fail(user.CodeInvalidCredentials, nil)
 			case err != nil:
-				return loginOut{}, httpez.Internal("db error", err)
+				return authOut{}, httpez.Internal("db error", err)
+			}
+			if !utils.CheckPassword(in.Password, u.PasswordHash) {
+				return authOut{}, httpez.Fail(user.CodeInvalidCredentials, nil)
+			}
+			toks, err := issueTokens(c, &u, "")
+			if err != nil {
+				return authOut{}, err
+			}
+			return authOut{tokenOut: toks, User: toUserOut(&u)}, nil
+		},
+	})
 
-			default:
-				// 已存在 → 校验密码
-				if !utils.CheckPassword(in.Password, u.PasswordHash) {
-					return loginOut{}, httpez.Unauthorized("invalid credentials")
+	// /auth/refresh：刷新令牌换新的一对令牌（旧刷新令牌作废；重放旧令牌会吊销整条链）
+	type refreshIn struct {
+		RefreshToken string `json:"refreshToken" binding:"required"`
+	}
+	httpez.RegisterAction[refreshIn, tokenOut](ezPublic, db, httpez.Action[refreshIn, tokenOut]{
+		Method:  http.MethodPost,
+		Path:    "/auth/refresh",
+		Binder:  httpez.BindJSON,
+		Summary: "刷新令牌轮换",
+		Handler: func(c *gin.Context, tx *gorm.DB, in *refreshIn) (tokenOut, error) {
+			rt, uid, err := refresh.Rotate(c, in.RefreshToken)
+			switch {
+			case errors.Is(err, auth.ErrRefreshReused):
+				return tokenOut{}, httpez.Fail(user.CodeRefreshReused, nil)
+			case errors.Is(err, auth.ErrRefreshInvalid):
+				return tokenOut{}, httpez.Fail(user.CodeRefreshInvalid, nil)
+			case err != nil:
+				return tokenOut{}, httpez.Internal("refresh failed", err)
+			}
+			var u user.UserModel
+			if err := tx.Where("id = ?", uid).First(&u).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					// 用户已删除：新令牌也作废
+					_ = refresh.Revoke(c, rt)
+					return tokenOut{}, httpez.Fail(user.CodeRefreshInvalid, nil)
 				}
-				tok, e := jwter.Issue(u.ID, u.Role)
-				if e != nil || tok == "" {
-					return loginOut{}, httpez.Internal("issue token failed", e)
+				return tokenOut{}, httpez.Internal("db error", err)
+			}
+			return issueTokens(c, &u, rt)
+		},
+	})
+
+	// /auth/logout：吊销当前刷新令牌所在的家族；带了 Authorization 时该访问令牌一并吊销
+	httpez.RegisterAction[refreshIn, struct{}](ezPublic, db, httpez.Action[refreshIn, struct{}]{
+		Method:  http.MethodPost,
+		Path:    "/auth/logout",
+		Binder:  httpez.BindJSON,
+		Summary: "登出（吊销刷新令牌与当前访问令牌）",
+		Handler: func(c *gin.Context, _ *gorm.DB, in *refreshIn) (struct{}, error) {
+			if err := refresh.Revoke(c, in.RefreshToken); err != nil {
+				return struct{}{}, httpez.Internal("logout failed", err)
+			}
+			if ah := c.GetHeader("Authorization"); jwter.Revocations != nil && strings.HasPrefix(ah, "Bearer ") {
+				if claims, err := jwter.Parse(strings.TrimPrefix(ah, "Bearer ")); err == nil {
+					if err := jwter.Revocations.Revoke(c, claims); err != nil {
+						return struct{}{}, httpez.Internal("logout failed", err)
+					}
 				}
-				return loginOut{
-					Token: tok, IsNew: false,
-					User: gin.H{"id": u.ID, "email": u.Email, "name": u.Name, "role": u.Role},
-				}, nil
 			}
+			return struct{}{}, nil
 		},
 	})
 
 	// 鉴权分组（需要登录）—— /me 必须挂在带中间件的分组
 	ezAuth := httpez.New(authUser)
 
-	type meOut struct {
-		ID    string `json:"id"`
-		Email string `json:"email"`
-		Name  string `json:"name"`
-		Role  string `json:"role"`
-	}
-	httpez.RegisterAction[struct{}, meOut](ezAuth, db, httpez.Action[struct{}, meOut]{
+	httpez.RegisterAction[struct{}, userOut](ezAuth, db, httpez.Action[struct{}, userOut]{
 		Method: http.MethodGet,
 		Path:   "/me",
 		Binder: httpez.BindNone,
 		Auth:   true, // 这里可以保留 true（双保险），也可以设为 false 因为分组已走中间件
-		Handler: func(c *gin.Context, tx *gorm.DB, _ *struct{}) (meOut, error) {
-			uid := c.GetString("userId")
+		Handler: func(c *gin.Context, tx *gorm.DB, _ *struct{}) (userOut, error) {
+			uid := auth.UserID(c)
 			if uid == "" {
-				return meOut{}, httpez.Unauthorized("unauthorized")
+				return userOut{}, httpez.Unauthorized("unauthorized")
 			}
 			var u user.UserModel
 			if err := tx.Where("id = ?", uid).First(&u).Error; err != nil {
 				if errors.Is(err, gorm.ErrRecordNotFound) {
-					return meOut{}, httpez.NotFound("user not found")
+					return userOut{}, httpez.NotFound("user not found")
+				}
+				return userOut{}, httpez.Internal("db error", err)
+			}
+			return toUserOut(&u), nil
+		},
+	})
+
+	// /me/password：改密后旧的访问令牌 / 刷新令牌全部失效，返回一对新令牌
+	type passwordIn struct {
+		OldPassword string `json:"oldPassword" binding:"required"`
+		NewPassword string `json:"newPassword" binding:"required,min=8,max=72"`
+	}
+	httpez.RegisterAction[passwordIn, *tokenOut](ezAuth, db, httpez.Action[passwordIn, *tokenOut]{
+		Method:  http.MethodPost,
+		Path:    "/me/password",
+		Binder:  httpez.BindJSON,
+		Auth:    true,
+		UseTx:   true, // 改密与吊销旧令牌一起提交
+		Summary: "修改密码",
+		Handler: func(c *gin.Context, tx *gorm.DB, in *passwordIn) (*tokenOut, error) {
+			var u user.UserModel
+			if err := tx.Where("id = ?", auth.UserID(c)).First(&u).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil, httpez.NotFound("user not found")
 				}
-				return meOut{}, httpez.Internal("db error", err)
+				return nil, httpez.Internal("db error", err)
+			}
+			if !utils.CheckPassword(in.OldPassword, u.PasswordHash) {
+				return nil, httpez.Fail(user.CodeInvalidCredentials, nil)
+			}
+			if err := tx.Model(&u).Update("password_hash", utils.HashPassword(in.NewPassword)).Error; err != nil {
+				return nil, httpez.Internal("update password failed", err)
 			}
-			return meOut{ID: u.ID, Email: u.Email, Name: u.Name, Role: u.Role}, nil
+			if err := revokeUserTokens(c, tx, jwter, refresh, u.ID); err != nil {
+				return nil, httpez.Internal("revoke tokens failed", err)
+			}
+			// 新令牌提交后再签：claims.ver 要取升级后的版本
+			out := &tokenOut{}
+			httpez.AfterCommit(c, func() (err error) {
+				*out, err = issueTokens(c, &u, "")
+				return err
+			})
+			return out, nil
 		},
 	})
+
+	// 邮箱验证 / 找回密码
+	mountAccountActions(ezPublic, ezAuth, db, jwter, refresh, mail)
+}
+
+// revokeUserTokens 用户全部令牌失效：访问令牌升版本，刷新令牌全部吊销
+// 须在动作事务 tx 里调用（UseTx）：与业务改动一起提交或回滚；版本缓存提交后再刷新
+func revokeUserTokens(c *gin.Context, tx *gorm.DB, jwter *auth.JWTer, refresh *auth.RefreshStore, uid string) error {
+	if jwter.Revocations != nil {
+		if err := jwter.Revocations.BumpTx(tx, uid); err != nil {
+			return err
+		}
+		httpez.AfterCommit(c, func() error {
+			if err := jwter.Revocations.SyncVersion(c, uid); err != nil {
+				return httpez.Internal("revoke tokens failed", err)
+			}
+			return nil
+		})
+	}
+	return refresh.WithDB(tx).RevokeUser(c, uid)
 }
 
 func isDupKey(err error) bool {
@@ -0,0 +1,680 @@
+package router_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"go-gin-gorm-starter/internal/core/auth"
+	"go-gin-gorm-starter/internal/core/mailer"
+	"go-gin-gorm-starter/internal/feature/user"
+	httpez "go-gin-gorm-starter/internal/transport/http/ez"
+	mdw "go-gin-gorm-starter/internal/transport/http/middleware"
+	resp "go-gin-gorm-starter/internal/transport/http/response"
+	"go-gin-gorm-starter/internal/transport/http/router"
+	"go-gin-gorm-starter/pkg/utils"
+)
+
+// 整条鉴权链：登录签发 → AuthJWT 解析 → Principal → ez.RegisterAction / ez.Crud / 管理端 → 吊销
+
+type env struct {
+	db    *gorm.DB
+	jwter *auth.JWTer
+	api   *gin.Engine
+	admin *gin.Engine
+	mail  *outbox
+}
+
+// outbox 记下发出的邮件
+type outbox struct {
+	mu    sync.Mutex
+	msgs  []mailer.Message
+	delay time.Duration // 模拟慢 SMTP
+}
+
+func (o *outbox) Send(_ context.Context, m mailer.Message) error {
+	time.Sleep(o.delay)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.msgs = append(o.msgs, m)
+	return nil
+}
+
+// sentTo 发给 to 的邮件数
+func (o *outbox) sentTo(to string) int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	n := 0
+	for _, m := range o.msgs {
+		if m.To == to {
+			n++
+		}
+	}
+	return n
+}
+
+// token 取发给 to 的最后一封 subject 邮件里链接的令牌（有的邮件后台发送，稍等片刻）
+func (o *outbox) token(t *testing.T, to, subject string) string {
+	t.Helper()
+	for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if tok, ok := o.find(t, to, subject); ok {
+			return tok
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("no %q mail to %s", subject, to)
+			return ""
+		}
+	}
+}
+
+func (o *outbox) find(t *testing.T, to, subject string) (string, bool) {
+	t.Helper()
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i := len(o.msgs) - 1; i >= 0; i-- {
+		m := o.msgs[i]
+		if m.To != to || m.Subject != subject {
+			continue
+		}
+		_, rest, ok := strings.Cut(m.Text, "?token=")
+		if !ok {
+			t.Fatalf("no link in mail %q", m.Text)
+		}
+		tok, _, _ := strings.Cut(rest, "\n")
+		return tok, true
+	}
+	return "", false
+}
+
+func newEnv(t *testing.T) *env {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	revocations, err := auth.NewRevocationStore(db, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwter := &auth.JWTer{
+		Secret: []byte("test-secret"), Issuer: "test", TTL: time.Minute, RefreshTTL: time.Hour,
+		Revocations: revocations,
+	}
+	mail := &outbox{}
+	return &env{
+		db:    db,
+		jwter: jwter,
+		api:   router.NewAPIEngine(zap.NewNop(), db, jwter, router.Mail{Mailer: mail, LinkBaseURL: "http://app.test", TokenSecret: []byte("test-token-secret")}, resp.ModeStatus),
+		admin: router.NewAdminEngine(zap.NewNop(), db, jwter, resp.ModeStatus),
+		mail:  mail,
+	}
+}
+
+type envelope struct {
+	Code int             `json:"code"`
+	Msg  string          `json:"msg"`
+	Data json.RawMessage `json:"data"`
+}
+
+func call(t *testing.T, h http.Handler, method, path, token string, body any) (int, envelope) {
+	t.Helper()
+	var rd *bytes.Reader
+	if body != nil {
+		b, _ := json.Marshal(body)
+		rd = bytes.NewReader(b)
+	} else {
+		rd = bytes.NewReader(nil)
+	}
+	req := httptest.NewRequest(method, path, rd)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	var e envelope
+	if err := json.Unmarshal(w.Body.Bytes(), &e); err != nil {
+		t.Fatalf("%s %s: bad body %q", method, path, w.Body.String())
+	}
+	return w.Code, e
+}
+
+func decode[T any](t *testing.T, e envelope) T {
+	t.Helper()
+	var v T
+	if err := json.Unmarshal(e.Data, &v); err != nil {
+		t.Fatalf("decode %s: %v", e.Data, err)
+	}
+	return v
+}
+
+type tokens struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+	User         struct {
+		ID            string `json:"id"`
+		EmailVerified bool   `json:"emailVerified"`
+	} `json:"user"`
+}
+
+func register(t *testing.T, e *env, email, password string) tokens {
+	t.Helper()
+	status, r := call(t, e.api, http.MethodPost, "/api/v1/auth/register", "", gin.H{"email": email, "password": password})
+	if status != http.StatusOK || r.Code != 0 {
+		t.Fatalf("register %s: %d %+v", email, status, r)
+	}
+	return decode[tokens](t, r)
+}
+
+func login(t *testing.T, e *env, email, password string) tokens {
+	t.Helper()
+	status, r := call(t, e.api, http.MethodPost, "/api/v1/auth/login", "", gin.H{"email": email, "password": password})
+	if status != http.StatusOK || r.Code != 0 {
+		t.Fatalf("login %s: %d %+v", email, status, r)
+	}
+	return decode[tokens](t, r)
+}
+
+func createAdmin(t *testing.T, e *env, email, password string) {
+	t.Helper()
+	u := user.UserModel{ID: utils.NewID(), Email: email, Name: "admin", PasswordHash: utils.HashPassword(password), Role: "admin"}
+	if err := e.db.Create(&u).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAuthJWTPopulatesPrincipalForActions(t *testing.T) {
+	e := newEnv(t)
+	tok := register(t, e, "alice@example.com", "password1")
+
+	status, r := call(t, e.api, http.MethodGet, "/api/v1/me", tok.Token, nil)
+	if status != http.StatusOK || r.Code != 0 {
+		t.Fatalf("GET /me: %d %+v", status, r)
+	}
+	if me := decode[struct{ ID, Email string }](t, r); me.ID != tok.User.ID || me.Email != "alice@example.com" {
+		t.Fatalf("GET /me returned %+v, want user %s", me, tok.User.ID)
+	}
+
+	if status, _ := call(t, e.api, http.MethodGet, "/api/v1/me", "", nil); status != http.StatusUnauthorized {
+		t.Fatalf("GET /me without token: %d, want 401", status)
+	}
+	if status, _ := call(t, e.api, http.MethodGet, "/api/v1/me", tok.Token+"x", nil); status != http.StatusUnauthorized {
+		t.Fatalf("GET /me with bad token: %d, want 401", status)
+	}
+}
+
+type note struct {
+	ID      string `gorm:"primaryKey;size:36" json:"id"`
+	OwnerID string `gorm:"index;size:36" json:"ownerId"`
+	Title   string `json:"title" binding:"required"`
+}
+
+type whoami struct {
+	UserID   string   `json:"userId"`
+	Roles    []string `json:"roles"`
+	TokenID  string   `json:"tokenId"`
+	Method   string   `json:"method"`
+	FromReq  string   `json:"fromRequestContext"`
+	FromStmt string   `json:"fromStatementContext"`
+}
+
+// 业务引擎：鉴权分组下挂 Crud 与读取 Principal 的动作
+func crudEngine(t *testing.T, e *env) *gin.Engine {
+	t.Helper()
+	if err := e.db.AutoMigrate(&note{}); err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	r.Use(resp.UseMode(resp.ModeStatus))
+	g := r.Group("/api/v1")
+	g.Use(mdw.AuthJWT(e.jwter, ""))
+	httpez.Crud(httpez.CrudConfig[note]{
+		DB: e.db, Group: g, Path: "/notes", New: func() *note { return &note{} },
+		AllowCreate: true, AllowList: true, AllowGet: true, AllowDelete: true,
+		Scope: httpez.Scope{Mode: httpez.ScopeRoleBypass},
+	})
+	httpez.RegisterAction(httpez.New(g), e.db, httpez.Action[struct{}, whoami]{
+		Method: http.MethodGet, Path: "/whoami", Binder: httpez.BindNone, Auth: true,
+		Handler: func(c *gin.Context, tx *gorm.DB, _ *struct{}) (whoami, error) {
+			p := auth.PrincipalFrom(c)
+			return whoami{
+				UserID: p.UserID, Roles: p.Roles, TokenID: p.TokenID, Method: p.Method,
+				FromReq:  auth.UserID(c.Request.Context()),
+				FromStmt: auth.UserID(tx.Statement.Context),
+			}, nil
+		},
+	})
+	httpez.RegisterAction(httpez.New(g), e.db, httpez.Action[struct{}, struct{}]{
+		Method: http.MethodGet, Path: "/admin-only", Binder: httpez.BindNone, Auth: true, Roles: []string{"admin"},
+		Handler: func(*gin.Context, *gorm.DB, *struct{}) (struct{}, error) { return struct{}{}, nil },
+	})
+	return r
+}
+
+func TestPrincipalAccessors(t *testing.T) {
+	e := newEnv(t)
+	r := crudEngine(t, e)
+	tok := register(t, e, "bob@example.com", "password1")
+
+	status, res := call(t, r, http.MethodGet, "/api/v1/whoami", tok.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("whoami: %d %+v", status, res)
+	}
+	w := decode[whoami](t, res)
+	if w.UserID != tok.User.ID || w.FromReq != tok.User.ID || w.FromStmt != tok.User.ID {
+		t.Fatalf("principal not propagated: %+v (want %s)", w, tok.User.ID)
+	}
+	if len(w.Roles) != 1 || w.Roles[0] != "user" || w.Method != auth.MethodJWT || w.TokenID == "" {
+		t.Fatalf("unexpected principal %+v", w)
+	}
+
+	if status, _ := call(t, r, http.MethodGet, "/api/v1/admin-only", tok.Token, nil); status != http.StatusForbidden {
+		t.Fatalf("admin-only as user: %d, want 403", status)
+	}
+	createAdmin(t, e, "root@example.com", "password1")
+	adm := login(t, e, "root@example.com", "password1")
+	if status, _ := call(t, r, http.MethodGet, "/api/v1/admin-only", adm.Token, nil); status != http.StatusOK {
+		t.Fatalf("admin-only as admin: %d, want 200", status)
+	}
+}
+
+func TestCrudUsesPrincipalForOwnership(t *testing.T) {
+	e := newEnv(t)
+	r := crudEngine(t, e)
+	alice := register(t, e, "alice@example.com", "password1")
+	bob := register(t, e, "bob@example.com", "password1")
+
+	status, res := call(t, r, http.MethodPost, "/api/v1/notes", alice.Token, gin.H{"title": "hello"})
+	if status != http.StatusOK {
+		t.Fatalf("create: %d %+v", status, res)
+	}
+	n := decode[note](t, res)
+	if n.OwnerID != alice.User.ID {
+		t.Fatalf("ownerId = %q, want %q", n.OwnerID, alice.User.ID)
+	}
+
+	if status, _ := call(t, r, http.MethodGet, "/api/v1/notes/"+n.ID, alice.Token, nil); status != http.StatusOK {
+		t.Fatalf("owner get: %d, want 200", status)
+	}
+	if status, _ := call(t, r, http.MethodGet, "/api/v1/notes/"+n.ID, bob.Token, nil); status != http.StatusNotFound {
+		t.Fatalf("other user get: %d, want 404", status)
+	}
+	if status, _ := call(t, r, http.MethodPost, "/api/v1/notes", "", gin.H{"title": "x"}); status != http.StatusUnauthorized {
+		t.Fatalf("anonymous create: %d, want 401", status)
+	}
+
+	// 管理员按 ScopeRoleBypass 可见全部
+	createAdmin(t, e, "root@example.com", "password1")
+	adm := login(t, e, "root@example.com", "password1")
+	status, res = call(t, r, http.MethodGet, "/api/v1/notes", adm.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("admin list: %d %+v", status, res)
+	}
+	if l := decode[struct{ List []note }](t, res); len(l.List) != 1 {
+		t.Fatalf("admin list = %d items, want 1", len(l.List))
+	}
+}
+
+func TestAdminRoutesAndRevocation(t *testing.T) {
+	e := newEnv(t)
+	alice := register(t, e, "alice@example.com", "password1")
+
+	if status, _ := call(t, e.admin, http.MethodGet, "/admin/v1/users", alice.Token, nil); status != http.StatusForbidden {
+		t.Fatalf("admin list as user: %d, want 403", status)
+	}
+	createAdmin(t, e, "root@example.com", "password1")
+	adm := login(t, e, "root@example.com", "password1")
+	if status, res := call(t, e.admin, http.MethodGet, "/admin/v1/users", adm.Token, nil); status != http.StatusOK {
+		t.Fatalf("admin list: %d %+v", status, res)
+	}
+	if status, _ := call(t, e.admin, http.MethodPost, "/admin/v1/users/"+adm.User.ID+"/ban", adm.Token, nil); status != http.StatusBadRequest {
+		t.Fatalf("self ban: %d, want 400", status)
+	}
+
+	// 封禁后旧的访问令牌与刷新令牌立即失效
+	if status, res := call(t, e.admin, http.MethodPost, "/admin/v1/users/"+alice.User.ID+"/ban", adm.Token, nil); status != http.StatusOK {
+		t.Fatalf("ban: %d %+v", status, res)
+	}
+	if status, _ := call(t, e.api, http.MethodGet, "/api/v1/me", alice.Token, nil); status != http.StatusUnauthorized {
+		t.Fatalf("banned user /me: %d, want 401", status)
+	}
+	if status, res := call(t, e.api, http.MethodPost, "/api/v1/auth/refresh", "", gin.H{"refreshToken": alice.RefreshToken}); status != http.StatusUnauthorized {
+		t.Fatalf("banned user refresh: %d %+v, want 401", status, res)
+	}
+}
+
+func TestAdminRoleChangeTargetsPathUser(t *testing.T) {
+	e := newEnv(t)
+	alice := register(t, e, "alice@example.com", "password1")
+	bob := register(t, e, "bob@example.com", "password1")
+	createAdmin(t, e, "root@example.com", "password1")
+	adm := login(t, e, "root@example.com", "password1")
+
+	// body 里的 id 不能改写路径上的 :id
+	status, res := call(t, e.admin, http.MethodPut, "/admin/v1/users/"+alice.User.ID+"/role", adm.Token, gin.H{"id": bob.User.ID, "role": "admin"})
+	if status != http.StatusOK {
+		t.Fatalf("change role: %d %+v", status, res)
+	}
+	roles := map[string]string{}
+	var us []user.UserModel
+	e.db.Find(&us)
+	for _, u := range us {
+		roles[u.ID] = u.Role
+	}
+	if roles[alice.User.ID] != "admin" || roles[bob.User.ID] != "user" {
+		t.Fatalf("roles after change: alice=%s bob=%s, want admin/user", roles[alice.User.ID], roles[bob.User.ID])
+	}
+	// 改角色后旧令牌失效
+	if status, _ := call(t, e.api, http.MethodGet, "/api/v1/me", alice.Token, nil); status != http.StatusUnauthorized {
+		t.Fatalf("/me after role change: %d, want 401", status)
+	}
+	if status, _ := call(t, e.api, http.MethodGet, "/api/v1/me", bob.Token, nil); status != http.StatusOK {
+		t.Fatalf("untouched user /me: %d, want 200", status)
+	}
+}
+
+func TestRefreshRotationAndLogout(t *testing.T) {
+	e := newEnv(t)
+	first := register(t, e, "alice@example.com", "password1")
+
+	status, res := call(t, e.api, http.MethodPost, "/api/v1/auth/refresh", "", gin.H{"refreshToken": first.RefreshToken})
+	if status != http.StatusOK {
+		t.Fatalf("refresh: %d %+v", status, res)
+	}
+	second := decode[tokens](t, res)
+	if second.RefreshToken == "" || second.RefreshToken == first.RefreshToken {
+		t.Fatalf("refresh token not rotated")
+	}
+	if status, _ := call(t, e.api, http.MethodGet, "/api/v1/me", second.Token, nil); status != http.StatusOK {
+		t.Fatalf("/me with refreshed token: %d", status)
+	}
+
+	// 重放已轮换的令牌：整条链吊销
+	if _, res := call(t, e.api, http.MethodPost, "/api/v1/auth/refresh", "", gin.H{"refreshToken": first.RefreshToken}); res.Code != user.CodeRefreshReused {
+		t.Fatalf("replay: code %d, want %d", res.Code, user.CodeRefreshReused)
+	}
+	if _, res := call(t, e.api, http.MethodPost, "/api/v1/auth/refresh", "", gin.H{"refreshToken": second.RefreshToken}); res.Code == 0 {
+		t.Fatalf("family not revoked after reuse")
+	}
+
+	// 登出吊销当前访问令牌
+	third := login(t, e, "alice@example.com", "password1")
+	if status, res := call(t, e.api, http.MethodPost, "/api/v1/auth/logout", third.Token, gin.H{"refreshToken": third.RefreshToken}); status != http.StatusOK {
+		t.Fatalf("logout: %d %+v", status, res)
+	}
+	if status, _ := call(t, e.api, http.MethodGet, "/api/v1/me", third.Token, nil); status != http.StatusUnauthorized {
+		t.Fatalf("/me after logout: %d, want 401", status)
+	}
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	e := newEnv(t)
+
+	// 登录不再自动注册
+	if status, res := call(t, e.api, http.MethodPost, "/api/v1/auth/login", "", gin.H{"email": "alice@example.com", "password": "password1"}); status != http.StatusUnauthorized || res.Code != user.CodeInvalidCredentials {
+		t.Fatalf("login unknown email: %d code %d, want 401 / %d", status, res.Code, user.CodeInvalidCredentials)
+	}
+
+	reg := register(t, e, "alice@example.com", "password1")
+	if reg.Token == "" || reg.RefreshToken == "" || reg.User.EmailVerified {
+		t.Fatalf("unexpected register result %+v", reg)
+	}
+	if status, res := call(t, e.api, http.MethodPost, "/api/v1/auth/register", "", gin.H{"email": "alice@example.com", "password": "password2"}); status != http.StatusConflict || res.Code != user.CodeEmailTaken {
+		t.Fatalf("duplicate register: %d code %d, want 409 / %d", status, res.Code, user.CodeEmailTaken)
+	}
+	if status, _ := call(t, e.api, http.MethodPost, "/api/v1/auth/register", "", gin.H{"email": "bob@example.com", "password": "short"}); status != http.StatusBadRequest {
+		t.Fatalf("register with short password: %d, want 400", status)
+	}
+
+	if _, res := call(t, e.api, http.MethodPost, "/api/v1/auth/login", "", gin.H{"email": "alice@example.com", "password": "wrong-password"}); res.Code != user.CodeInvalidCredentials {
+		t.Fatalf("login wrong password: code %d, want %d", res.Code, user.CodeInvalidCredentials)
+	}
+	if got := login(t, e, "alice@example.com", "password1"); got.User.ID != reg.User.ID {
+		t.Fatalf("login user %s, want %s", got.User.ID, reg.User.ID)
+	}
+}
+
+func TestEmailVerification(t *testing.T) {
+	e := newEnv(t)
+	alice := register(t, e, "alice@example.com", "password1")
+	first := e.mail.token(t, "alice@example.com", "Verify your email")
+
+	// 重发后旧链接作废
+	if status, res := call(t, e.api, http.MethodPost, "/api/v1/auth/verify-email/resend", alice.Token, nil); status != http.StatusOK {
+		t.Fatalf("resend: %d %+v", status, res)
+	}
+	if _, res := call(t, e.api, http.MethodPost, "/api/v1/auth/verify-email", "", gin.H{"token": first}); res.Code != user.CodeTokenInvalid {
+		t.Fatalf("superseded token: code %d, want %d", res.Code, user.CodeTokenInvalid)
+	}
+
+	tok := e.mail.token(t, "alice@example.com", "Verify your email")
+	if _, res := call(t, e.api, http.MethodPost, "/api/v1/auth/verify-email", "", gin.H{"token": tok + "x"}); res.Code != user.CodeTokenInvalid {
+		t.Fatalf("tampered token: code %d, want %d", res.Code, user.CodeTokenInvalid)
+	}
+	if status, res := call(t, e.api, http.MethodPost, "/api/v1/auth/verify-email", "", gin.H{"token": tok}); status != http.StatusOK {
+		t.Fatalf("verify: %d %+v", status, res)
+	}
+	if _, res := call(t, e.api, http.MethodPost, "/api/v1/auth/verify-email", "", gin.H{"token": tok}); res.Code != user.CodeTokenInvalid {
+		t.Fatalf("token reuse: code %d, want %d", res.Code, user.CodeTokenInvalid)
+	}
+
+	_, res := call(t, e.api, http.MethodGet, "/api/v1/me", alice.Token, nil)
+	if me := decode[struct{ EmailVerified bool }](t, res); !me.EmailVerified {
+		t.Fatalf("email not verified after consuming token")
+	}
+	if status, res := call(t, e.api, http.MethodPost, "/api/v1/auth/verify-email/resend", alice.Token, nil); status != http.StatusConflict || res.Code != user.CodeEmailAlreadyVerified {
+		t.Fatalf("resend after verify: %d code %d", status, res.Code)
+	}
+}
+
+func TestPasswordReset(t *testing.T) {
+	e := newEnv(t)
+	alice := register(t, e, "alice@example.com", "password1")
+
+	// 未注册邮箱同样返回成功，且不发信
+	if status, _ := call(t, e.api, http.MethodPost, "/api/v1/auth/forgot-password", "", gin.H{"email": "nobody@example.com"}); status != http.StatusOK {
+		t.Fatalf("forgot unknown email: %d, want 200", status)
+	}
+	// 邮件后台发送：慢 SMTP 不拖慢响应，已注册与未注册邮箱耗时一致
+	e.mail.delay = 300 * time.Millisecond
+	start := time.Now()
+	if status, _ := call(t, e.api, http.MethodPost, "/api/v1/auth/forgot-password", "", gin.H{"email": "alice@example.com"}); status != http.StatusOK {
+		t.Fatalf("forgot: %d, want 200", status)
+	}
+	if d := time.Since(start); d >= e.mail.delay {
+		t.Fatalf("forgot-password waited for the mailer (%s)", d)
+	}
+	tok := e.mail.token(t, "alice@example.com", "Reset your password")
+	e.mail.delay = 0
+	if e.mail.sentTo("nobody@example.com") != 0 {
+		t.Fatalf("mail sent to unknown address")
+	}
+
+	// 验证邮件的令牌不能用来重置密码
+	verify := e.mail.token(t, "alice@example.com", "Verify your email")
+	if _, res := call(t, e.api, http.MethodPost, "/api/v1/auth/reset-password", "", gin.H{"token": verify, "newPassword": "password2"}); res.Code != user.CodeTokenInvalid {
+		t.Fatalf("reset with verify token: code %d, want %d", res.Code, user.CodeTokenInvalid)
+	}
+
+	if status, res := call(t, e.api, http.MethodPost, "/api/v1/auth/reset-password", "", gin.H{"token": tok, "newPassword": "password2"}); status != http.StatusOK {
+		t.Fatalf("reset: %d %+v", status, res)
+	}
+	if _, res := call(t, e.api, http.MethodPost, "/api/v1/auth/reset-password", "", gin.H{"token": tok, "newPassword": "password3"}); res.Code != user.CodeTokenInvalid {
+		t.Fatalf("reset token reuse: code %d, want %d", res.Code, user.CodeTokenInvalid)
+	}
+
+	// 旧令牌全部失效，新密码可登录，邮箱视为已验证
+	if status, _ := call(t, e.api, http.MethodGet, "/api/v1/me", alice.Token, nil); status != http.StatusUnauthorized {
+		t.Fatalf("/me with pre-reset token: %d, want 401", status)
+	}
+	if _, res := call(t, e.api, http.MethodPost, "/api/v1/auth/refresh", "", gin.H{"refreshToken": alice.RefreshToken}); res.Code == 0 {
+		t.Fatalf("pre-reset refresh token still valid")
+	}
+	if _, res := call(t, e.api, http.MethodPost, "/api/v1/auth/login", "", gin.H{"email": "alice@example.com", "password": "password1"}); res.Code != user.CodeInvalidCredentials {
+		t.Fatalf("login with old password: code %d", res.Code)
+	}
+	if got := login(t, e, "alice@example.com", "password2"); !got.User.EmailVerified {
+		t.Fatalf("email not marked verified after reset")
+	}
+}
+
+func TestPasswordResetIsAtomic(t *testing.T) {
+	e := newEnv(t)
+	alice := register(t, e, "alice@example.com", "password1")
+
+	// 一次性令牌不用 JWT 密钥签：拿 jwt.secret 伪造的令牌无效
+	forged, err := (&auth.OneTimeTokens{DB: e.db, Secret: e.jwter.Secret}).Issue(context.Background(), alice.User.ID, auth.PurposeResetPassword, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, res := call(t, e.api, http.MethodPost, "/api/v1/auth/reset-password", "", gin.H{"token": forged, "newPassword": "password2"}); res.Code != user.CodeTokenInvalid {
+		t.Fatalf("token signed with jwt secret: code %d, want %d", res.Code, user.CodeTokenInvalid)
+	}
+
+	call(t, e.api, http.MethodPost, "/api/v1/auth/forgot-password", "", gin.H{"email": "alice@example.com"})
+	tok := e.mail.token(t, "alice@example.com", "Reset your password")
+
+	// 吊销旧令牌失败：改密和用掉令牌一并回滚
+	if err := e.db.Migrator().DropTable(&auth.TokenVersion{}); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := call(t, e.api, http.MethodPost, "/api/v1/auth/reset-password", "", gin.H{"token": tok, "newPassword": "password2"}); status != http.StatusInternalServerError {
+		t.Fatalf("reset with broken revocation store: %d, want 500", status)
+	}
+	if err := e.db.AutoMigrate(&auth.TokenVersion{}); err != nil {
+		t.Fatal(err)
+	}
+	login(t, e, "alice@example.com", "password1")
+
+	// 令牌未被用掉，可重试
+	if status, res := call(t, e.api, http.MethodPost, "/api/v1/auth/reset-password", "", gin.H{"token": tok, "newPassword": "password2"}); status != http.StatusOK {
+		t.Fatalf("retry reset: %d %+v", status, res)
+	}
+	login(t, e, "alice@example.com", "password2")
+}
+
+func TestRevokingActionsAreAtomic(t *testing.T) {
+	e := newEnv(t)
+	alice := register(t, e, "alice@example.com", "password1")
+	createAdmin(t, e, "root@example.com", "password1")
+	adm := login(t, e, "root@example.com", "password1")
+
+	// 吊销刷新令牌失败：封禁、改角色、改密连同升版本全部回滚
+	if err := e.db.Migrator().DropTable(&auth.RefreshToken{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		h            http.Handler
+		method, path string
+		token        string
+		body         any
+	}{
+		{e.admin, http.MethodPost, "/admin/v1/users/" + alice.User.ID + "/ban", adm.Token, nil},
+		{e.admin, http.MethodPut, "/admin/v1/users/" + alice.User.ID + "/role", adm.Token, gin.H{"role": "admin"}},
+		{e.api, http.MethodPost, "/api/v1/me/password", alice.Token, gin.H{"oldPassword": "password1", "newPassword": "password2"}},
+	} {
+		if status, _ := call(t, tc.h, tc.method, tc.path, tc.token, tc.body); status != http.StatusInternalServerError {
+			t.Errorf("%s %s with broken revocation store: %d, want 500", tc.method, tc.path, status)
+		}
+	}
+	if err := e.db.AutoMigrate(&auth.RefreshToken{}); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := call(t, e.api, http.MethodGet, "/api/v1/me", alice.Token, nil); status != http.StatusOK {
+		t.Fatalf("alice token after rollback: %d, want 200", status)
+	}
+	var u user.UserModel
+	if err := e.db.Where("id = ?", alice.User.ID).First(&u).Error; err != nil || u.Role != "user" {
+		t.Fatalf("alice after rollback: %+v %v", u, err)
+	}
+	login(t, e, "alice@example.com", "password1")
+
+	// 改密成功：旧令牌失效，返回的新令牌带上新版本，立即可用
+	status, res := call(t, e.api, http.MethodPost, "/api/v1/me/password", alice.Token, gin.H{"oldPassword": "password1", "newPassword": "password2"})
+	if status != http.StatusOK {
+		t.Fatalf("change password: %d %+v", status, res)
+	}
+	fresh := decode[tokens](t, res)
+	if status, _ := call(t, e.api, http.MethodGet, "/api/v1/me", alice.Token, nil); status != http.StatusUnauthorized {
+		t.Fatalf("old token after password change: %d, want 401", status)
+	}
+	if status, res := call(t, e.api, http.MethodGet, "/api/v1/me", fresh.Token, nil); status != http.StatusOK {
+		t.Fatalf("new token after password change: %d %+v", status, res)
+	}
+	if status, res := call(t, e.api, http.MethodPost, "/api/v1/auth/refresh", "", gin.H{"refreshToken": fresh.RefreshToken}); status != http.StatusOK {
+		t.Fatalf("new refresh token: %d %+v", status, res)
+	}
+}
+
+func TestJWKSEndpoint(t *testing.T) {
+	e := newEnv(t)
+	jwks := func() auth.JWKS {
+		t.Helper()
+		w := httptest.NewRecorder()
+		e.api.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+		var set auth.JWKS
+		if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &set) != nil {
+			t.Fatalf("jwks: %d %s", w.Code, w.Body)
+		}
+		return set
+	}
+	// 只用 HS256 时为空集（标准格式，不走统一信封）
+	if set := jwks(); set.Keys == nil || len(set.Keys) != 0 {
+		t.Fatalf("jwks without keys: %+v", set)
+	}
+
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	der, _ := x509.MarshalPKCS8PrivateKey(priv)
+	path := filepath.Join(t.TempDir(), "ed.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ks, err := auth.LoadKeySet("k1", []auth.KeyFile{{ID: "k1", PrivateKey: path}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.jwter.Keys = ks
+
+	// 发布的公钥与签发令牌的 kid 对得上，令牌可正常使用
+	set := jwks()
+	if len(set.Keys) != 1 || set.Keys[0].Kid != "k1" || set.Keys[0].Alg != "EdDSA" || set.Keys[0].Kty != "OKP" {
+		t.Fatalf("jwks: %+v", set)
+	}
+	alice := register(t, e, "alice@example.com", "password1")
+	if h := strings.SplitN(alice.Token, ".", 2)[0]; !strings.Contains(string(mustB64(t, h)), `"kid":"k1"`) {
+		t.Fatalf("token header %s lacks kid", mustB64(t, h))
+	}
+	if status, _ := call(t, e.api, http.MethodGet, "/api/v1/me", alice.Token, nil); status != http.StatusOK {
+		t.Fatalf("/me with asymmetric token: %d", status)
+	}
+}
+
+func mustB64(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
@@ -1,6 +1,10 @@
 package utils
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
 
 func HashPassword(pw string) string {
 	b, _ := bcrypt.GenerateFromPassword([]byte(pw), bcrypt.DefaultCost)
@@ -9,3 +13,11 @@ func HashPassword(pw string) string {
 func CheckPassword(pw, hashed string) bool {
 	return bcrypt.CompareHashAndPassword([]byte(hashed), []byte(pw)) == nil
 }
+
+// 固定的占位哈希（与 HashPassword 同 cost），首次用到时生成
+var dummyHash = sync.OnceValue(func() string { return HashPassword("dummy-password") })
+
+// CheckPasswordTiming 用户不存在时调用：与占位哈希比对一次，耗时与真实校验相同
+func CheckPasswordTiming(pw string) {
+	_ = CheckPassword(pw, dummyHash())
+}